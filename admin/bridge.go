@@ -0,0 +1,360 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/backfill"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+)
+
+var tokenABI, _ = ethabi.JSON(strings.NewReader(abi.WrappedTokenABI))
+
+// Topics of wrapped token and ownable events
+var (
+	MinterAddedTopic          = tokenABI.Events["MinterAdded"].ID
+	MinterRemovedTopic        = tokenABI.Events["MinterRemoved"].ID
+	OwnershipTransferredTopic = tokenABI.Events["OwnershipTransferred"].ID
+)
+
+// Ownable contract targets of TransferOwnership
+const (
+	OwnableBridge  = "bridge"
+	OwnableToken   = "token"
+	OwnableFee     = "fee"
+	OwnableLimiter = "limiter"
+)
+
+// Bridge operates a configured bridge contract
+type Bridge struct {
+	config.Bridge
+	Backend multiclient.Backend
+}
+
+// NewBridge creates bridge operator
+func NewBridge(b config.Bridge, backend multiclient.Backend) *Bridge {
+	return &Bridge{Bridge: b, Backend: backend}
+}
+
+func (b *Bridge) base() *abi.BridgeBase {
+	bridge, _ := abi.NewBridgeBase(b.Address, b.Backend)
+	return bridge
+}
+
+func (b *Bridge) change(action, current, new string, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (Change, error) {
+	c := Change{
+		Target:  b.String(),
+		Action:  action,
+		Current: current,
+		New:     new,
+		send:    send,
+	}
+	if current == new {
+		return c, fmt.Errorf("%w; %s %s is already %s", ErrNoChange, action, b, current)
+	}
+	return c, nil
+}
+
+// Logs calls handle with logs of contract on the chain of bridge in block order,
+// logs are queried in chunks from Start of bridge to head
+func (b *Bridge) Logs(ctx context.Context, contract common.Address, topics []common.Hash, handle func(l types.Log) error) error {
+	head, err := b.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can not get head of %s; %w", b.Chain, err)
+	}
+
+	bf := &backfill.Backfiller{
+		Filterer: b.Backend,
+		Query:    ethereum.FilterQuery{Addresses: []common.Address{contract}, Topics: [][]common.Hash{topics}},
+	}
+	return bf.Run(ctx, b.Start, head.Number.Uint64(), func(from, to uint64, logs []types.Log) error {
+		for _, l := range logs {
+			if l.Removed {
+				continue
+			}
+			if err := handle(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Token returns token address of locker or burner bridge
+func (b *Bridge) Token(ctx context.Context) (common.Address, error) {
+	if b.Type == config.TypeEther {
+		return common.Address{}, fmt.Errorf("%s has no token", b)
+	}
+
+	// locker and burner have the same token() signature
+	burner, _ := abi.NewBridgeBurner(b.Address, b.Backend)
+	token, err := burner.Token(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("can not get token of %s; %w", b, err)
+	}
+	return token, nil
+}
+
+// Limiter returns limiter address of bridge
+func (b *Bridge) Limiter(ctx context.Context) (common.Address, error) {
+	limiter, err := b.base().GetLimiter(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("can not get limiter of %s; %w", b, err)
+	}
+	return limiter, nil
+}
+
+// Fee returns fee address of bridge
+func (b *Bridge) Fee(ctx context.Context) (common.Address, error) {
+	fee, err := b.base().GetFee(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("can not get fee of %s; %w", b, err)
+	}
+	return fee, nil
+}
+
+func (b *Bridge) pause(ctx context.Context, paused bool) (Change, error) {
+	current, err := b.base().Paused(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Change{}, fmt.Errorf("can not get paused of %s; %w", b, err)
+	}
+
+	action := "unpause"
+	if paused {
+		action = "pause"
+	}
+	return b.change(action, fmt.Sprintf("paused=%t", current), fmt.Sprintf("paused=%t", paused), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if paused {
+			return b.base().Pause(opts)
+		}
+		return b.base().Unpause(opts)
+	})
+}
+
+// Pause pauses bridge
+func (b *Bridge) Pause(ctx context.Context) (Change, error) {
+	return b.pause(ctx, true)
+}
+
+// Unpause unpauses bridge
+func (b *Bridge) Unpause(ctx context.Context) (Change, error) {
+	return b.pause(ctx, false)
+}
+
+// SetFee sets fee contract of bridge
+func (b *Bridge) SetFee(ctx context.Context, fee common.Address) (Change, error) {
+	current, err := b.Fee(ctx)
+	if err != nil {
+		return Change{}, err
+	}
+
+	return b.change("setFee", current.Hex(), fee.Hex(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return b.base().SetFee(opts, fee)
+	})
+}
+
+// SetLimiter sets limiter contract of bridge
+func (b *Bridge) SetLimiter(ctx context.Context, limiter common.Address) (Change, error) {
+	current, err := b.Limiter(ctx)
+	if err != nil {
+		return Change{}, err
+	}
+
+	return b.change("setLimiter", current.Hex(), limiter.Hex(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return b.base().SetLimiter(opts, limiter)
+	})
+}
+
+// SetLimit sets daily limit of bridge on its LimiterDaily
+func (b *Bridge) SetLimit(ctx context.Context, limit *big.Int) (Change, error) {
+	limiterAddr, err := b.Limiter(ctx)
+	if err != nil {
+		return Change{}, err
+	}
+	if limiterAddr == (common.Address{}) {
+		return Change{}, fmt.Errorf("%s has no limiter", b)
+	}
+
+	limiter, _ := abi.NewLimiterDaily(limiterAddr, b.Backend)
+	current, err := limiter.GetLimit(&bind.CallOpts{Context: ctx}, b.Address)
+	if err != nil {
+		return Change{}, fmt.Errorf("can not get limit of %s; %w", b, err)
+	}
+
+	return b.change("LimiterDaily.setLimit", current.String(), limit.String(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return limiter.SetLimit(opts, b.Address, limit)
+	})
+}
+
+// SetFeeAmount sets fee amount of bridge FeeFixed
+func (b *Bridge) SetFeeAmount(ctx context.Context, amount *big.Int) (Change, error) {
+	feeAddr, err := b.Fee(ctx)
+	if err != nil {
+		return Change{}, err
+	}
+	if feeAddr == (common.Address{}) {
+		return Change{}, fmt.Errorf("%s has no fee", b)
+	}
+
+	fee, _ := abi.NewFeeFixed(feeAddr, b.Backend)
+	current, err := fee.Fee(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Change{}, fmt.Errorf("can not get fee amount of %s; %w", b, err)
+	}
+
+	return b.change("FeeFixed.setFee", current.String(), amount.String(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return fee.SetFee(opts, amount)
+	})
+}
+
+func (b *Bridge) minter(ctx context.Context, minter common.Address, add bool) (Change, error) {
+	if b.Type != config.TypeBurner {
+		return Change{}, fmt.Errorf("%s is not a burner", b)
+	}
+
+	tokenAddr, err := b.Token(ctx)
+	if err != nil {
+		return Change{}, err
+	}
+	token, _ := abi.NewWrappedToken(tokenAddr, b.Backend)
+	current, err := token.Mintable(&bind.CallOpts{Context: ctx}, minter)
+	if err != nil {
+		return Change{}, fmt.Errorf("can not get mintable of %s; %w", minter.Hex(), err)
+	}
+
+	action := "WrappedToken.removeMinter"
+	if add {
+		action = "WrappedToken.addMinter"
+	}
+	return b.change(action, fmt.Sprintf("mintable(%s)=%t", minter.Hex(), current), fmt.Sprintf("mintable(%s)=%t", minter.Hex(), add), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if add {
+			return token.AddMinter(opts, minter)
+		}
+		return token.RemoveMinter(opts, minter)
+	})
+}
+
+// AddMinter adds minter to wrapped token of burner bridge
+func (b *Bridge) AddMinter(ctx context.Context, minter common.Address) (Change, error) {
+	return b.minter(ctx, minter, true)
+}
+
+// RemoveMinter removes minter from wrapped token of burner bridge
+func (b *Bridge) RemoveMinter(ctx context.Context, minter common.Address) (Change, error) {
+	return b.minter(ctx, minter, false)
+}
+
+// Ownable returns address of bridge or its token, fee or limiter contract
+func (b *Bridge) Ownable(ctx context.Context, of string) (common.Address, error) {
+	switch of {
+	case OwnableBridge:
+		return b.Address, nil
+	case OwnableToken:
+		if b.Type != config.TypeBurner {
+			return common.Address{}, fmt.Errorf("%s token is not owned by bridge operator", b)
+		}
+		return b.Token(ctx)
+	case OwnableFee:
+		return b.Fee(ctx)
+	case OwnableLimiter:
+		return b.Limiter(ctx)
+	}
+	return common.Address{}, fmt.Errorf("unknown ownable %q", of)
+}
+
+// TransferOwnership transfers ownership of bridge or its token, fee or limiter contract
+func (b *Bridge) TransferOwnership(ctx context.Context, of string, newOwner common.Address) (Change, error) {
+	addr, err := b.Ownable(ctx, of)
+	if err != nil {
+		return Change{}, err
+	}
+	if addr == (common.Address{}) {
+		return Change{}, fmt.Errorf("%s has no %s", b, of)
+	}
+
+	ownable, _ := abi.NewOwnable(addr, b.Backend)
+	current, err := ownable.Owner(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Change{}, fmt.Errorf("can not get owner of %s; %w", addr.Hex(), err)
+	}
+
+	c, err := b.change("transferOwnership", current.Hex(), newOwner.Hex(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ownable.TransferOwnership(opts, newOwner)
+	})
+	c.Action = fmt.Sprintf("%s.transferOwnership", of)
+	return c, err
+}
+
+// Decimals returns decimals of bridge asset, native asset has 18 decimals
+func (b *Bridge) Decimals(ctx context.Context) (uint8, error) {
+	if b.Type == config.TypeEther {
+		return 18, nil
+	}
+
+	tokenAddr, err := b.Token(ctx)
+	if err != nil {
+		return 0, err
+	}
+	token, _ := abi.NewIERC20Metadata(tokenAddr, b.Backend)
+	decimals, err := token.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("can not get decimals of %s; %w", tokenAddr.Hex(), err)
+	}
+	return decimals, nil
+}
+
+// Minters returns current minters of wrapped token of burner bridge,
+// candidates are collected from MinterAdded events since Start and verified with mintable
+func (b *Bridge) Minters(ctx context.Context) ([]common.Address, error) {
+	if b.Type != config.TypeBurner {
+		return nil, fmt.Errorf("%s is not a burner", b)
+	}
+
+	tokenAddr, err := b.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, _ := abi.NewWrappedToken(tokenAddr, b.Backend)
+
+	seen := map[common.Address]bool{}
+	var r []common.Address
+	err = b.Logs(ctx, tokenAddr, []common.Hash{MinterAddedTopic}, func(l types.Log) error {
+		e, err := token.ParseMinterAdded(l)
+		if err != nil {
+			return fmt.Errorf("can not parse minter added log; %w", err)
+		}
+		if seen[e.Minter] {
+			return nil
+		}
+		seen[e.Minter] = true
+
+		mintable, err := token.Mintable(&bind.CallOpts{Context: ctx}, e.Minter)
+		if err != nil {
+			return fmt.Errorf("can not get mintable of %s; %w", e.Minter.Hex(), err)
+		}
+		if mintable {
+			r = append(r, e.Minter)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not replay minters of %s; %w", tokenAddr.Hex(), err)
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		return bytes.Compare(r[i][:], r[j][:]) < 0
+	})
+	return r, nil
+}
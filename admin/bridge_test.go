@@ -0,0 +1,168 @@
+package admin_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/testutil"
+)
+
+func TestBridge(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, tokenAddr, "kDAI Burner", decimal.EtherToWei("0.1"))
+	_, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0.1"))
+
+	b := admin.NewBridge(config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "DAI <=> kDAI", Side: config.SideDestination}, ctx.Backend)
+	locker := admin.NewBridge(config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr, Pair: "DAI <=> kDAI", Side: config.SideSource}, ctx.Backend)
+
+	t.Run("Pause", func(t *testing.T) {
+		c, err := b.Pause(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "paused=false", c.Current)
+		require.Equal(t, "paused=true", c.New)
+
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		paused, err := burner.Paused(nil)
+		require.NoError(t, err)
+		require.True(t, paused)
+
+		_, err = b.Pause(ctx)
+		require.ErrorIs(t, err, admin.ErrNoChange)
+
+		c, err = b.Unpause(ctx)
+		require.NoError(t, err)
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		paused, err = burner.Paused(nil)
+		require.NoError(t, err)
+		require.False(t, paused)
+	})
+
+	t.Run("Not owner", func(t *testing.T) {
+		c, err := b.Pause(ctx)
+		require.NoError(t, err)
+
+		_, err = c.Send(ctx.Wallets[1].TxOpts)
+		require.Error(t, err)
+	})
+
+	t.Run("SetFeeAmount", func(t *testing.T) {
+		c, err := b.SetFeeAmount(ctx, decimal.EtherToWei("0.2"))
+		require.NoError(t, err)
+		require.Equal(t, decimal.EtherToWei("0.1").String(), c.Current)
+		require.Equal(t, decimal.EtherToWei("0.2").String(), c.New)
+
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		fee, err := burner.CalculateFee(nil, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		require.Equal(t, decimal.EtherToWei("0.2").String(), fee.String())
+	})
+
+	t.Run("SetLimiter and SetLimit", func(t *testing.T) {
+		_, err := b.SetLimit(ctx, decimal.EtherToWei("100"))
+		require.Error(t, err)
+
+		limiterAddr, _, _, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		c, err := b.SetLimiter(ctx, limiterAddr)
+		require.NoError(t, err)
+		require.Equal(t, common.Address{}.Hex(), c.Current)
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		c, err = b.SetLimit(ctx, decimal.EtherToWei("100"))
+		require.NoError(t, err)
+		require.Equal(t, "0", c.Current)
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		limiter, err := abi.NewLimiterDaily(limiterAddr, ctx.Backend)
+		require.NoError(t, err)
+		limit, err := limiter.GetLimit(nil, burnerAddr)
+		require.NoError(t, err)
+		require.Equal(t, decimal.EtherToWei("100").String(), limit.String())
+	})
+
+	t.Run("Minter", func(t *testing.T) {
+		_, err := locker.AddMinter(ctx, burnerAddr)
+		require.Error(t, err)
+
+		c, err := b.AddMinter(ctx, burnerAddr)
+		require.NoError(t, err)
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		mintable, err := token.Mintable(nil, burnerAddr)
+		require.NoError(t, err)
+		require.True(t, mintable)
+
+		minters, err := b.Minters(ctx)
+		require.NoError(t, err)
+		require.Contains(t, minters, burnerAddr)
+
+		// events before start block are not replayed
+		head, err := ctx.Backend.HeaderByNumber(ctx, nil)
+		require.NoError(t, err)
+		late := *b
+		late.Start = head.Number.Uint64() + 1
+		minters, err = late.Minters(ctx)
+		require.NoError(t, err)
+		require.Empty(t, minters)
+
+		_, err = b.AddMinter(ctx, burnerAddr)
+		require.ErrorIs(t, err, admin.ErrNoChange)
+
+		c, err = b.RemoveMinter(ctx, burnerAddr)
+		require.NoError(t, err)
+		_, err = c.Send(owner.TxOpts)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		mintable, err = token.Mintable(nil, burnerAddr)
+		require.NoError(t, err)
+		require.False(t, mintable)
+	})
+
+	t.Run("TransferOwnership", func(t *testing.T) {
+		newOwner := ctx.Wallets[2]
+
+		for _, of := range []string{admin.OwnableFee, admin.OwnableLimiter, admin.OwnableToken, admin.OwnableBridge} {
+			c, err := b.TransferOwnership(ctx, of, newOwner.Address)
+			require.NoError(t, err)
+			require.Equal(t, owner.Address.Hex(), c.Current)
+			require.Equal(t, newOwner.Address.Hex(), c.New)
+
+			_, err = c.Send(owner.TxOpts)
+			require.NoError(t, err)
+			ctx.Backend.Commit()
+
+			_, err = b.TransferOwnership(ctx, of, newOwner.Address)
+			require.ErrorIs(t, err, admin.ErrNoChange)
+		}
+
+		_, err := locker.TransferOwnership(ctx, admin.OwnableToken, newOwner.Address)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoChange is returned when new value equals current value
+var ErrNoChange = errors.New("admin: no change")
+
+// Change is a pending admin transaction with its preview
+type Change struct {
+	Target  string
+	Action  string
+	Current string
+	New     string
+
+	send func(opts *bind.TransactOpts) (*types.Transaction, error)
+}
+
+// String returns preview of change
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s\n  current: %s\n  new:     %s", c.Action, c.Target, c.Current, c.New)
+}
+
+// Send sends change transaction
+func (c Change) Send(opts *bind.TransactOpts) (*types.Transaction, error) {
+	tx, err := c.send(opts)
+	if err != nil {
+		return nil, fmt.Errorf("can not %s %s; %w", c.Action, c.Target, err)
+	}
+	return tx, nil
+}
+
+// Execute sends change transaction and waits until it is mined
+func (c Change) Execute(ctx context.Context, opts *bind.TransactOpts, backend bind.DeployBackend) (*types.Transaction, *types.Receipt, error) {
+	tx, err := c.Send(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		return tx, nil, fmt.Errorf("can not wait tx %s; %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return tx, receipt, fmt.Errorf("tx %s reverted", tx.Hash().Hex())
+	}
+	return tx, receipt, nil
+}
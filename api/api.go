@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/unlocker"
+)
+
+// Status is the public status of a transfer
+type Status string
+
+const (
+	// StatusPendingConfirmations lock is not confirmed on source chain yet
+	StatusPendingConfirmations Status = "pending_confirmations"
+	// StatusAwaitingUnlock lock is confirmed and waits for unlock
+	StatusAwaitingUnlock Status = "awaiting_unlock"
+	// StatusUnlocked unlocked on destination chain
+	StatusUnlocked Status = "unlocked"
+	// StatusFailed unlocked with different account or amount
+	StatusFailed Status = "failed"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Heads returns head block of chain
+type Heads func(ctx context.Context, chain string) (uint64, error)
+
+// Server serves transfer status from the indexed transfer history
+type Server struct {
+	DB     *indexer.DB
+	Chains map[string]config.Chain
+	Heads  Heads
+	// Bridges returns paused and limiter state of bridges, nil disables /bridges
+	Bridges func() []unlocker.BridgeState
+	// NearCap is ratio of daily limit reported as near cap, 0.9 by default
+	NearCap float64
+	// Log receives database and rpc errors, which are not sent to callers
+	Log func(format string, v ...interface{})
+}
+
+// Handler returns http handler of api:
+//
+//	GET /transfers/{lock tx hash}
+//	GET /accounts/{address}/transfers?limit=&offset=
+//	GET /stats?pair=
+//	GET /bridges
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transfers/", s.transfer)
+	mux.HandleFunc("/accounts/", s.accountTransfers)
+	mux.HandleFunc("/stats", s.stats)
+	if s.Bridges != nil {
+		mux.HandleFunc("/bridges", s.bridges)
+	}
+	return mux
+}
+
+// Side is a transfer side
+type Side struct {
+	Chain string    `json:"chain"`
+	Tx    string    `json:"tx"`
+	TxURL string    `json:"txUrl"`
+	Block uint64    `json:"block"`
+	Time  time.Time `json:"time"`
+}
+
+// Transfer is a transfer response, amounts are in smallest unit
+type Transfer struct {
+	Hash          string `json:"hash"`
+	Pair          string `json:"pair"`
+	Status        Status `json:"status"`
+	Account       string `json:"account"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	Confirmations uint64 `json:"confirmations"`
+	// Required is confirmations required before unlock
+	Required    uint64 `json:"requiredConfirmations"`
+	Source      Side   `json:"source"`
+	Destination *Side  `json:"destination,omitempty"`
+	// Latency is seconds from lock to unlock
+	Latency float64 `json:"latency,omitempty"`
+}
+
+// Page is a page of transfers
+type Page struct {
+	Transfers []Transfer `json:"transfers"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+	// Next is offset of next page, omitted on last page
+	Next *int `json:"next,omitempty"`
+}
+
+// Stats is stats of a pair
+type Stats struct {
+	Pair      string `json:"pair"`
+	Transfers int    `json:"transfers"`
+	Pending   int    `json:"pending"`
+	Unlocked  int    `json:"unlocked"`
+	Failed    int    `json:"failed"`
+	Volume    string `json:"volume"`
+	Fees      string `json:"fees"`
+	// AvgLatency is average seconds from lock to unlock
+	AvgLatency float64    `json:"avgLatency"`
+	LastLock   *time.Time `json:"lastLock,omitempty"`
+}
+
+// Bridge is paused and limiter state of a bridge, amounts are in smallest unit
+type Bridge struct {
+	Pair    string `json:"pair"`
+	Side    string `json:"side"`
+	Chain   string `json:"chain"`
+	Address string `json:"address"`
+	Paused  bool   `json:"paused"`
+	// LimiterUsage and LimiterLimit are omitted without limiter, zero limit is unlimited
+	LimiterUsage string `json:"limiterUsage,omitempty"`
+	LimiterLimit string `json:"limiterLimit,omitempty"`
+	NearCap      bool   `json:"nearCap"`
+	Block        uint64 `json:"block"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, errorResponse{msg})
+}
+
+// fail logs err and responds with a fixed message, errors may contain
+// rpc endpoint urls with provider api keys
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if s.Log != nil {
+		s.Log("%s %s; %v", r.Method, r.URL.Path, err)
+	}
+	msg := "internal error"
+	if code == http.StatusBadGateway {
+		msg = "upstream unavailable"
+	}
+	writeError(w, code, msg)
+}
+
+func get(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	return true
+}
+
+// NewTransfer converts indexed transfer to response, head is head block of source chain
+func NewTransfer(t indexer.Transfer, chains map[string]config.Chain, head uint64) Transfer {
+	source := chains[t.Lock.Chain]
+	r := Transfer{
+		Hash:     t.Lock.Hash.Hex(),
+		Pair:     t.Lock.Pair,
+		Account:  t.Lock.Account.Hex(),
+		Amount:   t.Lock.Amount.String(),
+		Fee:      t.Lock.Fee.String(),
+		Required: source.Confirmations,
+		Source: Side{
+			Chain: t.Lock.Chain,
+			Tx:    t.Lock.Hash.Hex(),
+			TxURL: source.TxURL(t.Lock.Hash),
+			Block: t.Lock.Block,
+			Time:  t.Lock.Time,
+		},
+	}
+	if head >= t.Lock.Block {
+		r.Confirmations = head - t.Lock.Block + 1
+	}
+
+	switch t.Status {
+	case indexer.StatusLocked:
+		r.Status = StatusAwaitingUnlock
+		if r.Confirmations < r.Required {
+			r.Status = StatusPendingConfirmations
+		}
+	case indexer.StatusUnlocked:
+		r.Status = StatusUnlocked
+	case indexer.StatusMismatch:
+		r.Status = StatusFailed
+	}
+
+	if u := t.Unlock; u != nil {
+		r.Destination = &Side{
+			Chain: u.Chain,
+			Tx:    u.Tx.Hex(),
+			TxURL: chains[u.Chain].TxURL(u.Tx),
+			Block: u.Block,
+			Time:  u.Time,
+		}
+		r.Latency = t.Latency.Seconds()
+	}
+	return r
+}
+
+// HeadCache caches head of chains during a request
+type HeadCache struct {
+	Heads Heads
+	heads map[string]uint64
+}
+
+// Get returns head of chain
+func (c *HeadCache) Get(ctx context.Context, chain string) (uint64, error) {
+	if head, ok := c.heads[chain]; ok {
+		return head, nil
+	}
+	head, err := c.Heads(ctx, chain)
+	if err != nil {
+		return 0, err
+	}
+	if c.heads == nil {
+		c.heads = map[string]uint64{}
+	}
+	c.heads[chain] = head
+	return head, nil
+}
+
+func (s *Server) response(ctx context.Context, t indexer.Transfer, heads *HeadCache) (Transfer, error) {
+	head, err := heads.Get(ctx, t.Lock.Chain)
+	if err != nil {
+		return Transfer{}, err
+	}
+	return NewTransfer(t, s.Chains, head), nil
+}
+
+func (s *Server) transfer(w http.ResponseWriter, r *http.Request) {
+	if !get(w, r) {
+		return
+	}
+
+	hash := strings.TrimPrefix(r.URL.Path, "/transfers/")
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		writeError(w, http.StatusBadRequest, "invalid transaction hash")
+		return
+	}
+
+	t, err := s.DB.Transfer(r.Context(), common.HexToHash(hash))
+	if errors.Is(err, indexer.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := s.response(r.Context(), t, &HeadCache{Heads: s.Heads})
+	if err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) accountTransfers(w http.ResponseWriter, r *http.Request) {
+	if !get(w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+	if len(parts) != 2 || parts[1] != "transfers" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !common.IsHexAddress(parts[0]) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+	account := common.HexToAddress(parts[0])
+
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// one more to know whether there is a next page
+	transfers, err := s.DB.Transfers(r.Context(), indexer.Query{Account: &account, Limit: limit + 1, Offset: offset})
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	page := Page{Transfers: []Transfer{}, Limit: limit, Offset: offset}
+	if len(transfers) > limit {
+		transfers = transfers[:limit]
+		next := offset + limit
+		page.Next = &next
+	}
+	heads := &HeadCache{Heads: s.Heads}
+	for _, t := range transfers {
+		resp, err := s.response(r.Context(), t, heads)
+		if err != nil {
+			s.fail(w, r, http.StatusBadGateway, err)
+			return
+		}
+		page.Transfers = append(page.Transfers, resp)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func pagination(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	limit, offset = defaultLimit, 0
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			writeError(w, http.StatusBadRequest, "limit must be 1-"+strconv.Itoa(maxLimit))
+			return 0, 0, false
+		}
+		limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset")
+			return 0, 0, false
+		}
+		offset = n
+	}
+	return limit, offset, true
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	if !get(w, r) {
+		return
+	}
+
+	stats, err := s.DB.Stats(r.Context(), r.URL.Query().Get("pair"))
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := []Stats{}
+	for _, st := range stats {
+		v := Stats{
+			Pair:       st.Pair,
+			Transfers:  st.Transfers,
+			Pending:    st.Locked,
+			Unlocked:   st.Unlocked,
+			Failed:     st.Mismatch,
+			Volume:     st.Volume.String(),
+			Fees:       st.Fees.String(),
+			AvgLatency: st.AvgLatency.Seconds(),
+		}
+		if !st.LastLock.IsZero() {
+			last := st.LastLock
+			v.LastLock = &last
+		}
+		resp = append(resp, v)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) bridges(w http.ResponseWriter, r *http.Request) {
+	if !get(w, r) {
+		return
+	}
+
+	ratio := s.NearCap
+	if ratio == 0 {
+		ratio = 0.9
+	}
+	resp := []Bridge{}
+	for _, st := range s.Bridges() {
+		v := Bridge{
+			Pair:    st.Bridge.Pair,
+			Side:    st.Bridge.Side,
+			Chain:   st.Bridge.Chain,
+			Address: st.Bridge.Address.Hex(),
+			Paused:  st.Paused,
+			NearCap: st.NearCap(ratio),
+			Block:   st.Block,
+		}
+		if st.Limit != nil {
+			v.LimiterUsage = st.Usage.String()
+			v.LimiterLimit = st.Limit.String()
+		}
+		resp = append(resp, v)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
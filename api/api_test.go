@@ -0,0 +1,223 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/api"
+	"killswitch/bridge/config"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/unlocker"
+)
+
+const pair = "DAI <=> kDAI"
+
+func setup(t *testing.T) (*httptest.Server, common.Address) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	account := common.HexToAddress("0xa4e3a7DE03D4138620EEc38766C06d175dF64963")
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// lock i at block 10+i, locks 0 and 1 are unlocked, lock 1 with wrong amount
+	var locks []indexer.Lock
+	for i := 0; i < 5; i++ {
+		locks = append(locks, indexer.Lock{
+			Hash:    common.Hash{byte(i + 1)},
+			Pair:    pair,
+			Chain:   "bsc",
+			Account: account,
+			Amount:  big.NewInt(100),
+			Fee:     big.NewInt(1),
+			Block:   uint64(10 + i),
+			Time:    at.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	require.NoError(t, db.Save(ctx, pair, config.SideSource, 0, 20, locks, nil))
+	require.NoError(t, db.Save(ctx, pair, config.SideDestination, 0, 20, nil, []indexer.Unlock{
+		{Hash: locks[0].Hash, Pair: pair, Chain: "bkc", Account: account, Amount: big.NewInt(100), Tx: common.Hash{0xa1}, Block: 5, Time: at.Add(90 * time.Second)},
+		{Hash: locks[1].Hash, Pair: pair, Chain: "bkc", Account: account, Amount: big.NewInt(99), Tx: common.Hash{0xa2}, Block: 6, Time: at.Add(3 * time.Minute)},
+	}))
+
+	s := &api.Server{
+		DB: db,
+		Chains: map[string]config.Chain{
+			"bsc": {Name: "bsc", Explorer: "https://bscscan.com", Confirmations: 3},
+			"bkc": {Name: "bkc", Explorer: "https://bkcscan.com", Confirmations: 3},
+		},
+		Heads: func(ctx context.Context, chain string) (uint64, error) {
+			return 14, nil
+		},
+		Bridges: func() []unlocker.BridgeState {
+			return []unlocker.BridgeState{
+				{
+					Bridge: config.Bridge{Chain: "bsc", Address: common.Address{1}, Pair: pair, Side: config.SideSource},
+					Paused: true,
+					Usage:  big.NewInt(95),
+					Limit:  big.NewInt(100),
+					Block:  14,
+				},
+				{
+					Bridge: config.Bridge{Chain: "bkc", Address: common.Address{2}, Pair: pair, Side: config.SideDestination},
+					Block:  7,
+				},
+			}
+		},
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	return server, account
+}
+
+func get(t *testing.T, url string, code int, v interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, code, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func TestTransfer(t *testing.T) {
+	server, account := setup(t)
+
+	var tr api.Transfer
+	get(t, server.URL+"/transfers/"+common.Hash{1}.Hex(), http.StatusOK, &tr)
+	require.Equal(t, api.StatusUnlocked, tr.Status)
+	require.Equal(t, pair, tr.Pair)
+	require.Equal(t, account.Hex(), tr.Account)
+	require.Equal(t, "100", tr.Amount)
+	require.Equal(t, "1", tr.Fee)
+	require.Equal(t, "https://bscscan.com/tx/"+common.Hash{1}.Hex(), tr.Source.TxURL)
+	require.Equal(t, common.Hash{0xa1}.Hex(), tr.Destination.Tx)
+	require.Equal(t, "https://bkcscan.com/tx/"+common.Hash{0xa1}.Hex(), tr.Destination.TxURL)
+	require.Equal(t, 90.0, tr.Latency)
+
+	get(t, server.URL+"/transfers/"+common.Hash{2}.Hex(), http.StatusOK, &tr)
+	require.Equal(t, api.StatusFailed, tr.Status)
+
+	tr = api.Transfer{}
+	get(t, server.URL+"/transfers/"+common.Hash{3}.Hex(), http.StatusOK, &tr)
+	require.Equal(t, api.StatusAwaitingUnlock, tr.Status)
+	require.Equal(t, uint64(3), tr.Confirmations)
+	require.Nil(t, tr.Destination)
+
+	get(t, server.URL+"/transfers/"+common.Hash{5}.Hex(), http.StatusOK, &tr)
+	require.Equal(t, api.StatusPendingConfirmations, tr.Status)
+	require.Equal(t, uint64(1), tr.Confirmations)
+	require.Equal(t, uint64(3), tr.Required)
+
+	var e map[string]string
+	get(t, server.URL+"/transfers/"+common.Hash{9}.Hex(), http.StatusNotFound, &e)
+	require.Equal(t, "transfer not found", e["error"])
+	get(t, server.URL+"/transfers/0x12", http.StatusBadRequest, &e)
+}
+
+func TestAccountTransfers(t *testing.T) {
+	server, account := setup(t)
+	url := server.URL + "/accounts/" + account.Hex() + "/transfers"
+
+	var page api.Page
+	get(t, url+"?limit=2", http.StatusOK, &page)
+	require.Len(t, page.Transfers, 2)
+	require.Equal(t, common.Hash{5}.Hex(), page.Transfers[0].Hash)
+	require.Equal(t, common.Hash{4}.Hex(), page.Transfers[1].Hash)
+	require.Equal(t, 2, *page.Next)
+
+	page = api.Page{}
+	get(t, url+"?limit=2&offset=4", http.StatusOK, &page)
+	require.Len(t, page.Transfers, 1)
+	require.Equal(t, common.Hash{1}.Hex(), page.Transfers[0].Hash)
+	require.Nil(t, page.Next)
+
+	page = api.Page{}
+	get(t, server.URL+"/accounts/"+common.Address{1}.Hex()+"/transfers", http.StatusOK, &page)
+	require.Empty(t, page.Transfers)
+	require.Equal(t, 20, page.Limit)
+
+	var e map[string]string
+	get(t, url+"?limit=1000", http.StatusBadRequest, &e)
+	get(t, server.URL+"/accounts/0x1/transfers", http.StatusBadRequest, &e)
+}
+
+func TestStats(t *testing.T) {
+	server, _ := setup(t)
+
+	var stats []api.Stats
+	get(t, server.URL+"/stats", http.StatusOK, &stats)
+	require.Len(t, stats, 1)
+	require.Equal(t, pair, stats[0].Pair)
+	require.Equal(t, 5, stats[0].Transfers)
+	require.Equal(t, 3, stats[0].Pending)
+	require.Equal(t, 1, stats[0].Unlocked)
+	require.Equal(t, 1, stats[0].Failed)
+	require.Equal(t, "500", stats[0].Volume)
+	require.Equal(t, "5", stats[0].Fees)
+	require.Equal(t, 90.0, stats[0].AvgLatency)
+
+	stats = nil
+	get(t, server.URL+"/stats?pair=unknown", http.StatusOK, &stats)
+	require.Empty(t, stats)
+}
+
+func TestBridges(t *testing.T) {
+	server, _ := setup(t)
+
+	var bridges []api.Bridge
+	get(t, server.URL+"/bridges", http.StatusOK, &bridges)
+	require.Equal(t, []api.Bridge{
+		{Pair: pair, Side: config.SideSource, Chain: "bsc", Address: common.Address{1}.Hex(), Paused: true, LimiterUsage: "95", LimiterLimit: "100", NearCap: true, Block: 14},
+		{Pair: pair, Side: config.SideDestination, Chain: "bkc", Address: common.Address{2}.Hex(), Block: 7},
+	}, bridges)
+}
+
+func TestErrors(t *testing.T) {
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Save(context.Background(), pair, config.SideSource, 0, 1, []indexer.Lock{
+		{Hash: common.Hash{1}, Pair: pair, Chain: "bsc", Amount: big.NewInt(1), Fee: big.NewInt(0), Block: 1, Time: time.Now()},
+	}, nil))
+
+	var logged []string
+	s := &api.Server{
+		DB: db,
+		Heads: func(ctx context.Context, chain string) (uint64, error) {
+			return 0, errors.New(`Post "https://bsc.example.com/v1/secret-key": dial tcp: i/o timeout`)
+		},
+		Log: func(format string, v ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, v...))
+		},
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	var e map[string]string
+	get(t, server.URL+"/transfers/"+common.Hash{1}.Hex(), http.StatusBadGateway, &e)
+	require.Equal(t, "upstream unavailable", e["error"])
+	require.Len(t, logged, 1)
+	require.Contains(t, logged[0], "secret-key")
+
+	db.Close()
+	get(t, server.URL+"/stats", http.StatusInternalServerError, &e)
+	require.Equal(t, "internal error", e["error"])
+}
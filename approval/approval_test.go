@@ -0,0 +1,126 @@
+package approval_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/testutil"
+	"killswitch/bridge/unlocker"
+)
+
+func TestGate(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	_, tokenAddr := testutil.DeployTokenWith(ctx, owner, "USDC", "USDC", 6)
+	_, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "USDC Locker", decimal.EtherToWei("0"))
+
+	store, err := approval.OpenStore(filepath.Join(t.TempDir(), "approvals.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	var queued []approval.Request
+	g := &approval.Gate{
+		Store: store,
+		Pair: config.Pair{
+			Name:     "USDC <=> kUSDC",
+			Source:   config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr},
+			Approval: &config.Approval{Threshold: "1000.5", Approvals: 2},
+		},
+		Backend: ctx.Backend,
+		OnQueued: func(r approval.Request) {
+			queued = append(queued, r)
+		},
+	}
+
+	job := func(hash byte, amount string) unlocker.Job {
+		v, err := decimal.ToUnit(amount, 6)
+		require.NoError(t, err)
+		return unlocker.Job{Hash: common.Hash{hash}, Account: user.Address, Amount: v}
+	}
+	jobs := []unlocker.Job{job(1, "1000.5"), job(2, "1000.500001"), job(3, "5000")}
+
+	ready, err := g.Ready(ctx, jobs)
+	require.NoError(t, err)
+	require.Equal(t, jobs[:1], ready, "threshold is normalized with 6 decimals")
+	require.Len(t, queued, 2)
+	require.Equal(t, approval.StatusPending, queued[0].Status)
+	require.Equal(t, 2, queued[0].Required)
+	require.Equal(t, uint8(6), queued[0].Decimals)
+
+	_, err = g.Ready(ctx, jobs)
+	require.NoError(t, err)
+	require.Len(t, queued, 2, "queued once")
+
+	pending, err := store.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	r, err := store.Approve(ctx, jobs[1].Hash, "alice", approval.ViaCLI)
+	require.NoError(t, err)
+	require.Equal(t, approval.StatusPending, r.Status)
+	_, err = store.Approve(ctx, jobs[1].Hash, "alice", approval.ViaSlack)
+	require.ErrorIs(t, err, approval.ErrDuplicate)
+
+	ready, err = g.Ready(ctx, jobs)
+	require.NoError(t, err)
+	require.Equal(t, jobs[:1], ready, "one of two approvals")
+
+	r, err = store.Approve(ctx, jobs[1].Hash, "bob", approval.ViaSlack)
+	require.NoError(t, err)
+	require.Equal(t, approval.StatusApproved, r.Status)
+	require.Equal(t, []string{"alice", "bob"}, r.Approvers)
+
+	_, err = store.Reject(ctx, jobs[2].Hash, "carol", approval.ViaCLI, "account under review")
+	require.NoError(t, err)
+	_, err = store.Approve(ctx, jobs[2].Hash, "alice", approval.ViaCLI)
+	require.ErrorIs(t, err, approval.ErrDecided)
+
+	ready, err = g.Ready(ctx, jobs)
+	require.NoError(t, err)
+	require.Equal(t, jobs[:2], ready)
+
+	r, err = store.Submitted(ctx, jobs[1].Hash, common.Hash{0xaa})
+	require.NoError(t, err)
+	require.Equal(t, approval.StatusSubmitted, r.Status)
+	require.Equal(t, common.Hash{0xaa}, *r.Tx)
+	_, err = store.Submitted(ctx, jobs[2].Hash, common.Hash{0xbb})
+	require.Error(t, err)
+
+	_, err = store.Approve(ctx, common.Hash{9}, "alice", approval.ViaCLI)
+	require.ErrorIs(t, err, approval.ErrNotFound)
+
+	t.Run("Audit log", func(t *testing.T) {
+		log, err := store.Log(ctx, &jobs[1].Hash, 10)
+		require.NoError(t, err)
+
+		var actions []approval.Action
+		for _, e := range log {
+			actions = append(actions, e.Action)
+		}
+		require.Equal(t, []approval.Action{approval.ActionRequested, approval.ActionApproved, approval.ActionApproved, approval.ActionSubmitted}, actions)
+		require.Equal(t, "alice", log[1].Actor)
+		require.Equal(t, approval.ViaCLI, log[1].Via)
+		require.Equal(t, "2 of 2", log[2].Note)
+
+		all, err := store.Log(ctx, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		require.Equal(t, approval.ActionSubmitted, all[1].Action)
+		require.Equal(t, "account under review", all[0].Note)
+	})
+
+	t.Run("Without approval", func(t *testing.T) {
+		g := &approval.Gate{Store: store, Pair: config.Pair{Name: "free"}}
+		ready, err := g.Ready(ctx, jobs)
+		require.NoError(t, err)
+		require.Equal(t, jobs, ready)
+	})
+}
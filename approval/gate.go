@@ -0,0 +1,83 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// Gate queues unlocks of a pair above its approval threshold
+// and releases them to relayer once approved,
+// it is called from the unlock loop of the relayer, which is not part of this repository
+type Gate struct {
+	Store *Store
+	Pair  config.Pair
+	// Backend is client of source chain, used to normalize threshold with asset decimals
+	Backend multiclient.Backend
+
+	// OnQueued is called once when unlock is queued for approval
+	OnQueued func(r Request)
+
+	decimals  uint8
+	threshold *big.Int
+}
+
+func (g *Gate) init(ctx context.Context) error {
+	if g.threshold != nil {
+		return nil
+	}
+
+	decimals, err := admin.NewBridge(g.Pair.Source, g.Backend).Decimals(ctx)
+	if err != nil {
+		return err
+	}
+	threshold, err := g.Pair.Approval.Amount(decimals)
+	if err != nil {
+		return fmt.Errorf("invalid approval threshold of %s; %w", g.Pair.Name, err)
+	}
+	g.decimals, g.threshold = decimals, threshold
+	return nil
+}
+
+// Ready returns jobs up to threshold and approved jobs above it in order,
+// other jobs are queued for approval and must be passed again until they are returned
+func (g *Gate) Ready(ctx context.Context, jobs []unlocker.Job) ([]unlocker.Job, error) {
+	if g.Pair.Approval == nil {
+		return jobs, nil
+	}
+	if err := g.init(ctx); err != nil {
+		return nil, err
+	}
+
+	var r []unlocker.Job
+	for _, job := range jobs {
+		if job.Amount.Cmp(g.threshold) <= 0 {
+			r = append(r, job)
+			continue
+		}
+
+		req, created, err := g.Store.Request(ctx, Request{
+			Hash:     job.Hash,
+			Pair:     g.Pair.Name,
+			Account:  job.Account,
+			Amount:   job.Amount,
+			Decimals: g.decimals,
+			Required: g.Pair.Approval.Required(),
+		})
+		if err != nil {
+			return r, err
+		}
+		if created && g.OnQueued != nil {
+			g.OnQueued(req)
+		}
+		if req.Status == StatusApproved || req.Status == StatusSubmitted {
+			r = append(r, job)
+		}
+	}
+	return r, nil
+}
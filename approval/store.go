@@ -0,0 +1,353 @@
+package approval
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	// sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrNotFound is returned when unlock is not in approval queue
+	ErrNotFound = errors.New("approval: not found")
+	// ErrDecided is returned when approving or rejecting an unlock which is not pending
+	ErrDecided = errors.New("approval: already decided")
+	// ErrDuplicate is returned when approver already approved the unlock
+	ErrDuplicate = errors.New("approval: already approved by approver")
+)
+
+// Status is status of a queued unlock
+type Status string
+
+const (
+	// StatusPending waits for approvals
+	StatusPending Status = "pending"
+	// StatusApproved has required approvals, relayer may submit it
+	StatusApproved Status = "approved"
+	// StatusRejected is never submitted
+	StatusRejected Status = "rejected"
+	// StatusSubmitted unlock transaction was sent
+	StatusSubmitted Status = "submitted"
+)
+
+// Action is an audit log action
+type Action string
+
+// Audit log actions
+const (
+	ActionRequested Action = "requested"
+	ActionApproved  Action = "approved"
+	ActionRejected  Action = "rejected"
+	ActionSubmitted Action = "submitted"
+)
+
+// Approval channels
+const (
+	ViaRelayer = "relayer"
+	ViaCLI     = "cli"
+	ViaSlack   = "slack"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS requests (
+	hash TEXT PRIMARY KEY,
+	pair TEXT NOT NULL,
+	account TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	decimals INTEGER NOT NULL,
+	required INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	tx TEXT NOT NULL DEFAULT '',
+	created INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS requests_status ON requests (status, created);
+
+CREATE TABLE IF NOT EXISTS approvals (
+	hash TEXT NOT NULL REFERENCES requests (hash),
+	approver TEXT NOT NULL,
+	PRIMARY KEY (hash, approver)
+);
+
+CREATE TABLE IF NOT EXISTS log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hash TEXT NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	via TEXT NOT NULL,
+	note TEXT NOT NULL,
+	time INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS log_hash ON log (hash, id);
+`
+
+// Request is an unlock in approval queue, Amount is in smallest unit
+type Request struct {
+	Hash     common.Hash
+	Pair     string
+	Account  common.Address
+	Amount   *big.Int
+	Decimals uint8
+	Required int
+	// Approvers approved the unlock in order
+	Approvers []string
+	Status    Status
+	// Tx is unlock transaction once submitted
+	Tx      *common.Hash
+	Created time.Time
+}
+
+// Entry is an audit log entry
+type Entry struct {
+	ID     int64
+	Hash   common.Hash
+	Action Action
+	Actor  string
+	// Via is channel of action: relayer, cli or slack
+	Via  string
+	Note string
+	Time time.Time
+}
+
+// Store persists approval queue and its audit log in sqlite
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// OpenStore opens or creates sqlite database at path
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("can not open %s; %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can not create schema; %w", err)
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close closes database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) log(ctx context.Context, tx *sql.Tx, hash common.Hash, action Action, actor, via, note string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO log (hash, action, actor, via, note, time) VALUES (?, ?, ?, ?, ?, ?)`,
+		hash.Hex(), string(action), actor, via, note, s.now().Unix())
+	if err != nil {
+		return fmt.Errorf("can not write audit log; %w", err)
+	}
+	return nil
+}
+
+// update runs fn in transaction with current request of hash and returns updated request
+func (s *Store) update(ctx context.Context, hash common.Hash, fn func(tx *sql.Tx, r Request) error) (Request, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Request{}, err
+	}
+	defer tx.Rollback()
+
+	r, err := get(ctx, tx, hash)
+	if err != nil {
+		return Request{}, err
+	}
+	if err := fn(tx, r); err != nil {
+		return Request{}, err
+	}
+	if r, err = get(ctx, tx, hash); err != nil {
+		return Request{}, err
+	}
+	return r, tx.Commit()
+}
+
+// Request queues unlock for approval and returns it,
+// created is false when it is already queued
+func (s *Store) Request(ctx context.Context, r Request) (_ Request, created bool, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Request{}, false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO requests (hash, pair, account, amount, decimals, required, status, created) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Hash.Hex(), r.Pair, r.Account.Hex(), r.Amount.String(), r.Decimals, r.Required, string(StatusPending), s.now().Unix())
+	if err != nil {
+		return Request{}, false, fmt.Errorf("can not queue %s; %w", r.Hash.Hex(), err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		created = true
+		note := fmt.Sprintf("%s amount %s account %s requires %d approvals", r.Pair, r.Amount, r.Account.Hex(), r.Required)
+		if err := s.log(ctx, tx, r.Hash, ActionRequested, ViaRelayer, ViaRelayer, note); err != nil {
+			return Request{}, false, err
+		}
+	}
+
+	if r, err = get(ctx, tx, r.Hash); err != nil {
+		return Request{}, false, err
+	}
+	return r, created, tx.Commit()
+}
+
+// Approve records approval of approver, request is approved once it has required approvals
+func (s *Store) Approve(ctx context.Context, hash common.Hash, approver, via string) (Request, error) {
+	return s.update(ctx, hash, func(tx *sql.Tx, r Request) error {
+		if r.Status != StatusPending {
+			return ErrDecided
+		}
+		for _, a := range r.Approvers {
+			if a == approver {
+				return ErrDuplicate
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO approvals (hash, approver) VALUES (?, ?)`, hash.Hex(), approver); err != nil {
+			return fmt.Errorf("can not approve %s; %w", hash.Hex(), err)
+		}
+		approvals := len(r.Approvers) + 1
+		if approvals >= r.Required {
+			if _, err := tx.ExecContext(ctx, `UPDATE requests SET status = ? WHERE hash = ?`, string(StatusApproved), hash.Hex()); err != nil {
+				return fmt.Errorf("can not approve %s; %w", hash.Hex(), err)
+			}
+		}
+		return s.log(ctx, tx, hash, ActionApproved, approver, via, fmt.Sprintf("%d of %d", approvals, r.Required))
+	})
+}
+
+// Reject rejects pending request, it is never returned to relayer
+func (s *Store) Reject(ctx context.Context, hash common.Hash, actor, via, reason string) (Request, error) {
+	return s.update(ctx, hash, func(tx *sql.Tx, r Request) error {
+		if r.Status != StatusPending {
+			return ErrDecided
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE requests SET status = ? WHERE hash = ?`, string(StatusRejected), hash.Hex()); err != nil {
+			return fmt.Errorf("can not reject %s; %w", hash.Hex(), err)
+		}
+		return s.log(ctx, tx, hash, ActionRejected, actor, via, reason)
+	})
+}
+
+// Submitted records unlock transaction of approved request
+func (s *Store) Submitted(ctx context.Context, hash, txHash common.Hash) (Request, error) {
+	return s.update(ctx, hash, func(tx *sql.Tx, r Request) error {
+		if r.Status != StatusApproved && r.Status != StatusSubmitted {
+			return fmt.Errorf("approval: %s is %s", hash.Hex(), r.Status)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE requests SET status = ?, tx = ? WHERE hash = ?`, string(StatusSubmitted), txHash.Hex(), hash.Hex()); err != nil {
+			return fmt.Errorf("can not update %s; %w", hash.Hex(), err)
+		}
+		return s.log(ctx, tx, hash, ActionSubmitted, ViaRelayer, ViaRelayer, txHash.Hex())
+	})
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const selectRequest = `SELECT hash, pair, account, amount, decimals, required, status, tx, created,
+	(SELECT COALESCE(GROUP_CONCAT(approver, char(10)), '') FROM (SELECT approver FROM approvals a WHERE a.hash = r.hash ORDER BY rowid))
+	FROM requests r`
+
+func scanRequests(rows *sql.Rows) ([]Request, error) {
+	defer rows.Close()
+
+	var r []Request
+	for rows.Next() {
+		var (
+			req                                          Request
+			hash, account, amount, status, tx, approvers string
+			created                                      int64
+		)
+		if err := rows.Scan(&hash, &req.Pair, &account, &amount, &req.Decimals, &req.Required, &status, &tx, &created, &approvers); err != nil {
+			return nil, err
+		}
+		req.Hash = common.HexToHash(hash)
+		req.Account = common.HexToAddress(account)
+		req.Amount, _ = new(big.Int).SetString(amount, 10)
+		req.Status = Status(status)
+		if tx != "" {
+			h := common.HexToHash(tx)
+			req.Tx = &h
+		}
+		req.Created = time.Unix(created, 0).UTC()
+		if approvers != "" {
+			req.Approvers = strings.Split(approvers, "\n")
+		}
+		r = append(r, req)
+	}
+	return r, rows.Err()
+}
+
+func get(ctx context.Context, q querier, hash common.Hash) (Request, error) {
+	rows, err := q.QueryContext(ctx, selectRequest+` WHERE hash = ?`, hash.Hex())
+	if err != nil {
+		return Request{}, fmt.Errorf("can not query %s; %w", hash.Hex(), err)
+	}
+	r, err := scanRequests(rows)
+	if err != nil {
+		return Request{}, err
+	}
+	if len(r) == 0 {
+		return Request{}, ErrNotFound
+	}
+	return r[0], nil
+}
+
+// Get returns request of unlock
+func (s *Store) Get(ctx context.Context, hash common.Hash) (Request, error) {
+	return get(ctx, s.db, hash)
+}
+
+// Pending returns requests waiting for approvals, oldest first
+func (s *Store) Pending(ctx context.Context) ([]Request, error) {
+	rows, err := s.db.QueryContext(ctx, selectRequest+` WHERE status = ? ORDER BY created, hash`, string(StatusPending))
+	if err != nil {
+		return nil, fmt.Errorf("can not query pending requests; %w", err)
+	}
+	return scanRequests(rows)
+}
+
+// Log returns audit log of unlock, or latest entries of all unlocks when hash is nil, oldest first
+func (s *Store) Log(ctx context.Context, hash *common.Hash, limit int) ([]Entry, error) {
+	query, args := `SELECT id, hash, action, actor, via, note, time FROM log`, []interface{}{}
+	if hash != nil {
+		query += ` WHERE hash = ?`
+		args = append(args, hash.Hex())
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not query audit log; %w", err)
+	}
+	defer rows.Close()
+
+	var r []Entry
+	for rows.Next() {
+		var (
+			e            Entry
+			hash, action string
+			at           int64
+		)
+		if err := rows.Scan(&e.ID, &hash, &action, &e.Actor, &e.Via, &e.Note, &at); err != nil {
+			return nil, err
+		}
+		e.Hash = common.HexToHash(hash)
+		e.Action = Action(action)
+		e.Time = time.Unix(at, 0).UTC()
+		r = append([]Entry{e}, r...)
+	}
+	return r, rows.Err()
+}
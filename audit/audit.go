@@ -0,0 +1,270 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+)
+
+// Check is a single audit check
+type Check struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// Report is audit result of a pair
+type Report struct {
+	Pair   string
+	Checks []Check
+}
+
+// OK returns whether every check passed
+func (r Report) OK() bool {
+	for _, c := range r.Checks {
+		if !c.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes readable report
+func (r Report) Write(w io.Writer) {
+	status := "PASS"
+	if !r.OK() {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "%s %s\n", status, r.Pair)
+	for _, c := range r.Checks {
+		mark := "ok  "
+		if !c.OK {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", mark, c.Name, c.Detail)
+	}
+}
+
+// Auditor audits bridges against the config
+type Auditor struct {
+	// Owner is expected owner of bridges and wrapped tokens, nil only requires a contract owner
+	Owner *common.Address
+}
+
+// Pair audits source and destination bridge of a pair
+func (a *Auditor) Pair(ctx context.Context, name string, source, destination *admin.Bridge) Report {
+	r := Report{Pair: name}
+	for _, b := range []*admin.Bridge{source, destination} {
+		for _, c := range a.Bridge(ctx, b) {
+			c.Name = b.Side + " " + c.Name
+			r.Checks = append(r.Checks, c)
+		}
+	}
+	return r
+}
+
+// Bridge audits owner, token, limiter and limit of a bridge,
+// and owner and minters of its wrapped token when it is a burner
+func (a *Auditor) Bridge(ctx context.Context, b *admin.Bridge) []Check {
+	owner := a.Owner
+	if b.State != nil && b.State.Owner != nil {
+		owner = b.State.Owner
+	}
+
+	r := []Check{a.owner(ctx, b, "owner", b.Address, owner)}
+
+	if b.Type != config.TypeEther {
+		check, token := a.token(ctx, b)
+		r = append(r, check)
+		if b.Type == config.TypeBurner && token != (common.Address{}) {
+			r = append(r, a.owner(ctx, b, "token owner", token, owner))
+			r = append(r, a.minters(ctx, b, token))
+		}
+	}
+
+	return append(r, a.limiter(ctx, b)...)
+}
+
+func (a *Auditor) owner(ctx context.Context, b *admin.Bridge, name string, addr common.Address, expected *common.Address) Check {
+	c := Check{Name: name}
+
+	ownable, _ := abi.NewOwnable(addr, b.Backend)
+	current, err := ownable.Owner(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		c.Detail = fmt.Sprintf("can not get owner of %s; %v", addr.Hex(), err)
+		return c
+	}
+
+	history, err := ownershipHistory(ctx, b, ownable, addr)
+	if err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+
+	code, err := b.Backend.CodeAt(ctx, current, nil)
+	switch {
+	case err != nil:
+		c.Detail = fmt.Sprintf("can not get code of owner %s; %v", current.Hex(), err)
+	case expected != nil && current != *expected:
+		c.Detail = fmt.Sprintf("owner %s, expected %s", current.Hex(), expected.Hex())
+	case len(code) == 0:
+		c.Detail = fmt.Sprintf("owner %s is not a contract", current.Hex())
+	default:
+		c.OK = true
+		c.Detail = current.Hex()
+	}
+	if len(history) > 0 {
+		c.Detail += "; transfers: " + strings.Join(history, ", ")
+	}
+	return c
+}
+
+func ownershipHistory(ctx context.Context, b *admin.Bridge, ownable *abi.Ownable, addr common.Address) ([]string, error) {
+	var r []string
+	err := b.Logs(ctx, addr, []common.Hash{admin.OwnershipTransferredTopic}, func(l types.Log) error {
+		e, err := ownable.ParseOwnershipTransferred(l)
+		if err != nil {
+			return fmt.Errorf("can not parse ownership transferred log; %w", err)
+		}
+		r = append(r, fmt.Sprintf("%s => %s at %d", e.PreviousOwner.Hex(), e.NewOwner.Hex(), e.Raw.BlockNumber))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not replay ownership transferred of %s; %w", addr.Hex(), err)
+	}
+	return r, nil
+}
+
+func (a *Auditor) token(ctx context.Context, b *admin.Bridge) (Check, common.Address) {
+	c := Check{Name: "token"}
+
+	token, err := b.Token(ctx)
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+	case b.TokenAddress == nil && b.Type == config.TypeBurner:
+		c.Detail = fmt.Sprintf("token %s is not in config", token.Hex())
+	case b.TokenAddress != nil && token != *b.TokenAddress:
+		c.Detail = fmt.Sprintf("token %s, expected %s", token.Hex(), b.TokenAddress.Hex())
+	default:
+		c.OK = true
+		c.Detail = token.Hex()
+	}
+	return c, token
+}
+
+// minters replays MinterAdded and MinterRemoved events and requires
+// both the replayed set and mintable to equal the expected minters
+func (a *Auditor) minters(ctx context.Context, b *admin.Bridge, tokenAddr common.Address) Check {
+	c := Check{Name: "minters"}
+	token, _ := abi.NewWrappedToken(tokenAddr, b.Backend)
+
+	// logs are replayed in block order, the last event of a minter wins
+	replayed := map[common.Address]bool{}
+	events := 0
+	err := b.Logs(ctx, tokenAddr, []common.Hash{admin.MinterAddedTopic, admin.MinterRemovedTopic}, func(l types.Log) error {
+		events++
+		if l.Topics[0] == admin.MinterAddedTopic {
+			e, err := token.ParseMinterAdded(l)
+			if err != nil {
+				return fmt.Errorf("can not parse minter added log; %w", err)
+			}
+			replayed[e.Minter] = true
+			return nil
+		}
+		e, err := token.ParseMinterRemoved(l)
+		if err != nil {
+			return fmt.Errorf("can not parse minter removed log; %w", err)
+		}
+		replayed[e.Minter] = false
+		return nil
+	})
+	if err != nil {
+		c.Detail = fmt.Sprintf("can not replay minters of %s; %v", tokenAddr.Hex(), err)
+		return c
+	}
+
+	expected := []common.Address{b.Address}
+	if b.State != nil && b.State.Minters != nil {
+		expected = b.State.Minters
+	}
+	want := map[common.Address]bool{}
+	for _, m := range expected {
+		want[m] = true
+	}
+
+	var problems, minters []string
+	for m, replayedMinter := range replayed {
+		mintable, err := token.Mintable(&bind.CallOpts{Context: ctx}, m)
+		if err != nil {
+			c.Detail = fmt.Sprintf("can not get mintable of %s; %v", m.Hex(), err)
+			return c
+		}
+		if mintable != replayedMinter {
+			problems = append(problems, fmt.Sprintf("%s mintable=%t but events say %t", m.Hex(), mintable, replayedMinter))
+		}
+		if mintable {
+			minters = append(minters, m.Hex())
+			if !want[m] {
+				problems = append(problems, fmt.Sprintf("unexpected minter %s", m.Hex()))
+			}
+		}
+	}
+	for _, m := range expected {
+		if !replayed[m] {
+			problems = append(problems, fmt.Sprintf("missing minter %s", m.Hex()))
+		}
+	}
+
+	sort.Strings(minters)
+	sort.Strings(problems)
+	c.OK = len(problems) == 0
+	c.Detail = fmt.Sprintf("%d events, minters [%s]", events, strings.Join(minters, ", "))
+	if !c.OK {
+		c.Detail = strings.Join(problems, "; ") + "; " + c.Detail
+	}
+	return c
+}
+
+func (a *Auditor) limiter(ctx context.Context, b *admin.Bridge) []Check {
+	c := Check{Name: "limiter"}
+	limiter, err := b.Limiter(ctx)
+	if err != nil {
+		c.Detail = err.Error()
+		return []Check{c}
+	}
+	if limiter == (common.Address{}) {
+		c.Detail = "limiter is not set"
+		return []Check{c}
+	}
+	c.OK = true
+	c.Detail = limiter.Hex()
+
+	limit := Check{Name: "limit"}
+	limiterDaily, _ := abi.NewLimiterDaily(limiter, b.Backend)
+	v, err := limiterDaily.GetLimit(&bind.CallOpts{Context: ctx}, b.Address)
+	switch {
+	case err != nil:
+		limit.Detail = fmt.Sprintf("can not get limit of %s; %v", b, err)
+	case v.Sign() == 0:
+		limit.Detail = "limit is not set"
+	default:
+		limit.OK = true
+		limit.Detail = v.String()
+		if decimals, err := b.Decimals(ctx); err == nil {
+			limit.Detail = decimal.FromUnit(v, decimals)
+		}
+	}
+	return []Check{c, limit}
+}
@@ -0,0 +1,95 @@
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/audit"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/testutil"
+)
+
+func TestAuditor(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	attacker := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, tokenAddr, "kDAI Burner", decimal.EtherToWei("0.1"))
+	// any contract stands in for the multisig
+	_, multisig := testutil.DeployFeeFixed(ctx, owner, decimal.EtherToWei("0"))
+
+	send := func(tx interface{}, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+
+	b := admin.NewBridge(config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, TokenAddress: &tokenAddr, Pair: "DAI <=> kDAI", Side: config.SideDestination}, ctx.Backend)
+	a := &audit.Auditor{Owner: &multisig}
+
+	checks := func() map[string]audit.Check {
+		r := map[string]audit.Check{}
+		for _, c := range a.Bridge(ctx, b) {
+			r[c.Name] = c
+		}
+		return r
+	}
+
+	t.Run("Misconfigured", func(t *testing.T) {
+		r := checks()
+		require.False(t, r["owner"].OK)
+		require.Contains(t, r["owner"].Detail, "expected "+multisig.Hex())
+		require.True(t, r["token"].OK)
+		require.False(t, r["token owner"].OK)
+		require.False(t, r["minters"].OK)
+		require.Contains(t, r["minters"].Detail, "missing minter "+burnerAddr.Hex())
+		require.False(t, r["limiter"].OK)
+		require.NotContains(t, r, "limit")
+	})
+
+	t.Run("Unexpected minter", func(t *testing.T) {
+		send(token.AddMinter(owner.TxOpts, burnerAddr))
+		send(token.AddMinter(owner.TxOpts, attacker.Address))
+
+		c := checks()["minters"]
+		require.False(t, c.OK)
+		require.Contains(t, c.Detail, "unexpected minter "+attacker.Address.Hex())
+
+		send(token.RemoveMinter(owner.TxOpts, attacker.Address))
+		c = checks()["minters"]
+		require.True(t, c.OK, c.Detail)
+		require.Contains(t, c.Detail, "3 events")
+	})
+
+	t.Run("Pass", func(t *testing.T) {
+		limiterAddr, _, limiter, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+		send(nil, err)
+		send(burner.SetLimiter(owner.TxOpts, limiterAddr))
+		send(limiter.SetLimit(owner.TxOpts, burnerAddr, decimal.EtherToWei("100")))
+		send(burner.TransferOwnership(owner.TxOpts, multisig))
+		send(token.TransferOwnership(owner.TxOpts, multisig))
+
+		for _, c := range a.Bridge(ctx, b) {
+			require.True(t, c.OK, "%s: %s", c.Name, c.Detail)
+		}
+		r := checks()
+		require.Equal(t, "100", r["limit"].Detail)
+		require.Contains(t, r["owner"].Detail, "transfers:")
+	})
+
+	t.Run("Token mismatch", func(t *testing.T) {
+		other := common.HexToAddress("0x01")
+		b := admin.NewBridge(config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, TokenAddress: &other}, ctx.Backend)
+		for _, c := range a.Bridge(ctx, b) {
+			if c.Name == "token" {
+				require.False(t, c.OK)
+			}
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rangeErrors are messages of rpc providers rejecting a query over too many blocks or logs
+var rangeErrors = []string{
+	"query returned more than",
+	"too many results",
+	"too many logs",
+	"range too large",
+	"block range",
+	"limit exceeded",
+	"response size",
+	"query timeout",
+}
+
+// IsRangeError reports whether err is a rejection of query size,
+// the same query over a smaller range may succeed
+func IsRangeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler receives logs of a block range, ranges are handled in block order
+type Handler func(from, to uint64, logs []types.Log) error
+
+// Backfiller queries logs over a large block range in chunks,
+// the chunk is halved when the provider rejects the range and grows back on success
+type Backfiller struct {
+	Filterer ethereum.LogFilterer
+	// Query selects addresses and topics, block range is set by backfiller
+	Query ethereum.FilterQuery
+
+	// Chunk is the initial chunk, default 5000
+	Chunk uint64
+	// MaxChunk is the largest chunk, default 100000
+	MaxChunk uint64
+	// Concurrency is number of parallel queries, default 4
+	Concurrency int
+
+	mu    sync.Mutex
+	chunk uint64
+}
+
+func (b *Backfiller) init() {
+	if b.Chunk == 0 {
+		b.Chunk = 5000
+	}
+	if b.MaxChunk == 0 {
+		b.MaxChunk = 100000
+	}
+	if b.MaxChunk < b.Chunk {
+		b.MaxChunk = b.Chunk
+	}
+	if b.Concurrency < 1 {
+		b.Concurrency = 4
+	}
+	if b.chunk == 0 {
+		b.chunk = b.Chunk
+	}
+}
+
+// CurrentChunk returns the chunk used for next query
+func (b *Backfiller) CurrentChunk() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.init()
+	return b.chunk
+}
+
+func (b *Backfiller) shrink(size uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if half := size / 2; half < b.chunk {
+		b.chunk = half
+		if b.chunk == 0 {
+			b.chunk = 1
+		}
+	}
+}
+
+func (b *Backfiller) grow() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.chunk *= 2; b.chunk > b.MaxChunk {
+		b.chunk = b.MaxChunk
+	}
+}
+
+type result struct {
+	from, to uint64
+	logs     []types.Log
+	err      error
+}
+
+// Run queries logs of block range [from, to] and calls handle for each chunk in block order,
+// up to Concurrency chunks are queried in parallel
+func (b *Backfiller) Run(ctx context.Context, from, to uint64, handle Handler) error {
+	b.mu.Lock()
+	b.init()
+	b.mu.Unlock()
+
+	for from <= to {
+		// a wave of consecutive chunks
+		chunk := b.CurrentChunk()
+		var results []*result
+		for i := 0; i < b.Concurrency && from <= to; i++ {
+			end := from + chunk - 1
+			if end > to || end < from {
+				end = to
+			}
+			results = append(results, &result{from: from, to: end})
+			if end == to {
+				from = to + 1
+				break
+			}
+			from = end + 1
+		}
+
+		var wg sync.WaitGroup
+		for _, r := range results {
+			wg.Add(1)
+			go func(r *result) {
+				defer wg.Done()
+				r.logs, r.err = b.fetch(ctx, r.from, r.to)
+			}(r)
+		}
+		wg.Wait()
+
+		for _, r := range results {
+			if r.err != nil {
+				return r.err
+			}
+			if err := handle(r.from, r.to, r.logs); err != nil {
+				return err
+			}
+		}
+		if b.CurrentChunk() == chunk {
+			b.grow()
+		}
+	}
+	return nil
+}
+
+// fetch queries range and splits it in halves while provider rejects the range
+func (b *Backfiller) fetch(ctx context.Context, from, to uint64) ([]types.Log, error) {
+	q := b.Query
+	q.BlockHash = nil
+	q.FromBlock = new(big.Int).SetUint64(from)
+	q.ToBlock = new(big.Int).SetUint64(to)
+
+	logs, err := b.Filterer.FilterLogs(ctx, q)
+	if err == nil {
+		return logs, nil
+	}
+	if !IsRangeError(err) || from == to {
+		return nil, fmt.Errorf("can not filter logs %d-%d; %w", from, to, err)
+	}
+
+	b.shrink(to - from + 1)
+	mid := from + (to-from)/2
+	left, err := b.fetch(ctx, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.fetch(ctx, mid+1, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
@@ -0,0 +1,117 @@
+package backfill_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/backfill"
+)
+
+// filterer has a log at every block and rejects ranges over max blocks
+type filterer struct {
+	max int64
+
+	mu      sync.Mutex
+	queries int
+	fail    error
+}
+
+func (f *filterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	f.mu.Lock()
+	f.queries++
+	fail := f.fail
+	f.mu.Unlock()
+
+	if fail != nil {
+		return nil, fail
+	}
+	from, to := q.FromBlock.Int64(), q.ToBlock.Int64()
+	if to-from+1 > f.max {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+
+	var r []types.Log
+	for n := from; n <= to; n++ {
+		r = append(r, types.Log{BlockNumber: uint64(n)})
+	}
+	return r, nil
+}
+
+func (f *filterer) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestBackfiller(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Adaptive", func(t *testing.T) {
+		f := &filterer{max: 300}
+		b := &backfill.Backfiller{Filterer: f, Chunk: 1000, MaxChunk: 4000, Concurrency: 3}
+
+		var blocks []uint64
+		next := uint64(10)
+		err := b.Run(ctx, 10, 5009, func(from, to uint64, logs []types.Log) error {
+			require.Equal(t, next, from)
+			next = to + 1
+			for _, l := range logs {
+				blocks = append(blocks, l.BlockNumber)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(5010), next)
+
+		require.Len(t, blocks, 5000)
+		for i, n := range blocks {
+			require.Equal(t, uint64(10+i), n)
+		}
+		require.LessOrEqual(t, b.CurrentChunk(), uint64(600))
+	})
+
+	t.Run("Grow", func(t *testing.T) {
+		f := &filterer{max: 1 << 30}
+		b := &backfill.Backfiller{Filterer: f, Chunk: 10, MaxChunk: 80, Concurrency: 1}
+
+		var sizes []uint64
+		err := b.Run(ctx, 0, 999, func(from, to uint64, logs []types.Log) error {
+			sizes = append(sizes, to-from+1)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{10, 20, 40, 80, 80}, sizes[:5])
+		require.Equal(t, uint64(80), b.CurrentChunk())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		f := &filterer{max: 100, fail: errors.New("connection refused")}
+		b := &backfill.Backfiller{Filterer: f}
+
+		err := b.Run(ctx, 0, 10, func(uint64, uint64, []types.Log) error { return nil })
+		require.Error(t, err)
+		require.Equal(t, 1, f.queries)
+	})
+
+	t.Run("Handler error", func(t *testing.T) {
+		f := &filterer{max: 100}
+		b := &backfill.Backfiller{Filterer: f, Chunk: 10, Concurrency: 1}
+
+		stop := errors.New("stop")
+		err := b.Run(ctx, 0, 100, func(uint64, uint64, []types.Log) error { return stop })
+		require.ErrorIs(t, err, stop)
+		require.Equal(t, 1, f.queries)
+	})
+}
+
+func TestIsRangeError(t *testing.T) {
+	require.True(t, backfill.IsRangeError(errors.New("query returned more than 10000 results")))
+	require.True(t, backfill.IsRangeError(errors.New("exceed maximum block range: 5000")))
+	require.True(t, backfill.IsRangeError(errors.New("Log response size exceeded")))
+	require.False(t, backfill.IsRangeError(errors.New("connection refused")))
+	require.False(t, backfill.IsRangeError(nil))
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/digest"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/notify"
+	"killswitch/bridge/reserve"
+	"killswitch/bridge/signer"
+	"killswitch/bridge/slack"
+	"killswitch/bridge/unlocker"
+)
+
+// bridge-monitor watches pauses, unlocks, mints, reserves and relayer runway of all configured pairs,
+// notifies findings, optionally pauses every bridge on unauthorized mint or reserve deficit
+// and serves the Slack app, transfers are read from the sqlite history of transfer-indexer
+func main() {
+	var (
+		configPath = flag.String("config", "config.yaml", "config file")
+		dbPath     = flag.String("db", "transfers.db", "sqlite database written by transfer-indexer")
+		once       = flag.Bool("once", false, "check once and exit")
+		interval   = flag.Duration("interval", 15*time.Second, "poll interval")
+		nearCap    = flag.Float64("near-cap", 0.9, "ratio of daily limit reported as near cap by slack bridges command")
+		slackAddr  = flag.String("slack", "", "serve slack slash commands and actions on address, e.g. :8083")
+		operators  = flag.String("slack-operators", "", "comma separated slack user ids allowed to pause and unpause")
+		approvals  = flag.String("approvals", "", "sqlite database of unlock approval queue")
+		daily      = flag.Bool("digest", false, "post daily digest to slack incoming webhook SLACK_WEBHOOK_URL")
+		digestAt   = flag.Duration("digest-at", 0, "time of daily digest after UTC midnight, digest covers previous 24 hours")
+		unlockAge  = flag.Duration("unlock-age", 30*time.Minute, "lock older than age without unlock is reported missing")
+		window     = flag.Uint64("watch-blocks", 5000, "number of recent blocks correlated on start")
+		trip       = flag.Bool("breaker", false, "pause every bridge with configured signer on unauthorized mint or reserve deficit")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := indexer.Open(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	clients, err := cfg.Clients(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	backends := map[string]multiclient.Backend{}
+	for name, c := range clients {
+		defer c.Close()
+		backends[name] = c
+	}
+
+	heads := func(ctx context.Context, chain string) (uint64, error) {
+		c, ok := clients[chain]
+		if !ok {
+			return 0, fmt.Errorf("unknown chain %s", chain)
+		}
+		return c.BlockNumber(ctx)
+	}
+
+	// breaker and slack pause with the same signer
+	var opts emergency.TransactOpts
+	if *trip || *operators != "" {
+		s, err := signer.New(ctx, cfg.Signer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = func(ctx context.Context, chain string) (*bind.TransactOpts, error) {
+			return signer.TransactOpts(ctx, s, big.NewInt(cfg.Chains[chain].ChainID)), nil
+		}
+	}
+
+	var bridges []config.Bridge
+	for _, p := range cfg.Pairs {
+		bridges = append(bridges, p.Source, p.Destination)
+	}
+	notifier, err := notify.New(cfg.Notifiers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	send := func(e notify.Event) {
+		if err := notifier.Notify(ctx, e); err != nil {
+			log.Printf("can not notify %s; %v", e.Kind, err)
+		}
+	}
+	digests := &digest.Builder{Pairs: cfg.Pairs, Clients: backends, DB: db, Monitor: cfg.Monitor}
+	pauses := &unlocker.PauseWatcher{
+		Bridges: bridges,
+		Clients: backends,
+		OnChange: func(b config.Bridge, paused bool, l types.Log) {
+			state := "unpaused"
+			if paused {
+				state = "paused"
+			}
+			log.Printf("%s %s at block %d tx %s", b, state, l.BlockNumber, l.TxHash.Hex())
+			digests.Incident(time.Now(), "%s %s at block %d", b, state, l.BlockNumber)
+			send(notify.Paused(b, paused, l))
+		},
+	}
+	relayers := &monitor.BalanceWatcher{
+		Pairs:   cfg.Pairs,
+		Clients: backends,
+		Config:  cfg.Monitor,
+		OnAlert: func(r monitor.Runway) {
+			log.Print(r)
+			send(notify.Runway(r))
+		},
+	}
+
+	var breaker *monitor.Breaker
+	if *trip {
+		var all []*admin.Bridge
+		for _, b := range bridges {
+			all = append(all, admin.NewBridge(b, backends[b.Chain]))
+		}
+		breaker = &monitor.Breaker{
+			Pauser: &emergency.Pauser{
+				Bridges:  all,
+				Opts:     opts,
+				Timeouts: emergency.Timeouts(cfg.Chains, 40),
+			},
+			OnTrip: func(reason string, results []emergency.Result) {
+				log.Printf("breaker tripped, %s", reason)
+				for _, r := range results {
+					log.Print(r)
+				}
+				digests.Incident(time.Now(), "breaker tripped, %s", reason)
+				send(notify.Tripped(reason, results))
+			},
+		}
+	}
+	correlator := &monitor.Watcher{
+		Pairs:   cfg.Pairs,
+		Chains:  cfg.Chains,
+		Clients: backends,
+		MaxAge:  *unlockAge,
+		Window:  *window,
+		OnFinding: func(f monitor.Finding) {
+			log.Print(f)
+			digests.Incident(time.Now(), "%s", f)
+			send(notify.Unlock(f))
+		},
+		Mints: &monitor.MintWatcher{
+			Breaker: breaker,
+			OnUnauthorized: func(m monitor.Mint) {
+				log.Print(m)
+				digests.Incident(time.Now(), "%s", m)
+				send(notify.Mint(m))
+			},
+		},
+	}
+	reserves := &monitor.ReserveWatcher{
+		Pairs:   cfg.Pairs,
+		Clients: backends,
+		Breaker: breaker,
+		OnChange: func(r reserve.Result) {
+			log.Printf("%s locked %s, minted %s, deficit %t", r.Pair.Name, r.Locked, r.Minted, r.Deficit())
+			send(notify.Deficit(r))
+		},
+	}
+	nextDigest := digest.Next(time.Now(), *digestAt)
+
+	if *slackAddr != "" {
+		secret := os.Getenv("SLACK_SIGNING_SECRET")
+		if secret == "" {
+			log.Fatal("-slack requires SLACK_SIGNING_SECRET")
+		}
+		commands := &slack.Commands{
+			SigningSecret: secret,
+			Config:        cfg,
+			Clients:       backends,
+			DB:            db,
+			Heads:         heads,
+			Bridges:       pauses.States,
+			NearCap:       *nearCap,
+			Log:           log.Printf,
+		}
+		if *operators != "" {
+			commands.Operators = strings.Split(*operators, ",")
+			commands.Opts = opts
+		}
+		if *approvals != "" {
+			store, err := approval.OpenStore(*approvals)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer store.Close()
+			commands.Approvals = store
+		}
+		go func() {
+			log.Fatal(http.ListenAndServe(*slackAddr, commands.Handler()))
+		}()
+	}
+
+	for {
+		if err := pauses.Sync(ctx); err != nil {
+			log.Printf("can not sync paused state; %v", err)
+		}
+		if _, err := correlator.Check(ctx); err != nil {
+			log.Printf("can not correlate unlocks; %v", err)
+		}
+		if _, err := reserves.Check(ctx); err != nil {
+			log.Printf("can not check reserves; %v", err)
+		}
+		if _, err := relayers.Check(ctx); err != nil {
+			log.Printf("can not check relayer balances; %v", err)
+		}
+		if *daily {
+			if err := digests.Sample(ctx); err != nil {
+				log.Printf("can not sample limiter usage; %v", err)
+			}
+			if now := time.Now(); !now.Before(nextDigest) {
+				if err := postDigest(ctx, digests, nextDigest); err != nil {
+					// retried every poll until the following digest is due
+					log.Printf("can not post digest; %v", err)
+					if following := digest.Next(nextDigest, *digestAt); !now.Before(following) {
+						nextDigest = following
+					}
+				} else {
+					nextDigest = digest.Next(now, *digestAt)
+				}
+			}
+		}
+		if *once {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// postDigest posts digest of 24 hours before to to slack
+func postDigest(ctx context.Context, b *digest.Builder, to time.Time) error {
+	r, err := b.Build(ctx, to.Add(-24*time.Hour), to)
+	if err != nil {
+		return err
+	}
+	if err := slack.Post(ctx, nil, os.Getenv("SLACK_WEBHOOK_URL"), r.Message()); err != nil {
+		return err
+	}
+	b.Done(to)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/decimal"
+)
+
+func init() {
+	commands["pause"] = command{"<bridge>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, _ []string) (admin.Change, error) {
+		return b.Pause(ctx)
+	}, 0)}
+	commands["unpause"] = command{"<bridge>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, _ []string) (admin.Change, error) {
+		return b.Unpause(ctx)
+	}, 0)}
+	commands["set-fee"] = command{"<bridge> <fee contract>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.SetFee(ctx, addr)
+	}, 1)}
+	commands["set-limiter"] = command{"<bridge> <limiter contract>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.SetLimiter(ctx, addr)
+	}, 1)}
+	commands["set-limit"] = command{"<bridge> <daily limit in token unit, 0 is unlimited>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		decimals, err := b.Decimals(ctx)
+		if err != nil {
+			return admin.Change{}, err
+		}
+		limit, err := decimal.ToUnit(args[0], decimals)
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.SetLimit(ctx, limit)
+	}, 1)}
+	commands["set-fee-amount"] = command{"<bridge> <fixed fee in native unit>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		fee, err := decimal.ToUnit(args[0], 18)
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.SetFeeAmount(ctx, fee)
+	}, 1)}
+	commands["add-minter"] = command{"<burner bridge> <minter>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.AddMinter(ctx, addr)
+	}, 1)}
+	commands["remove-minter"] = command{"<burner bridge> <minter>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.RemoveMinter(ctx, addr)
+	}, 1)}
+	commands["transfer-ownership"] = command{"<bridge> <bridge|token|fee|limiter> <new owner>", bridgeCommand(func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error) {
+		addr, err := parseAddress(args[1])
+		if err != nil {
+			return admin.Change{}, err
+		}
+		return b.TransferOwnership(ctx, args[0], addr)
+	}, 2)}
+}
+
+// bridgeCommand creates command which applies a change to a bridge,
+// nargs is number of arguments after bridge
+func bridgeCommand(f func(ctx context.Context, b *admin.Bridge, args []string) (admin.Change, error), nargs int) func(ctx context.Context, env *env, args []string) error {
+	return func(ctx context.Context, env *env, args []string) error {
+		if len(args) != nargs+1 {
+			return fmt.Errorf("expected %d arguments, got %d", nargs+1, len(args))
+		}
+
+		b, err := env.bridge(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		c, err := f(ctx, b, args[1:])
+		if errors.Is(err, admin.ErrNoChange) {
+			fmt.Fprintln(env.out, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return env.apply(ctx, b.Chain, c)
+	}
+}
+
+func parseAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return common.HexToAddress(s), nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/signer"
+)
+
+func init() {
+	commands["approvals"] = command{"[-db file]", approvals}
+	commands["approve"] = command{"[-db file] -keystore file <lock tx hash>", approve}
+	commands["reject"] = command{"[-db file] -keystore file <lock tx hash> <reason>", reject}
+	commands["approval-log"] = command{"[-db file] [-limit n] [lock tx hash]", approvalLog}
+}
+
+func approvalFlags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	dbPath := fs.String("db", "approvals.db", "sqlite database of approval queue")
+	return fs, dbPath
+}
+
+func keystoreFlag(fs *flag.FlagSet) *string {
+	return fs.String("keystore", "", "approver keystore, passphrase from APPROVER_PASSPHRASE")
+}
+
+// approverOf returns configured approver of keystore, decrypting the keystore
+// with its passphrase is what entitles the caller to decide as the approver
+func approverOf(env *env, keystore string) (config.Approver, error) {
+	if keystore == "" {
+		return config.Approver{}, errors.New("approver keystore is required, use -keystore")
+	}
+	s, err := signer.NewKeystore(keystore, signer.PassphraseEnv("APPROVER_PASSPHRASE"))
+	if err != nil {
+		return config.Approver{}, err
+	}
+	a, ok := env.cfg.ApproverByKey(s.Address())
+	if !ok {
+		return config.Approver{}, fmt.Errorf("key %s is not a configured approver", s.Address().Hex())
+	}
+	return a, nil
+}
+
+func parseHash(s string) (common.Hash, error) {
+	if len(s) != 66 || !strings.HasPrefix(s, "0x") {
+		return common.Hash{}, fmt.Errorf("invalid lock tx hash %q", s)
+	}
+	return common.HexToHash(s), nil
+}
+
+func printRequest(env *env, r approval.Request) {
+	fmt.Fprintf(env.out, "%s %s %s\n", r.Status, r.Pair, r.Created.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(env.out, "  lock:      %s\n", r.Hash.Hex())
+	fmt.Fprintf(env.out, "  unlock:    %s to %s\n", decimal.FromUnit(r.Amount, r.Decimals), r.Account.Hex())
+	fmt.Fprintf(env.out, "  approvals: %d of %d %s\n", len(r.Approvers), r.Required, strings.Join(r.Approvers, ", "))
+	if r.Tx != nil {
+		fmt.Fprintf(env.out, "  tx:        %s\n", r.Tx.Hex())
+	}
+}
+
+// approvals prints unlocks waiting for approval
+func approvals(ctx context.Context, env *env, args []string) error {
+	fs, dbPath := approvalFlags("approvals")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := approval.OpenStore(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	pending, err := store.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(env.out, "no unlock waits for approval")
+	}
+	for _, r := range pending {
+		printRequest(env, r)
+	}
+	return nil
+}
+
+// approve approves queued unlock after confirmation
+func approve(ctx context.Context, env *env, args []string) error {
+	fs, dbPath := approvalFlags("approve")
+	keystore := keystoreFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expect lock tx hash")
+	}
+	hash, err := parseHash(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	as, err := approverOf(env, *keystore)
+	if err != nil {
+		return err
+	}
+
+	store, err := approval.OpenStore(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	r, err := store.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+	printRequest(env, r)
+	if !env.confirm("approve unlock as " + as.Name + "?") {
+		return errAborted
+	}
+
+	if r, err = store.Approve(ctx, hash, as.Name, approval.ViaCLI); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "%s, %d of %d approvals\n", r.Status, len(r.Approvers), r.Required)
+	return nil
+}
+
+// reject rejects queued unlock, relayer never submits it
+func reject(ctx context.Context, env *env, args []string) error {
+	fs, dbPath := approvalFlags("reject")
+	keystore := keystoreFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("expect lock tx hash and reason")
+	}
+	hash, err := parseHash(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	as, err := approverOf(env, *keystore)
+	if err != nil {
+		return err
+	}
+
+	store, err := approval.OpenStore(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	r, err := store.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+	printRequest(env, r)
+	if !env.confirm("reject unlock as " + as.Name + "?") {
+		return errAborted
+	}
+
+	if _, err := store.Reject(ctx, hash, as.Name, approval.ViaCLI, fs.Arg(1)); err != nil {
+		return err
+	}
+	fmt.Fprintln(env.out, approval.StatusRejected)
+	return nil
+}
+
+// approvalLog prints audit log of an unlock or latest entries of all unlocks
+func approvalLog(ctx context.Context, env *env, args []string) error {
+	fs, dbPath := approvalFlags("approval-log")
+	limit := fs.Int("limit", 50, "max entries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var hash *common.Hash
+	if fs.NArg() == 1 {
+		h, err := parseHash(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		hash = &h
+	}
+
+	store, err := approval.OpenStore(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	entries, err := store.Log(ctx, hash, *limit)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		fmt.Fprintf(env.out, "%s %s %-9s by %s via %s %s\n", e.Time.Format("2006-01-02 15:04:05"), e.Hash.Hex(), e.Action, e.Actor, e.Via, e.Note)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/audit"
+)
+
+func init() {
+	commands["audit"] = command{"[-pair name]", auditPairs}
+}
+
+// auditPairs prints pass/fail security report of every pair
+func auditPairs(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	pair := fs.String("pair", "", "audit only pair")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a := &audit.Auditor{Owner: env.cfg.Owner}
+	failed := false
+	for _, p := range env.cfg.Pairs {
+		if *pair != "" && !strings.EqualFold(p.Name, *pair) {
+			continue
+		}
+
+		source, err := env.client(ctx, p.Source.Chain)
+		if err != nil {
+			return err
+		}
+		destination, err := env.client(ctx, p.Destination.Chain)
+		if err != nil {
+			return err
+		}
+
+		r := a.Pair(ctx, p.Name, admin.NewBridge(p.Source, source), admin.NewBridge(p.Destination, destination))
+		r.Write(env.out)
+		failed = failed || !r.OK()
+	}
+
+	if failed {
+		return errors.New("audit failed")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/deploy"
+)
+
+func init() {
+	commands["deploy"] = command{"[-manifest path] <deployment name>", deployPair}
+}
+
+// deployPair deploys a pair declared in deployments of config
+func deployPair(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", "", "manifest file, default is deploy-<symbol>.json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected deployment name")
+	}
+
+	spec, ok := env.cfg.Deployment(fs.Arg(0))
+	if !ok {
+		return fmt.Errorf("deployment %s not found", fs.Arg(0))
+	}
+	if *manifestPath == "" {
+		*manifestPath = "deploy-" + strings.ToLower(spec.Token.Symbol) + ".json"
+	}
+
+	manifest, err := deploy.LoadManifest(*manifestPath, spec.Name)
+	if err != nil {
+		return err
+	}
+
+	source, err := env.deployChain(ctx, spec.Source.Chain)
+	if err != nil {
+		return err
+	}
+	destination, err := env.deployChain(ctx, spec.Destination.Chain)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.out, "deploy %s from %s\n  source:      %s %s on %s\n  destination: %s %s on %s\n  manifest:    %s\n",
+		spec.Name, source.Opts.From.Hex(),
+		spec.Source.Type, spec.Source.Name, spec.Source.Chain,
+		spec.Destination.Type, spec.Destination.Name, spec.Destination.Chain,
+		*manifestPath)
+	if !env.confirm("send transactions?") {
+		return errAborted
+	}
+
+	d := &deploy.Deployer{
+		Spec:        spec,
+		Source:      source,
+		Destination: destination,
+		Manifest:    manifest,
+		Poll:        env.cfg.Chains[spec.Source.Chain].BlockTime,
+		Log:         env.out,
+	}
+	if err := d.Run(ctx); err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal([]config.Pair{d.Pair()})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "\nadd to pairs of config:\n%s", b)
+	return nil
+}
+
+func (e *env) deployChain(ctx context.Context, chain string) (deploy.Chain, error) {
+	client, err := e.client(ctx, chain)
+	if err != nil {
+		return deploy.Chain{}, err
+	}
+	opts, err := e.transactOpts(ctx, chain)
+	if err != nil {
+		return deploy.Chain{}, err
+	}
+
+	return deploy.Chain{
+		Name:          chain,
+		Backend:       client,
+		Opts:          opts,
+		Confirmations: e.cfg.Chains[chain].Confirmations,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/drift"
+)
+
+func init() {
+	commands["plan"] = command{"[bridge...]", plan}
+	commands["apply"] = command{"[bridge...]", apply}
+}
+
+// managed returns bridges which have desired state, all of them when no bridge is given
+func (e *env) managed(ctx context.Context, args []string) ([]*admin.Bridge, error) {
+	var r []*admin.Bridge
+	if len(args) > 0 {
+		for _, s := range args {
+			b, err := e.bridge(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			if b.State == nil {
+				return nil, fmt.Errorf("%s has no desired state", b)
+			}
+			r = append(r, b)
+		}
+		return r, nil
+	}
+
+	for _, b := range e.cfg.Bridges() {
+		if b.State == nil {
+			continue
+		}
+		client, err := e.client(ctx, b.Chain)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, admin.NewBridge(b, client))
+	}
+	return r, nil
+}
+
+// diffs plans every managed bridge and prints the diffs
+func (e *env) diffs(ctx context.Context, args []string) ([]drift.Diff, error) {
+	bridges, err := e.managed(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []drift.Diff
+	for _, b := range bridges {
+		diffs, err := drift.Plan(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diffs {
+			fmt.Fprintln(e.out, d)
+		}
+		r = append(r, diffs...)
+	}
+	fmt.Fprintf(e.out, "%d bridges, %d changes\n", len(bridges), len(r))
+	return r, nil
+}
+
+// plan shows difference between live and desired state
+func plan(ctx context.Context, env *env, args []string) error {
+	_, err := env.diffs(ctx, args)
+	return err
+}
+
+// apply sends admin transactions which converge live state to desired state
+func apply(ctx context.Context, env *env, args []string) error {
+	diffs, err := env.diffs(ctx, args)
+	if err != nil || len(diffs) == 0 {
+		return err
+	}
+
+	return drift.Apply(ctx, diffs, env.apply)
+}
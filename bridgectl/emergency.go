@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/emergency"
+)
+
+func init() {
+	commands["pause-all"] = command{"[-chain name]", pauseAll}
+}
+
+// pauseAll pauses every configured bridge in parallel
+func pauseAll(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("pause-all", flag.ContinueOnError)
+	chain := fs.String("chain", "", "pause only bridges on chain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var bridges []*admin.Bridge
+	for _, b := range env.cfg.Bridges() {
+		if *chain != "" && b.Chain != *chain {
+			continue
+		}
+		client, err := env.client(ctx, b.Chain)
+		if err != nil {
+			return err
+		}
+		bridges = append(bridges, admin.NewBridge(b, client))
+	}
+	if len(bridges) == 0 {
+		return errors.New("no bridge to pause")
+	}
+
+	// resolve signer once before chains are paused in parallel
+	if _, err := env.getSigner(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.out, "pause %d bridges\n", len(bridges))
+	if !env.confirm("send transactions?") {
+		return errAborted
+	}
+
+	p := &emergency.Pauser{Bridges: bridges, Opts: env.transactOpts, Timeouts: emergency.Timeouts(env.cfg.Chains, 40)}
+	if !emergency.Write(env.out, p.PauseAll(ctx)) {
+		return errors.New("some bridges are not paused")
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/signer"
+)
+
+var errAborted = errors.New("aborted")
+
+// env is shared state of commands, clients and signer are created on demand
+type env struct {
+	cfg *config.Config
+	yes bool
+	in  *bufio.Reader
+	out io.Writer
+
+	clients map[string]multiclient.Conn
+
+	// mu guards signer, transactOpts is called from a goroutine per chain by pause-all
+	mu     sync.Mutex
+	signer signer.Signer
+}
+
+func newEnv(cfg *config.Config, yes bool) *env {
+	return &env{
+		cfg:     cfg,
+		yes:     yes,
+		in:      bufio.NewReader(os.Stdin),
+		out:     os.Stdout,
+		clients: map[string]multiclient.Conn{},
+	}
+}
+
+func (e *env) close() {
+	for _, c := range e.clients {
+		c.Close()
+	}
+}
+
+func (e *env) client(ctx context.Context, chain string) (multiclient.Conn, error) {
+	if c, ok := e.clients[chain]; ok {
+		return c, nil
+	}
+
+	cfg, ok := e.cfg.Chains[chain]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain %s", chain)
+	}
+	c, err := cfg.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.clients[chain] = c
+	return c, nil
+}
+
+func (e *env) bridge(ctx context.Context, s string) (*admin.Bridge, error) {
+	b, err := e.cfg.FindBridge(s)
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.client(ctx, b.Chain)
+	if err != nil {
+		return nil, err
+	}
+	return admin.NewBridge(b, c), nil
+}
+
+func (e *env) getSigner(ctx context.Context) (signer.Signer, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.signer != nil {
+		return e.signer, nil
+	}
+
+	s, err := signer.New(ctx, e.cfg.Signer)
+	if err != nil {
+		return nil, err
+	}
+	e.signer = s
+	return s, nil
+}
+
+func (e *env) transactOpts(ctx context.Context, chain string) (*bind.TransactOpts, error) {
+	s, err := e.getSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return signer.TransactOpts(ctx, s, big.NewInt(e.cfg.Chains[chain].ChainID)), nil
+}
+
+func (e *env) confirm(question string) bool {
+	if e.yes {
+		return true
+	}
+
+	fmt.Fprintf(e.out, "%s [y/N] ", question)
+	answer, _ := e.in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// apply previews change, asks for confirmation, sends transaction
+// and prints tx hash and receipt status
+func (e *env) apply(ctx context.Context, chain string, c admin.Change) error {
+	fmt.Fprintln(e.out, c)
+	if !e.confirm("send transaction?") {
+		return errAborted
+	}
+
+	opts, err := e.transactOpts(ctx, chain)
+	if err != nil {
+		return err
+	}
+	client, err := e.client(ctx, chain)
+	if err != nil {
+		return err
+	}
+
+	tx, receipt, err := c.Execute(ctx, opts, client)
+	if tx != nil {
+		fmt.Fprintf(e.out, "tx: %s\n", e.cfg.Chains[chain].TxURL(tx.Hash()))
+	}
+	if receipt != nil {
+		fmt.Fprintf(e.out, "status: %d, block: %s, gas used: %d\n", receipt.Status, receipt.BlockNumber, receipt.GasUsed)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"killswitch/bridge/config"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: bridgectl [flags] <command> [args]\n\nflags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\n<bridge> is bridge address or \"<pair name>:<source|destination>\"\n")
+}
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "config file")
+	yes := flag.Bool("yes", false, "do not ask for confirmation")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	env := newEnv(cfg, *yes)
+	defer env.close()
+
+	if err := cmd.run(context.Background(), env, args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+)
+
+func init() {
+	commands["report"] = command{"[-pair name] [-blocks n] [-age duration]", report}
+}
+
+// report lists orphan and missing unlocks of recent blocks with explorer links
+// and runway of relayer balances
+func report(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	pair := fs.String("pair", "", "report only pair")
+	blocks := fs.Uint64("blocks", 5000, "number of recent blocks to scan on each chain")
+	age := fs.Duration("age", 30*time.Minute, "lock older than age without unlock is missing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	heads := map[string]uint64{}
+	head := func(chain string) (uint64, uint64, error) {
+		if _, ok := heads[chain]; !ok {
+			client, err := env.client(ctx, chain)
+			if err != nil {
+				return 0, 0, err
+			}
+			n, err := client.BlockNumber(ctx)
+			if err != nil {
+				return 0, 0, fmt.Errorf("can not get head of %s; %w", chain, err)
+			}
+			heads[chain] = n
+		}
+		to := heads[chain]
+		if to < *blocks {
+			return 0, to, nil
+		}
+		return to - *blocks, to, nil
+	}
+
+	total := 0
+	for _, p := range env.cfg.Pairs {
+		if *pair != "" && !strings.EqualFold(p.Name, *pair) {
+			continue
+		}
+
+		source, err := env.client(ctx, p.Source.Chain)
+		if err != nil {
+			return err
+		}
+		destination, err := env.client(ctx, p.Destination.Chain)
+		if err != nil {
+			return err
+		}
+		forward := &monitor.Correlator{Pair: p, Source: source, Destination: destination, MaxAge: *age}
+
+		// transfers from source to destination and back
+		var findings []monitor.Finding
+		for _, c := range []*monitor.Correlator{forward, forward.Reverse()} {
+			from, to, err := head(c.Pair.Destination.Chain)
+			if err != nil {
+				return err
+			}
+			orphans, err := c.Orphans(ctx, from, to)
+			if err != nil {
+				return fmt.Errorf("%s; %w", p.Name, err)
+			}
+
+			if from, to, err = head(c.Pair.Source.Chain); err != nil {
+				return err
+			}
+			missing, err := c.Missing(ctx, from, to)
+			if err != nil {
+				return fmt.Errorf("%s; %w", p.Name, err)
+			}
+			findings = append(findings, append(orphans, missing...)...)
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		decimals, err := admin.NewBridge(p.Source, source).Decimals(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "%s\n", p.Name)
+		for _, f := range findings {
+			chain := env.cfg.Chains[f.Bridge.Chain]
+			fmt.Fprintf(env.out, "  %s %s to %s; %s\n", f.Kind, decimal.FromUnit(f.Amount, decimals), f.Account.Hex(), f.Detail)
+			fmt.Fprintf(env.out, "    hash:    %s\n", f.Hash.Hex())
+			fmt.Fprintf(env.out, "    tx:      %s\n", chain.TxURL(f.Log.TxHash))
+			fmt.Fprintf(env.out, "    account: %s\n", chain.AddressURL(f.Account))
+		}
+		total += len(findings)
+	}
+
+	clients := map[string]multiclient.Backend{}
+	for _, p := range env.cfg.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			client, err := env.client(ctx, b.Chain)
+			if err != nil {
+				return err
+			}
+			clients[b.Chain] = client
+		}
+	}
+	w := &monitor.BalanceWatcher{Pairs: env.cfg.Pairs, Clients: clients, Config: env.cfg.Monitor}
+	runways, err := w.Check(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "relayer runway\n")
+	for _, r := range runways {
+		fmt.Fprintf(env.out, "  %-8s %-6s %s balance %s, %d unlocks at %s\n", r.Level, r.Chain, r.Account.Hex(),
+			decimal.FromUnit(r.Balance, 18), r.Unlocks, decimal.FromUnit(r.UnlockCost, 18))
+		if r.Level != monitor.LevelOK {
+			total++
+		}
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%d findings", total)
+	}
+	fmt.Fprintln(env.out, "no findings")
+	return nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+	"killswitch/bridge/signer"
+)
+
+func init() {
+	commands["reserves"] = command{"[-pair name] [-start chain=block,...]", reserves}
+	commands["prove"] = command{"[-pair name] [-time RFC3339] [-block chain=block,...] [-keystore file] [-out file]", prove}
+	commands["verify-proof"] = command{"[-signer address] [-sig file] <proof file>", verifyProof}
+}
+
+// chainBlocks parses "chain=block,..."
+func chainBlocks(s string) (map[string]uint64, error) {
+	blocks := map[string]uint64{}
+	if s == "" {
+		return blocks, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid chain block %q, expect chain=block", kv)
+		}
+		n, err := strconv.ParseUint(kv[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block of %s; %w", kv[:i], err)
+		}
+		blocks[kv[:i]] = n
+	}
+	return blocks, nil
+}
+
+// reserves rebuilds custody and supply of every pair from its Locked and Unlocked events
+// and compares them with on-chain balance of source bridge and total supply of wrapped token
+func reserves(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("reserves", flag.ContinueOnError)
+	pair := fs.String("pair", "", "replay only pair")
+	start := fs.String("start", "", "first block to replay per chain, e.g. bsc=9000000,bkc=100")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pairs, clients, err := pairClients(ctx, env, *pair)
+	if err != nil {
+		return err
+	}
+	r := &reserve.Replayer{Clients: clients}
+	if r.Start, err = chainBlocks(*start); err != nil {
+		return err
+	}
+
+	total := 0
+	for _, p := range pairs {
+		h, err := r.Replay(ctx, p, nil)
+		if err != nil {
+			return fmt.Errorf("%s; %w", p.Name, err)
+		}
+		decimals, err := admin.NewBridge(p.Source, r.Clients[p.Source.Chain]).Decimals(ctx)
+		if err != nil {
+			return err
+		}
+		findings := h.Findings(decimals)
+
+		fmt.Fprintf(env.out, "%s\n", p.Name)
+		fmt.Fprintf(env.out, "  %s block %d: %d locks %s, %d unlocks %s\n", p.Source.Chain, h.SourceBlock,
+			h.Source.Locks, decimal.FromUnit(h.Source.Locked, decimals), h.Source.Unlocks, decimal.FromUnit(h.Source.Unlocked, decimals))
+		fmt.Fprintf(env.out, "  %s block %d: %d locks %s, %d unlocks %s\n", p.Destination.Chain, h.DestinationBlock,
+			h.Destination.Locks, decimal.FromUnit(h.Destination.Locked, decimals), h.Destination.Unlocks, decimal.FromUnit(h.Destination.Unlocked, decimals))
+		fmt.Fprintf(env.out, "  custody: %s events, %s on-chain\n", decimal.FromUnit(h.Custody, decimals), decimal.FromUnit(h.Locked, decimals))
+		fmt.Fprintf(env.out, "  supply:  %s events, %s on-chain\n", decimal.FromUnit(h.Supply, decimals), decimal.FromUnit(h.Minted, decimals))
+		for _, f := range findings {
+			fmt.Fprintf(env.out, "  %s\n", f)
+		}
+		total += len(findings)
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%d findings", total)
+	}
+	fmt.Fprintln(env.out, "no findings")
+	return nil
+}
+
+// pairClients returns pairs matching name, all when empty, and clients of their chains
+func pairClients(ctx context.Context, env *env, name string) ([]config.Pair, map[string]multiclient.Backend, error) {
+	var pairs []config.Pair
+	clients := map[string]multiclient.Backend{}
+	for _, p := range env.cfg.Pairs {
+		if name != "" && !strings.EqualFold(p.Name, name) {
+			continue
+		}
+		for _, chain := range []string{p.Source.Chain, p.Destination.Chain} {
+			client, err := env.client(ctx, chain)
+			if err != nil {
+				return nil, nil, err
+			}
+			clients[chain] = client
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, clients, nil
+}
+
+// prove writes proof of reserves at a UTC time or blocks, signed by operator key,
+// the detached signature is written next to the report with .sig extension
+func prove(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("prove", flag.ContinueOnError)
+	pair := fs.String("pair", "", "prove only pair")
+	at := fs.String("time", "", "UTC time of proof, e.g. 2021-06-30T23:59:59Z, default now")
+	blocks := fs.String("block", "", "block per chain, overrides -time, e.g. bsc=9000000,bkc=100")
+	keystore := fs.String("keystore", "", "operator keystore, passphrase from PROOF_PASSPHRASE, default configured signer")
+	out := fs.String("out", "proof.json", "report file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pairs, clients, err := pairClients(ctx, env, *pair)
+	if err != nil {
+		return err
+	}
+	var s signer.Signer
+	if *keystore != "" {
+		s, err = signer.NewKeystore(*keystore, signer.PassphraseEnv("PROOF_PASSPHRASE"))
+	} else {
+		s, err = env.getSigner(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	heights := map[string]uint64{}
+	var t *time.Time
+	if *at != "" {
+		v, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("invalid time; %w", err)
+		}
+		v = v.UTC()
+		t = &v
+		if heights, err = reserve.BlocksAt(ctx, clients, v); err != nil {
+			return err
+		}
+	}
+	overrides, err := chainBlocks(*blocks)
+	if err != nil {
+		return err
+	}
+	for chain, n := range overrides {
+		heights[chain] = n
+	}
+
+	p, err := reserve.Prove(ctx, pairs, clients, heights)
+	if err != nil {
+		return err
+	}
+	p.At = t
+	report, sig, err := p.Sign(ctx, s)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out, report, 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out+".sig", []byte(hexutil.Encode(sig)+"\n"), 0644); err != nil {
+		return err
+	}
+
+	for _, a := range p.Chains {
+		fmt.Fprintf(env.out, "%-6s block %d %s at %s\n", a.Chain, a.Block, a.Hash.Hex(), a.Time.Format(time.RFC3339))
+	}
+	for _, st := range p.Pairs {
+		locked, _ := new(big.Int).SetString(st.Locked, 10)
+		minted, _ := new(big.Int).SetString(st.Minted, 10)
+		fmt.Fprintf(env.out, "%s locked %s, minted %s, valid %t\n", st.Pair, decimal.FromUnit(locked, st.Decimals), decimal.FromUnit(minted, st.Decimals), st.Valid)
+	}
+	fmt.Fprintf(env.out, "signed by %s: %s, %s.sig\n", s.Address().Hex(), *out, *out)
+	return nil
+}
+
+// verifyProof checks signature of proof and reads it again at its blocks,
+// configured chains must serve archive state
+func verifyProof(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("verify-proof", flag.ContinueOnError)
+	expected := fs.String("signer", "", "expected operator address")
+	sigPath := fs.String("sig", "", "detached signature, default <proof file>.sig")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("proof file is required")
+	}
+	path := fs.Arg(0)
+	if *sigPath == "" {
+		*sigPath = path + ".sig"
+	}
+
+	report, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	raw, err := ioutil.ReadFile(*sigPath)
+	if err != nil {
+		return err
+	}
+	sig, err := hexutil.Decode(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return fmt.Errorf("invalid signature file; %w", err)
+	}
+
+	p, err := reserve.Open(report, sig)
+	if err != nil {
+		return err
+	}
+	if *expected != "" && common.HexToAddress(*expected) != p.Signer {
+		return fmt.Errorf("proof is signed by %s, expected %s", p.Signer.Hex(), *expected)
+	}
+	fmt.Fprintf(env.out, "signed by %s\n", p.Signer.Hex())
+
+	clients := map[string]multiclient.Backend{}
+	for _, a := range p.Chains {
+		client, err := env.client(ctx, a.Chain)
+		if err != nil {
+			return err
+		}
+		clients[a.Chain] = client
+	}
+	diffs, err := reserve.Verify(ctx, p, clients)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(env.out, "  %s\n", d)
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d differences", len(diffs))
+	}
+	fmt.Fprintf(env.out, "proof matches chains, valid %t\n", p.Valid())
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/indexer"
+)
+
+func init() {
+	commands["transfer"] = command{"[-db file] <lock tx hash|account>", transfer}
+}
+
+// transfer looks up transfers in the indexed transfer history
+func transfer(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	dbPath := fs.String("db", "transfers.db", "sqlite database of transfer-indexer")
+	limit := fs.Int("limit", 20, "max transfers of account")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expect lock tx hash or account")
+	}
+
+	db, err := indexer.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var transfers []indexer.Transfer
+	switch s := fs.Arg(0); {
+	case common.IsHexAddress(s):
+		account := common.HexToAddress(s)
+		if transfers, err = db.Transfers(ctx, indexer.Query{Account: &account, Limit: *limit}); err != nil {
+			return err
+		}
+	case len(s) == 66:
+		t, err := db.Transfer(ctx, common.HexToHash(s))
+		if err != nil {
+			return err
+		}
+		transfers = append(transfers, t)
+	default:
+		return fmt.Errorf("invalid lock tx hash or account %q", s)
+	}
+
+	for _, t := range transfers {
+		source := env.cfg.Chains[t.Lock.Chain]
+		fmt.Fprintf(env.out, "%s %s %s\n", t.Status, t.Lock.Pair, t.Lock.Time.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(env.out, "  lock:   %s amount %s fee %s\n", source.TxURL(t.Lock.Hash), t.Lock.Amount, t.Lock.Fee)
+		if t.Unlock != nil {
+			destination := env.cfg.Chains[t.Unlock.Chain]
+			fmt.Fprintf(env.out, "  unlock: %s amount %s after %s\n", destination.TxURL(t.Unlock.Tx), t.Unlock.Amount, t.Latency)
+		}
+	}
+	if len(transfers) == 0 {
+		fmt.Fprintln(env.out, "no transfer")
+	}
+	return nil
+}
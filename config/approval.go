@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/decimal"
+)
+
+// Approval requires manual approval of unlocks of a pair above a threshold
+type Approval struct {
+	// Threshold is amount in token unit above which unlock waits for approval
+	Threshold string `yaml:"threshold"`
+	// Approvals is number of distinct approvers required, default 1
+	Approvals int `yaml:"approvals,omitempty"`
+}
+
+// Required returns number of approvals required
+func (a Approval) Required() int {
+	if a.Approvals < 1 {
+		return 1
+	}
+	return a.Approvals
+}
+
+// Amount returns threshold in smallest unit of token with decimals
+func (a Approval) Amount(decimals uint8) (*big.Int, error) {
+	return decimal.ToUnit(a.Threshold, decimals)
+}
+
+// Approver is a person allowed to approve and reject unlocks, each channel
+// maps to the same approver so one person counts once
+type Approver struct {
+	Name string `yaml:"name"`
+	// Slack is Slack user id
+	Slack string `yaml:"slack,omitempty"`
+	// Key is address of approver key which signs CLI decisions
+	Key *common.Address `yaml:"key,omitempty"`
+}
+
+// ApproverBySlack returns approver of Slack user id
+func (cfg *Config) ApproverBySlack(id string) (Approver, bool) {
+	for _, a := range cfg.Approvers {
+		if id != "" && a.Slack == id {
+			return a, true
+		}
+	}
+	return Approver{}, false
+}
+
+// ApproverByKey returns approver of key address
+func (cfg *Config) ApproverByKey(addr common.Address) (Approver, bool) {
+	for _, a := range cfg.Approvers {
+		if a.Key != nil && *a.Key == addr {
+			return a, true
+		}
+	}
+	return Approver{}, false
+}
+
+func (cfg *Config) validateApprovers() error {
+	names, slack, keys := map[string]bool{}, map[string]bool{}, map[common.Address]bool{}
+	for _, a := range cfg.Approvers {
+		if a.Name == "" {
+			return errors.New("config: approver has no name")
+		}
+		if names[a.Name] {
+			return fmt.Errorf("config: duplicate approver %s", a.Name)
+		}
+		names[a.Name] = true
+		if a.Slack != "" {
+			if slack[a.Slack] {
+				return fmt.Errorf("config: approver %s shares slack id %s", a.Name, a.Slack)
+			}
+			slack[a.Slack] = true
+		}
+		if a.Key != nil {
+			if keys[*a.Key] {
+				return fmt.Errorf("config: approver %s shares key %s", a.Name, a.Key.Hex())
+			}
+			keys[*a.Key] = true
+		}
+	}
+
+	for _, p := range cfg.Pairs {
+		if p.Approval != nil && p.Approval.Required() > len(cfg.Approvers) {
+			return fmt.Errorf("config: pair %s requires %d approvals but %d approvers are configured", p.Name, p.Approval.Required(), len(cfg.Approvers))
+		}
+	}
+	return nil
+}
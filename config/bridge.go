@@ -0,0 +1,263 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v2"
+
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/signer"
+)
+
+// Bridge types
+const (
+	TypeLocker = "locker"
+	TypeBurner = "burner"
+	TypeEther  = "ether"
+)
+
+// Pair sides
+const (
+	SideSource      = "source"
+	SideDestination = "destination"
+)
+
+// Config is the bridge configuration file
+type Config struct {
+	Signer signer.Config    `yaml:"signer"`
+	Chains map[string]Chain `yaml:"chains"`
+	Pairs  []Pair           `yaml:"pairs"`
+
+	Deployments []Deployment `yaml:"deployments"`
+
+	// Owner is expected owner (multisig) of every bridge and wrapped token
+	Owner *common.Address `yaml:"owner,omitempty"`
+
+	Monitor Monitor `yaml:"monitor"`
+
+	Notifiers []Notifier `yaml:"notifiers,omitempty"`
+
+	// Approvers may approve unlocks of pairs with approval
+	Approvers []Approver `yaml:"approvers,omitempty"`
+}
+
+// Chain is a configured chain
+type Chain struct {
+	Name          string        `yaml:"-"`
+	ChainID       int64         `yaml:"chainId"`
+	RPC           []string      `yaml:"rpc"`
+	Quorum        int           `yaml:"quorum"`
+	Explorer      string        `yaml:"explorer"`
+	BlockTime     time.Duration `yaml:"blockTime"`
+	Confirmations uint64        `yaml:"confirmations"`
+}
+
+// Pair is a bridge pair, tokens locked on source bridge
+// are minted by destination burner and vice versa
+type Pair struct {
+	Name        string `yaml:"name"`
+	Source      Bridge `yaml:"source"`
+	Destination Bridge `yaml:"destination"`
+
+	// Approval holds large unlocks for manual approval, nil unlocks without approval
+	Approval *Approval `yaml:"approval,omitempty"`
+}
+
+// Bridge is a bridge contract of a pair
+type Bridge struct {
+	Chain   string         `yaml:"chain"`
+	Type    string         `yaml:"type"`
+	Address common.Address `yaml:"address"`
+	// Token is expected token of locker or burner bridge
+	TokenAddress *common.Address `yaml:"token,omitempty"`
+
+	// State is desired on-chain configuration, nil is not managed
+	State *State `yaml:"state,omitempty"`
+
+	// Start is the deployment block of the bridge and its token,
+	// event history of the contracts is queried from it, default 0
+	Start uint64 `yaml:"start,omitempty"`
+
+	// Pair and Side are filled by Load
+	Pair string `yaml:"-"`
+	Side string `yaml:"-"`
+}
+
+// String returns readable bridge name
+func (b Bridge) String() string {
+	return fmt.Sprintf("%s %s (%s %s)", b.Chain, b.Address.Hex(), b.Pair, b.Side)
+}
+
+// Load reads and validates config file
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read config; %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("can not parse config; %w", err)
+	}
+
+	if err := cfg.init(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (cfg *Config) init() error {
+	for name, c := range cfg.Chains {
+		c.Name = name
+		if len(c.RPC) == 0 {
+			return fmt.Errorf("config: chain %s has no rpc", name)
+		}
+		cfg.Chains[name] = c
+	}
+
+	for i := range cfg.Pairs {
+		p := &cfg.Pairs[i]
+		p.Source.Pair, p.Source.Side = p.Name, SideSource
+		p.Destination.Pair, p.Destination.Side = p.Name, SideDestination
+
+		if p.Source.Type != TypeLocker && p.Source.Type != TypeEther {
+			return fmt.Errorf("config: pair %s source must be %s or %s", p.Name, TypeLocker, TypeEther)
+		}
+		if p.Destination.Type != TypeBurner {
+			return fmt.Errorf("config: pair %s destination must be %s", p.Name, TypeBurner)
+		}
+		for _, b := range []Bridge{p.Source, p.Destination} {
+			if _, ok := cfg.Chains[b.Chain]; !ok {
+				return fmt.Errorf("config: pair %s unknown chain %s", p.Name, b.Chain)
+			}
+		}
+		if p.Source.State != nil && p.Source.State.Minters != nil {
+			return fmt.Errorf("config: pair %s source has no minters", p.Name)
+		}
+		if p.Approval != nil {
+			if _, err := p.Approval.Amount(18); err != nil {
+				return fmt.Errorf("config: pair %s invalid approval threshold %q; %w", p.Name, p.Approval.Threshold, err)
+			}
+			if p.Approval.Approvals < 0 {
+				return fmt.Errorf("config: pair %s approvals must not be negative", p.Name)
+			}
+		}
+	}
+
+	if err := cfg.validateApprovers(); err != nil {
+		return err
+	}
+
+	for _, n := range cfg.Notifiers {
+		if err := n.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range cfg.Deployments {
+		if err := d.validate(cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Bridges returns all bridges of all pairs
+func (cfg *Config) Bridges() []Bridge {
+	var r []Bridge
+	for _, p := range cfg.Pairs {
+		r = append(r, p.Source, p.Destination)
+	}
+	return r
+}
+
+// Pair returns pair by name (case insensitive)
+func (cfg *Config) Pair(name string) (Pair, bool) {
+	for _, p := range cfg.Pairs {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Pair{}, false
+}
+
+// FindBridge returns bridge by address or "<pair name>:<side>"
+func (cfg *Config) FindBridge(s string) (Bridge, error) {
+	if common.IsHexAddress(s) {
+		addr := common.HexToAddress(s)
+		for _, b := range cfg.Bridges() {
+			if b.Address == addr {
+				return b, nil
+			}
+		}
+		return Bridge{}, fmt.Errorf("config: bridge %s not found", s)
+	}
+
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return Bridge{}, fmt.Errorf("config: invalid bridge %q, use address or <pair>:<source|destination>", s)
+	}
+	p, ok := cfg.Pair(s[:i])
+	if !ok {
+		return Bridge{}, fmt.Errorf("config: pair %s not found", s[:i])
+	}
+	switch s[i+1:] {
+	case SideSource:
+		return p.Source, nil
+	case SideDestination:
+		return p.Destination, nil
+	}
+	return Bridge{}, fmt.Errorf("config: invalid side %q", s[i+1:])
+}
+
+// Dial connects to chain rpc endpoints, critical reads must be agreed
+// by quorum endpoints when quorum is greater than 1
+func (c Chain) Dial(ctx context.Context) (multiclient.Conn, error) {
+	client, err := multiclient.Dial(ctx, c.RPC)
+	if err != nil {
+		return nil, fmt.Errorf("can not dial %s; %w", c.Name, err)
+	}
+	if c.Quorum > 1 {
+		return client.Quorum(c.Quorum), nil
+	}
+	return client, nil
+}
+
+// TxURL returns explorer link of transaction
+func (c Chain) TxURL(hash common.Hash) string {
+	if c.Explorer == "" {
+		return hash.Hex()
+	}
+	return strings.TrimRight(c.Explorer, "/") + "/tx/" + hash.Hex()
+}
+
+// AddressURL returns explorer link of address
+func (c Chain) AddressURL(addr common.Address) string {
+	if c.Explorer == "" {
+		return addr.Hex()
+	}
+	return strings.TrimRight(c.Explorer, "/") + "/address/" + addr.Hex()
+}
+
+// Clients dials all configured chains
+func (cfg *Config) Clients(ctx context.Context) (map[string]multiclient.Conn, error) {
+	r := map[string]multiclient.Conn{}
+	for name, c := range cfg.Chains {
+		client, err := c.Dial(ctx)
+		if err != nil {
+			for _, c := range r {
+				c.Close()
+			}
+			return nil, err
+		}
+		r[name] = client
+	}
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := config.Load("../config.example.yaml")
+	require.NoError(t, err)
+
+	require.Equal(t, "keystore", cfg.Signer.Type)
+	require.Len(t, cfg.Chains, 3)
+	require.Equal(t, "bsc", cfg.Chains["bsc"].Name)
+	require.Equal(t, int64(56), cfg.Chains["bsc"].ChainID)
+	require.Equal(t, 3*time.Second, cfg.Chains["bsc"].BlockTime)
+	require.Len(t, cfg.Bridges(), 2*len(cfg.Pairs))
+	require.Len(t, cfg.Notifiers, 3)
+	require.Equal(t, []string{"oncall@example.com"}, cfg.Notifiers[2].To)
+
+	t.Run("FindBridge", func(t *testing.T) {
+		b, err := cfg.FindBridge("dai <=> kdai:destination")
+		require.NoError(t, err)
+		require.Equal(t, common.HexToAddress("0xA7E186636Bcb7Da5B6E1aa58aC34DE5D35772d10"), b.Address)
+		require.Equal(t, config.TypeBurner, b.Type)
+		require.Equal(t, "DAI <=> kDAI", b.Pair)
+
+		p, _ := cfg.Pair("DAI <=> kDAI")
+		require.Equal(t, 2, p.Approval.Required())
+
+		b, err = cfg.FindBridge("0xAA23Db1B0D19f933504c7e2C9279d427834f3692")
+		require.NoError(t, err)
+		require.Equal(t, config.SideSource, b.Side)
+		require.Equal(t, "bsc", b.Chain)
+
+		_, err = cfg.FindBridge("0x0000000000000000000000000000000000000001")
+		require.Error(t, err)
+		_, err = cfg.FindBridge("DAI <=> kDAI:middle")
+		require.Error(t, err)
+		_, err = cfg.FindBridge("DAI")
+		require.Error(t, err)
+	})
+
+	t.Run("Approvers", func(t *testing.T) {
+		a, ok := cfg.ApproverBySlack("U024BE7LH")
+		require.True(t, ok)
+		require.Equal(t, "alice", a.Name)
+		a, ok = cfg.ApproverByKey(*a.Key)
+		require.True(t, ok)
+		require.Equal(t, "alice", a.Name)
+		_, ok = cfg.ApproverBySlack("")
+		require.False(t, ok)
+	})
+
+	t.Run("Explorer", func(t *testing.T) {
+		require.Equal(t, "https://bscscan.com/tx/"+common.Hash{1}.Hex(), cfg.Chains["bsc"].TxURL(common.Hash{1}))
+	})
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Deployment is desired contracts of a new pair
+type Deployment struct {
+	Name        string           `yaml:"name"`
+	Source      DeploymentBridge `yaml:"source"`
+	Destination DeploymentBridge `yaml:"destination"`
+
+	// Token is wrapped token minted by destination burner
+	Token DeploymentToken `yaml:"token"`
+}
+
+// DeploymentBridge is desired bridge of a deployment
+type DeploymentBridge struct {
+	Chain string `yaml:"chain"`
+	Type  string `yaml:"type"`
+	Name  string `yaml:"name"`
+
+	// Token is asset locked by source locker
+	Token common.Address `yaml:"token"`
+
+	// Fee is fixed fee in native unit
+	Fee string `yaml:"fee"`
+
+	// Limit is daily limit in token unit, empty or 0 is unlimited
+	Limit string `yaml:"limit"`
+
+	// Limiter is existing LimiterDaily to use, new one is deployed when empty
+	Limiter common.Address `yaml:"limiter"`
+}
+
+// DeploymentToken is desired wrapped token
+type DeploymentToken struct {
+	Name     string `yaml:"name"`
+	Symbol   string `yaml:"symbol"`
+	Decimals uint8  `yaml:"decimals"`
+}
+
+// Deployment returns deployment by name (case insensitive)
+func (cfg *Config) Deployment(name string) (Deployment, bool) {
+	for _, d := range cfg.Deployments {
+		if strings.EqualFold(d.Name, name) {
+			return d, true
+		}
+	}
+	return Deployment{}, false
+}
+
+func (d Deployment) validate(cfg *Config) error {
+	if d.Source.Type != TypeLocker && d.Source.Type != TypeEther {
+		return fmt.Errorf("config: deployment %s source must be %s or %s", d.Name, TypeLocker, TypeEther)
+	}
+	if d.Source.Type == TypeLocker && d.Source.Token == (common.Address{}) {
+		return fmt.Errorf("config: deployment %s source locker requires token", d.Name)
+	}
+	if d.Destination.Type != TypeBurner {
+		return fmt.Errorf("config: deployment %s destination must be %s", d.Name, TypeBurner)
+	}
+	if d.Token.Name == "" || d.Token.Symbol == "" {
+		return fmt.Errorf("config: deployment %s requires token name and symbol", d.Name)
+	}
+	for _, b := range []DeploymentBridge{d.Source, d.Destination} {
+		if _, ok := cfg.Chains[b.Chain]; !ok {
+			return fmt.Errorf("config: deployment %s unknown chain %s", d.Name, b.Chain)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package config
+
+// Monitor configures monitor alerts, zero fields use defaults
+type Monitor struct {
+	// UnlockGas is gas used by an unlock, default 150000
+	UnlockGas uint64 `yaml:"unlockGas,omitempty"`
+	// RunwayWarning is number of unlocks relayer balance can pay below which a warning is raised, default 500
+	RunwayWarning uint64 `yaml:"runwayWarning,omitempty"`
+	// RunwayCritical is number of unlocks relayer balance can pay below which a critical alert is raised, default 100
+	RunwayCritical uint64 `yaml:"runwayCritical,omitempty"`
+}
@@ -0,0 +1,56 @@
+package config
+
+import "fmt"
+
+// Notifier types
+const (
+	NotifierWebhook = "webhook"
+	NotifierSMTP    = "smtp"
+	NotifierLog     = "log"
+)
+
+// Notifier is a sink of alerts and relayer events
+type Notifier struct {
+	Type string `yaml:"type"`
+	// Severity is minimum severity sent: info (default), warning or critical
+	Severity string `yaml:"severity,omitempty"`
+	// Kinds filters event kinds, empty sends every kind
+	Kinds []string `yaml:"kinds,omitempty"`
+
+	// URL receives events as JSON of webhook notifier
+	URL string `yaml:"url,omitempty"`
+
+	// SMTP server address host:port, sender and recipients of smtp notifier,
+	// password is read from env NOTIFY_SMTP_PASSWORD
+	SMTP     string   `yaml:"smtp,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       []string `yaml:"to,omitempty"`
+
+	// Subject and Template are text/template of event title and text, empty uses defaults
+	Subject  string `yaml:"subject,omitempty"`
+	Template string `yaml:"template,omitempty"`
+}
+
+func (n Notifier) validate() error {
+	switch n.Type {
+	case NotifierWebhook:
+		if n.URL == "" {
+			return fmt.Errorf("config: %s notifier has no url", n.Type)
+		}
+	case NotifierSMTP:
+		if n.SMTP == "" || n.From == "" || len(n.To) == 0 {
+			return fmt.Errorf("config: %s notifier needs smtp, from and to", n.Type)
+		}
+	case NotifierLog:
+	default:
+		return fmt.Errorf("config: unknown notifier type %q", n.Type)
+	}
+
+	switch n.Severity {
+	case "", "info", "warning", "critical":
+	default:
+		return fmt.Errorf("config: unknown notifier severity %q", n.Severity)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// State is desired on-chain configuration of a bridge,
+// nil fields are not managed
+type State struct {
+	Owner *common.Address `yaml:"owner,omitempty"`
+
+	// Fee is fee contract address
+	Fee *common.Address `yaml:"fee,omitempty"`
+	// FeeAmount is FeeFixed fee in native unit
+	FeeAmount string `yaml:"feeAmount,omitempty"`
+
+	// Limiter is limiter contract address
+	Limiter *common.Address `yaml:"limiter,omitempty"`
+	// Limit is LimiterDaily limit in token unit, 0 is unlimited
+	Limit string `yaml:"limit,omitempty"`
+
+	Paused *bool `yaml:"paused,omitempty"`
+
+	// Minters is exact minter set of wrapped token, burner only
+	Minters []common.Address `yaml:"minters,omitempty"`
+}
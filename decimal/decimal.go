@@ -1,6 +1,7 @@
 package decimal
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/params"
@@ -18,3 +19,25 @@ func EtherToWei(eth string) *big.Int {
 func WeiToEther(v *big.Int) *big.Float {
 	return new(big.Float).Quo(new(big.Float).SetInt(v), big.NewFloat(params.Ether))
 }
+
+// ToUnit converts human readable amount to smallest unit of token with decimals,
+// negative amounts are rejected
+func ToUnit(amount string, decimals uint8) (*big.Int, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, err
+	}
+	if d.IsNegative() {
+		return nil, fmt.Errorf("amount %s is negative", amount)
+	}
+	d = d.Shift(int32(decimals))
+	if !d.Equal(d.Truncate(0)) {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", amount, decimals)
+	}
+	return d.BigInt(), nil
+}
+
+// FromUnit converts smallest unit of token with decimals to human readable amount
+func FromUnit(v *big.Int, decimals uint8) string {
+	return decimal.NewFromBigInt(v, -int32(decimals)).String()
+}
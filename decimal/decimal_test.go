@@ -0,0 +1,36 @@
+package decimal_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/decimal"
+)
+
+func TestUnit(t *testing.T) {
+	v, err := decimal.ToUnit("1.5", 6)
+	require.NoError(t, err)
+	require.Equal(t, "1500000", v.String())
+
+	v, err = decimal.ToUnit("100", 18)
+	require.NoError(t, err)
+	require.Equal(t, decimal.EtherToWei("100").String(), v.String())
+
+	_, err = decimal.ToUnit("0.0000001", 6)
+	require.Error(t, err)
+
+	_, err = decimal.ToUnit("abc", 6)
+	require.Error(t, err)
+
+	_, err = decimal.ToUnit("-1", 6)
+	require.Error(t, err)
+
+	v, err = decimal.ToUnit("0", 6)
+	require.NoError(t, err)
+	require.Equal(t, "0", v.String())
+
+	require.Equal(t, "1.5", decimal.FromUnit(big.NewInt(1500000), 6))
+	require.Equal(t, "0", decimal.FromUnit(big.NewInt(0), 18))
+}
@@ -0,0 +1,339 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+)
+
+// Step names
+const (
+	StepSourceFee            = "source.fee"
+	StepSourceLimiter        = "source.limiter"
+	StepSourceBridge         = "source.bridge"
+	StepSourceSetLimit       = "source.setLimit"
+	StepDestinationToken     = "destination.token"
+	StepDestinationFee       = "destination.fee"
+	StepDestinationLimiter   = "destination.limiter"
+	StepDestinationBridge    = "destination.bridge"
+	StepDestinationAddMinter = "destination.addMinter"
+	StepDestinationSetLimit  = "destination.setLimit"
+)
+
+// Chain is chain access of a deployment side
+type Chain struct {
+	Name          string
+	Backend       multiclient.Backend
+	Opts          *bind.TransactOpts
+	Confirmations uint64
+}
+
+// Deployer deploys a full bridge pair,
+// every step is recorded in manifest and skipped when run again
+type Deployer struct {
+	Spec        config.Deployment
+	Source      Chain
+	Destination Chain
+	Manifest    *Manifest
+
+	// Poll is interval to check receipts and confirmations
+	Poll time.Duration
+	Log  io.Writer
+}
+
+func (d *Deployer) logf(format string, args ...interface{}) {
+	w := d.Log
+	if w == nil {
+		w = ioutil.Discard
+	}
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+// Run deploys all contracts of pair and wires them together
+func (d *Deployer) Run(ctx context.Context) error {
+	if d.Poll == 0 {
+		d.Poll = time.Second
+	}
+
+	src, dst := d.Spec.Source, d.Spec.Destination
+
+	// source
+	if err := d.deployFee(ctx, d.Source, StepSourceFee, src); err != nil {
+		return err
+	}
+	if err := d.deployLimiter(ctx, d.Source, StepSourceLimiter, src); err != nil {
+		return err
+	}
+	if err := d.step(ctx, d.Source, StepSourceBridge, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		fee, limiter := d.Manifest.Address(StepSourceFee), d.limiter(StepSourceLimiter, src)
+		if src.Type == config.TypeEther {
+			addr, tx, _, err := abi.DeployBridgeEther(opts, d.Source.Backend, src.Name, fee, limiter)
+			return &addr, tx, err
+		}
+		addr, tx, _, err := abi.DeployBridgeLocker(opts, d.Source.Backend, src.Token, src.Name, fee, limiter)
+		return &addr, tx, err
+	}); err != nil {
+		return err
+	}
+	if err := d.setLimit(ctx, d.Source, StepSourceSetLimit, StepSourceLimiter, StepSourceBridge, src); err != nil {
+		return err
+	}
+
+	// destination
+	if err := d.step(ctx, d.Destination, StepDestinationToken, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		addr, tx, _, err := abi.DeployWrappedToken(opts, d.Destination.Backend, d.Spec.Token.Name, d.Spec.Token.Symbol, d.Spec.Token.Decimals)
+		return &addr, tx, err
+	}); err != nil {
+		return err
+	}
+	if err := d.deployFee(ctx, d.Destination, StepDestinationFee, dst); err != nil {
+		return err
+	}
+	if err := d.deployLimiter(ctx, d.Destination, StepDestinationLimiter, dst); err != nil {
+		return err
+	}
+	if err := d.step(ctx, d.Destination, StepDestinationBridge, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		token, fee, limiter := d.Manifest.Address(StepDestinationToken), d.Manifest.Address(StepDestinationFee), d.limiter(StepDestinationLimiter, dst)
+		addr, tx, _, err := abi.DeployBridgeBurner(opts, d.Destination.Backend, token, dst.Name, fee, limiter)
+		return &addr, tx, err
+	}); err != nil {
+		return err
+	}
+	if err := d.step(ctx, d.Destination, StepDestinationAddMinter, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		token, _ := abi.NewWrappedToken(d.Manifest.Address(StepDestinationToken), d.Destination.Backend)
+		tx, err := token.AddMinter(opts, d.Manifest.Address(StepDestinationBridge))
+		return nil, tx, err
+	}); err != nil {
+		return err
+	}
+	return d.setLimit(ctx, d.Destination, StepDestinationSetLimit, StepDestinationLimiter, StepDestinationBridge, dst)
+}
+
+// Pair returns config of deployed pair
+func (d *Deployer) Pair() config.Pair {
+	return config.Pair{
+		Name:        d.Spec.Name,
+		Source:      config.Bridge{Chain: d.Spec.Source.Chain, Type: d.Spec.Source.Type, Address: d.Manifest.Address(StepSourceBridge)},
+		Destination: config.Bridge{Chain: d.Spec.Destination.Chain, Type: d.Spec.Destination.Type, Address: d.Manifest.Address(StepDestinationBridge)},
+	}
+}
+
+func (d *Deployer) limiter(step string, b config.DeploymentBridge) common.Address {
+	if b.Limiter != (common.Address{}) {
+		return b.Limiter
+	}
+	return d.Manifest.Address(step)
+}
+
+func (d *Deployer) deployFee(ctx context.Context, c Chain, name string, b config.DeploymentBridge) error {
+	fee := big.NewInt(0)
+	if b.Fee != "" {
+		var err error
+		if fee, err = decimal.ToUnit(b.Fee, 18); err != nil {
+			return fmt.Errorf("invalid fee %q; %w", b.Fee, err)
+		}
+	}
+
+	return d.step(ctx, c, name, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		addr, tx, _, err := abi.DeployFeeFixed(opts, c.Backend, fee)
+		return &addr, tx, err
+	})
+}
+
+func (d *Deployer) deployLimiter(ctx context.Context, c Chain, name string, b config.DeploymentBridge) error {
+	if b.Limiter != (common.Address{}) {
+		return nil
+	}
+
+	return d.step(ctx, c, name, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		addr, tx, _, err := abi.DeployLimiterDaily(opts, c.Backend)
+		return &addr, tx, err
+	})
+}
+
+func (d *Deployer) setLimit(ctx context.Context, c Chain, name, limiterStep, bridgeStep string, b config.DeploymentBridge) error {
+	if b.Limit == "" || b.Limit == "0" {
+		return nil
+	}
+
+	decimals, err := d.decimals(ctx, c, b)
+	if err != nil {
+		return err
+	}
+	limit, err := decimal.ToUnit(b.Limit, decimals)
+	if err != nil {
+		return fmt.Errorf("invalid limit %q; %w", b.Limit, err)
+	}
+
+	return d.step(ctx, c, name, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+		limiter, _ := abi.NewLimiterDaily(d.limiter(limiterStep, b), c.Backend)
+		tx, err := limiter.SetLimit(opts, d.Manifest.Address(bridgeStep), limit)
+		return nil, tx, err
+	})
+}
+
+func (d *Deployer) decimals(ctx context.Context, c Chain, b config.DeploymentBridge) (uint8, error) {
+	switch b.Type {
+	case config.TypeEther:
+		return 18, nil
+	case config.TypeBurner:
+		return d.Spec.Token.Decimals, nil
+	}
+
+	token, _ := abi.NewIERC20Metadata(b.Token, c.Backend)
+	decimals, err := token.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("can not get decimals of %s; %w", b.Token.Hex(), err)
+	}
+	return decimals, nil
+}
+
+// step sends transaction of step unless manifest says it is done,
+// a step sent but not confirmed is awaited instead of sent again.
+// Transaction is signed and recorded in manifest before it is sent,
+// so an interrupted run never sends a second transaction of the same step
+func (d *Deployer) step(ctx context.Context, c Chain, name string, send func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error)) error {
+	s := d.Manifest.Step(name)
+	if s != nil && s.Done {
+		d.logf("%s: done %s", name, s.Tx.Hex())
+		return nil
+	}
+
+	if s != nil {
+		receipt, err := c.Backend.TransactionReceipt(ctx, s.Tx)
+		switch {
+		case errors.Is(err, ethereum.NotFound) || (err == nil && receipt == nil):
+			if _, pending, err := c.Backend.TransactionByHash(ctx, s.Tx); err == nil && pending {
+				d.logf("%s: waiting pending %s", name, s.Tx.Hex())
+				return d.confirm(ctx, c, s)
+			}
+			return d.resend(ctx, c, s)
+		case err != nil:
+			return fmt.Errorf("can not get receipt of %s; %w", name, err)
+		case receipt.Status == types.ReceiptStatusSuccessful:
+			return d.confirm(ctx, c, s)
+		default:
+			d.logf("%s: %s reverted, resend", name, s.Tx.Hex())
+		}
+	}
+
+	opts := *c.Opts
+	opts.Context = ctx
+	opts.NoSend = true
+	addr, tx, err := send(&opts)
+	if err != nil {
+		return fmt.Errorf("can not sign %s; %w", name, err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	s = &Step{Name: name, Chain: c.Name, Tx: tx.Hash(), Raw: raw, Address: addr}
+	if err := d.Manifest.record(s); err != nil {
+		return err
+	}
+	if err := c.Backend.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("can not send %s; %w", name, err)
+	}
+	d.logf("%s: sent %s", name, tx.Hash().Hex())
+
+	return d.confirm(ctx, c, s)
+}
+
+// resend sends recorded transaction of step which is neither mined nor pending again,
+// it fails when the nonce of the transaction was used since, another transaction
+// of the sender may have been mined instead and the step must be checked by hand
+func (d *Deployer) resend(ctx context.Context, c Chain, s *Step) error {
+	if len(s.Raw) == 0 {
+		return fmt.Errorf("%s: %s is not found and not recorded, check it on chain and remove the step from manifest to send it again", s.Name, s.Tx.Hex())
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(s.Raw); err != nil {
+		return fmt.Errorf("can not decode %s; %w", s.Name, err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return fmt.Errorf("can not get sender of %s; %w", s.Name, err)
+	}
+
+	nonce, err := c.Backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return fmt.Errorf("can not get nonce of %s; %w", from.Hex(), err)
+	}
+	if nonce > tx.Nonce() {
+		return fmt.Errorf("%s: nonce %d of %s was used since %s was sent, check it on chain and remove the step from manifest to send it again",
+			s.Name, tx.Nonce(), from.Hex(), s.Tx.Hex())
+	}
+
+	if err := c.Backend.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("can not send %s again; %w", s.Name, err)
+	}
+	d.logf("%s: %s was dropped, sent again", s.Name, s.Tx.Hex())
+	return d.confirm(ctx, c, s)
+}
+
+// confirm waits until step transaction has enough confirmations and marks it done
+func (d *Deployer) confirm(ctx context.Context, c Chain, s *Step) error {
+	var receipt *types.Receipt
+	for {
+		r, err := c.Backend.TransactionReceipt(ctx, s.Tx)
+		if err == nil && r != nil {
+			receipt = r
+			break
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("can not get receipt of %s; %w", s.Name, err)
+		}
+		if err := sleep(ctx, d.Poll); err != nil {
+			return err
+		}
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s tx %s reverted", s.Name, s.Tx.Hex())
+	}
+
+	for {
+		head, err := c.Backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("can not get head; %w", err)
+		}
+		if head.Number.Uint64()+1 >= receipt.BlockNumber.Uint64()+c.Confirmations {
+			break
+		}
+		if err := sleep(ctx, d.Poll); err != nil {
+			return err
+		}
+	}
+
+	s.Block = receipt.BlockNumber.Uint64()
+	s.Done = true
+	if err := d.Manifest.record(s); err != nil {
+		return err
+	}
+	d.logf("%s: confirmed block %d", s.Name, s.Block)
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package deploy_test
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/deploy"
+	"killswitch/bridge/testutil"
+)
+
+func TestDeployer(t *testing.T) {
+	ctx := testutil.Setup(t)
+	sourceOwner := ctx.Wallets[0]
+	destinationOwner := ctx.Wallets[1]
+
+	_, tokenAddr := testutil.DeployTokenWith(ctx, ctx.Wallets[5], "DAI", "DAI", 6)
+	testutil.AutoCommit(t, ctx)
+
+	spec := config.Deployment{
+		Name: "DAI <=> kDAI",
+		Source: config.DeploymentBridge{
+			Chain: "bsc",
+			Type:  config.TypeLocker,
+			Name:  "DAI Locker",
+			Token: tokenAddr,
+			Fee:   "0.01",
+			Limit: "1000",
+		},
+		Destination: config.DeploymentBridge{
+			Chain: "bkc",
+			Type:  config.TypeBurner,
+			Name:  "kDAI Burner",
+			Fee:   "0.1",
+		},
+		Token: config.DeploymentToken{Name: "kDAI", Symbol: "kDAI", Decimals: 6},
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	manifest, err := deploy.LoadManifest(path, spec.Name)
+	require.NoError(t, err)
+
+	// destination account without gas, deployment stops after source
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	poorOpts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+
+	d := &deploy.Deployer{
+		Spec:        spec,
+		Source:      deploy.Chain{Name: "bsc", Backend: ctx.Backend, Opts: sourceOwner.TxOpts, Confirmations: 2},
+		Destination: deploy.Chain{Name: "bkc", Backend: ctx.Backend, Opts: poorOpts},
+		Manifest:    manifest,
+		Poll:        10 * time.Millisecond,
+	}
+	require.Error(t, d.Run(ctx))
+
+	manifest, err = deploy.LoadManifest(path, spec.Name)
+	require.NoError(t, err)
+	require.Len(t, manifest.Steps, 4)
+	for _, s := range manifest.Steps {
+		require.True(t, s.Done, s.Name)
+	}
+
+	// resume
+	sourceNonce, err := ctx.Backend.PendingNonceAt(ctx, sourceOwner.Address)
+	require.NoError(t, err)
+
+	d.Manifest = manifest
+	d.Destination.Opts = destinationOwner.TxOpts
+	require.NoError(t, d.Run(ctx))
+
+	nonce, err := ctx.Backend.PendingNonceAt(ctx, sourceOwner.Address)
+	require.NoError(t, err)
+	require.Equal(t, sourceNonce, nonce)
+
+	// run again does nothing
+	destinationNonce, err := ctx.Backend.PendingNonceAt(ctx, destinationOwner.Address)
+	require.NoError(t, err)
+	require.NoError(t, d.Run(ctx))
+	nonce, err = ctx.Backend.PendingNonceAt(ctx, destinationOwner.Address)
+	require.NoError(t, err)
+	require.Equal(t, destinationNonce, nonce)
+
+	pair := d.Pair()
+	require.NotZero(t, pair.Source.Address)
+	require.NotZero(t, pair.Destination.Address)
+
+	t.Run("Source", func(t *testing.T) {
+		locker, err := abi.NewBridgeLocker(pair.Source.Address, ctx.Backend)
+		require.NoError(t, err)
+
+		token, err := locker.Token(nil)
+		require.NoError(t, err)
+		require.Equal(t, tokenAddr, token)
+
+		fee, err := locker.CalculateFee(nil, big.NewInt(0))
+		require.NoError(t, err)
+		require.Equal(t, decimal.EtherToWei("0.01").String(), fee.String())
+
+		limiterAddr, err := locker.GetLimiter(nil)
+		require.NoError(t, err)
+		limiter, err := abi.NewLimiterDaily(limiterAddr, ctx.Backend)
+		require.NoError(t, err)
+		limit, err := limiter.GetLimit(nil, pair.Source.Address)
+		require.NoError(t, err)
+		require.Equal(t, "1000000000", limit.String())
+
+		owner, err := locker.Owner(nil)
+		require.NoError(t, err)
+		require.Equal(t, sourceOwner.Address, owner)
+	})
+
+	t.Run("Destination", func(t *testing.T) {
+		burner, err := abi.NewBridgeBurner(pair.Destination.Address, ctx.Backend)
+		require.NoError(t, err)
+
+		tokenAddr, err := burner.Token(nil)
+		require.NoError(t, err)
+		token, err := abi.NewWrappedToken(tokenAddr, ctx.Backend)
+		require.NoError(t, err)
+
+		symbol, err := token.Symbol(nil)
+		require.NoError(t, err)
+		require.Equal(t, "kDAI", symbol)
+		decimals, err := token.Decimals(nil)
+		require.NoError(t, err)
+		require.Equal(t, uint8(6), decimals)
+
+		mintable, err := token.Mintable(nil, pair.Destination.Address)
+		require.NoError(t, err)
+		require.True(t, mintable)
+
+		fee, err := burner.CalculateFee(nil, big.NewInt(0))
+		require.NoError(t, err)
+		require.Equal(t, decimal.EtherToWei("0.1").String(), fee.String())
+
+		limiterAddr, err := burner.GetLimiter(nil)
+		require.NoError(t, err)
+		require.NotZero(t, limiterAddr)
+	})
+}
+
+func TestManifestPair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+
+	m, err := deploy.LoadManifest(path, "A")
+	require.NoError(t, err)
+	require.NoError(t, m.Save())
+
+	_, err = deploy.LoadManifest(path, "B")
+	require.Error(t, err)
+
+	_, err = deploy.LoadManifest(path, "A")
+	require.NoError(t, err)
+
+}
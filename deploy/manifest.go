@@ -0,0 +1,111 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Step is a deployment transaction recorded in manifest
+type Step struct {
+	Name  string      `json:"name"`
+	Chain string      `json:"chain"`
+	Tx    common.Hash `json:"tx"`
+	// Raw is signed transaction, recorded before it is sent so a dropped transaction
+	// is sent again with the same nonce
+	Raw     hexutil.Bytes   `json:"raw,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Block   uint64          `json:"block,omitempty"`
+	Done    bool            `json:"done"`
+}
+
+// Manifest records deployment progress, it is saved after every step
+// so an interrupted deployment can be resumed
+type Manifest struct {
+	Pair  string  `json:"pair"`
+	Steps []*Step `json:"steps"`
+
+	path string
+}
+
+// LoadManifest reads manifest from path, new manifest is returned when file does not exist
+func LoadManifest(path, pair string) (*Manifest, error) {
+	m := &Manifest{Pair: pair, path: path}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read manifest; %w", err)
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("can not parse manifest; %w", err)
+	}
+	if m.Pair != pair {
+		return nil, fmt.Errorf("manifest %s is for pair %s", path, m.Pair)
+	}
+	return m, nil
+}
+
+// Step returns recorded step by name
+func (m *Manifest) Step(name string) *Step {
+	for _, s := range m.Steps {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Address returns deployed contract address of step
+func (m *Manifest) Address(name string) common.Address {
+	if s := m.Step(name); s != nil && s.Address != nil {
+		return *s.Address
+	}
+	return common.Address{}
+}
+
+func (m *Manifest) record(s *Step) error {
+	if old := m.Step(s.Name); old != nil {
+		*old = *s
+	} else {
+		m.Steps = append(m.Steps, s)
+	}
+	return m.Save()
+}
+
+// Save writes manifest atomically
+func (m *Manifest) Save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), ".manifest")
+	if err != nil {
+		return fmt.Errorf("can not write manifest; %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("can not write manifest; %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("can not write manifest; %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("can not write manifest; %w", err)
+	}
+	return nil
+}
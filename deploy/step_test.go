@@ -0,0 +1,92 @@
+package deploy
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/testutil"
+)
+
+func TestResend(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	to := ctx.Wallets[1].Address
+
+	c := Chain{Name: "bsc", Backend: ctx.Backend, Opts: owner.TxOpts}
+	d := &Deployer{Manifest: &Manifest{}, Poll: 10 * time.Millisecond}
+
+	// signed transfer of value, sent only when opts allow
+	transfer := func(opts *bind.TransactOpts, value int64) *types.Transaction {
+		nonce, err := ctx.Backend.PendingNonceAt(ctx, opts.From)
+		require.NoError(t, err)
+		tx, err := opts.Signer(opts.From, types.NewTransaction(nonce, to, big.NewInt(value), 21000, big.NewInt(1), nil))
+		require.NoError(t, err)
+		if !opts.NoSend {
+			require.NoError(t, ctx.Backend.SendTransaction(ctx, tx))
+		}
+		return tx
+	}
+	record := func(name string, tx *types.Transaction) {
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, d.Manifest.record(&Step{Name: name, Chain: c.Name, Tx: tx.Hash(), Raw: raw}))
+	}
+	step := func(name string) error {
+		return d.step(ctx, c, name, func(opts *bind.TransactOpts) (*common.Address, *types.Transaction, error) {
+			return nil, transfer(opts, 1), nil
+		})
+	}
+	testutil.AutoCommit(t, ctx)
+
+	t.Run("Sent", func(t *testing.T) {
+		require.NoError(t, step("sent"))
+		s := d.Manifest.Step("sent")
+		require.True(t, s.Done)
+		require.NotEmpty(t, s.Raw)
+	})
+
+	t.Run("Dropped", func(t *testing.T) {
+		// signed and recorded, interrupted before it was sent
+		opts := *owner.TxOpts
+		opts.NoSend = true
+		tx := transfer(&opts, 2)
+		record("dropped", tx)
+
+		require.NoError(t, step("dropped"))
+		require.True(t, d.Manifest.Step("dropped").Done)
+		receipt, err := ctx.Backend.TransactionReceipt(ctx, tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	})
+
+	t.Run("NonceUsed", func(t *testing.T) {
+		opts := *owner.TxOpts
+		opts.NoSend = true
+		record("replaced", transfer(&opts, 3))
+
+		// another transaction of owner takes the nonce
+		replacement := transfer(owner.TxOpts, 4)
+		require.Eventually(t, func() bool {
+			r, _ := ctx.Backend.TransactionReceipt(ctx, replacement.Hash())
+			return r != nil
+		}, time.Second, 10*time.Millisecond)
+
+		err := step("replaced")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "was used")
+		require.False(t, d.Manifest.Step("replaced").Done)
+	})
+
+	t.Run("NotRecorded", func(t *testing.T) {
+		require.NoError(t, d.Manifest.record(&Step{Name: "legacy", Chain: c.Name, Tx: common.Hash{1}}))
+		err := step("legacy")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not recorded")
+	})
+}
@@ -0,0 +1,247 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+)
+
+// Pair is activity of a pair in digest period, amounts are in smallest unit
+type Pair struct {
+	Name string
+	// Decimals of bridged asset
+	Decimals uint8
+	// Locks are locked on source bridge and Unlocks unlocked on destination bridge in period
+	Locks        int
+	LockVolume   *big.Int
+	Unlocks      int
+	UnlockVolume *big.Int
+	// Fees is native fee collected by source bridge
+	Fees *big.Int
+	// GasSpent is native fee paid by relayer for unlocks on destination chain
+	GasSpent *big.Int
+
+	// LimiterPeak is highest daily limiter usage of source bridge sampled in period,
+	// LimiterLimit is nil without limiter and zero when unlimited
+	LimiterPeak  *big.Int
+	LimiterLimit *big.Int
+
+	// Reserve is reconciliation at end of period, nil when it failed with ReserveErr
+	Reserve    *reserve.Result
+	ReserveErr string
+}
+
+// Incident is a notable event in digest period
+type Incident struct {
+	Time time.Time
+	Text string
+}
+
+// Report is digest of bridge activity in period [From, To)
+type Report struct {
+	From      time.Time
+	To        time.Time
+	Pairs     []Pair
+	Relayers  []monitor.Runway
+	Incidents []Incident
+}
+
+// Builder collects limiter peaks and incidents between reports and builds digest reports
+type Builder struct {
+	Pairs   []config.Pair
+	Clients map[string]multiclient.Backend
+	DB      *indexer.DB
+	Monitor config.Monitor
+
+	mu        sync.Mutex
+	peaks     map[string]*big.Int
+	incidents []Incident
+}
+
+// Incident records incident for next report
+func (b *Builder) Incident(at time.Time, format string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.incidents = append(b.incidents, Incident{Time: at, Text: fmt.Sprintf(format, args...)})
+}
+
+// limiter returns daily usage and limit of bridge, nil limit without limiter
+func limiter(ctx context.Context, b config.Bridge, client multiclient.Backend) (usage, limit *big.Int, err error) {
+	opts := &bind.CallOpts{Context: ctx}
+	bridge, _ := abi.NewBridgeBase(b.Address, client)
+
+	addr, err := bridge.GetLimiter(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not get limiter of %s; %w", b, err)
+	}
+	if addr == (common.Address{}) {
+		return nil, nil, nil
+	}
+	if usage, err = bridge.GetLimiterUsage(opts); err != nil {
+		return nil, nil, fmt.Errorf("can not get limiter usage of %s; %w", b, err)
+	}
+	l, _ := abi.NewLimiterDaily(addr, client)
+	if limit, err = l.GetLimit(opts, b.Address); err != nil {
+		return nil, nil, fmt.Errorf("can not get limit of %s; %w", b, err)
+	}
+	return usage, limit, nil
+}
+
+// Sample records limiter usage of source bridges, usage resets daily
+// so it should be sampled regularly to find the peak of the period
+func (b *Builder) Sample(ctx context.Context) error {
+	for _, p := range b.Pairs {
+		usage, _, err := limiter(ctx, p.Source, b.Clients[p.Source.Chain])
+		if err != nil {
+			return err
+		}
+		if usage == nil {
+			continue
+		}
+
+		b.mu.Lock()
+		if b.peaks == nil {
+			b.peaks = map[string]*big.Int{}
+		}
+		if peak, ok := b.peaks[p.Name]; !ok || usage.Cmp(peak) > 0 {
+			b.peaks[p.Name] = usage
+		}
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+func within(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+// Build returns report of period [from, to) with limiter peaks and incidents before to,
+// they are kept until Done so a report which could not be posted can be built again
+func (b *Builder) Build(ctx context.Context, from, to time.Time) (Report, error) {
+	if err := b.Sample(ctx); err != nil {
+		return Report{}, err
+	}
+
+	activity, err := b.DB.Activity(ctx, from, to)
+	if err != nil {
+		return Report{}, err
+	}
+	bridges := map[[2]string]indexer.Activity{}
+	for _, a := range activity {
+		bridges[[2]string{a.Pair, a.Side}] = a
+	}
+	mismatches, err := b.DB.Transfers(ctx, indexer.Query{Status: indexer.StatusMismatch, From: from, To: to})
+	if err != nil {
+		return Report{}, err
+	}
+
+	r := Report{From: from, To: to}
+	b.mu.Lock()
+	peaks := map[string]*big.Int{}
+	for name, peak := range b.peaks {
+		peaks[name] = peak
+	}
+	for _, i := range b.incidents {
+		if i.Time.Before(to) {
+			r.Incidents = append(r.Incidents, i)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, p := range b.Pairs {
+		source, destination := b.Clients[p.Source.Chain], b.Clients[p.Destination.Chain]
+		decimals, err := admin.NewBridge(p.Source, source).Decimals(ctx)
+		if err != nil {
+			return Report{}, err
+		}
+
+		s := Pair{
+			Name:         p.Name,
+			Decimals:     decimals,
+			LockVolume:   new(big.Int),
+			UnlockVolume: new(big.Int),
+			Fees:         new(big.Int),
+			GasSpent:     new(big.Int),
+		}
+		if a, ok := bridges[[2]string{p.Name, config.SideSource}]; ok {
+			s.Locks, s.LockVolume, s.Fees = a.Locks, a.LockVolume, a.Fees
+		}
+		if a, ok := bridges[[2]string{p.Name, config.SideDestination}]; ok {
+			s.Unlocks, s.UnlockVolume, s.GasSpent = a.Unlocks, a.UnlockVolume, a.GasFees
+		}
+		for _, t := range mismatches {
+			if u := t.Unlock; t.Lock.Pair == p.Name && within(u.Time, from, to) {
+				r.Incidents = append(r.Incidents, Incident{Time: u.Time, Text: fmt.Sprintf("%s unlock %s does not match lock %s", p.Name, u.Tx.Hex(), t.Lock.Hash.Hex())})
+			}
+		}
+
+		if _, s.LimiterLimit, err = limiter(ctx, p.Source, source); err != nil {
+			return Report{}, err
+		}
+		if s.LimiterLimit != nil {
+			s.LimiterPeak = peaks[p.Name]
+		}
+
+		res, err := reserve.Check(ctx, p, source, destination)
+		if err != nil {
+			s.ReserveErr = err.Error()
+		} else {
+			s.Reserve = &res
+			if res.Deficit() {
+				r.Incidents = append(r.Incidents, Incident{Time: to, Text: fmt.Sprintf("%s minted exceeds locked by %s", p.Name, decimal.FromUnit(new(big.Int).Neg(res.Delta()), decimals))})
+			}
+		}
+		r.Pairs = append(r.Pairs, s)
+	}
+
+	sort.SliceStable(r.Incidents, func(i, j int) bool {
+		return r.Incidents[i].Time.Before(r.Incidents[j].Time)
+	})
+
+	w := &monitor.BalanceWatcher{Pairs: b.Pairs, Clients: b.Clients, Config: b.Monitor}
+	if r.Relayers, err = w.Check(ctx); err != nil {
+		return Report{}, err
+	}
+	return r, nil
+}
+
+// Done drops limiter peaks and incidents before to once report of to is posted
+func (b *Builder) Done(to time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.peaks = nil
+	var later []Incident
+	for _, i := range b.incidents {
+		if !i.Time.Before(to) {
+			later = append(later, i)
+		}
+	}
+	b.incidents = later
+}
+
+// Next returns next daily run at offset after UTC midnight which is after now
+func Next(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	for !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
@@ -0,0 +1,122 @@
+package digest_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/digest"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestBuilder(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	send := func(tx interface{}, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	limiterAddr, _, limiter, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	send(wrapped.AddMinter(owner.TxOpts, burnerAddr))
+	send(ether.SetLimiter(owner.TxOpts, limiterAddr))
+	send(limiter.SetLimit(owner.TxOpts, etherAddr, decimal.EtherToWei("10")))
+
+	lock := func(amount string) common.Hash {
+		opts := *user.TxOpts
+		opts.Value = decimal.EtherToWei(amount)
+		tx, err := ether.Lock(&opts, decimal.EtherToWei(amount))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		return tx.Hash()
+	}
+	send(burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1.5"), lock("1.5")))
+	lock("2.5")
+
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+	}
+	clients := map[string]multiclient.Backend{"bsc": ctx.Archive(), "bkc": ctx.Archive()}
+
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ix := &indexer.Indexer{DB: db, Pairs: []config.Pair{pair}, Chains: map[string]config.Chain{"bsc": {}, "bkc": {}}, Clients: clients}
+	require.NoError(t, ix.Sync(ctx))
+
+	b := &digest.Builder{Pairs: []config.Pair{pair}, Clients: clients, DB: db}
+	require.NoError(t, b.Sample(ctx))
+
+	head, err := ctx.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	now := time.Unix(int64(head.Time), 0)
+	b.Incident(now, "%s paused", pair.Source)
+	b.Incident(now.Add(2*time.Hour), "later")
+
+	r, err := b.Build(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, r.Pairs, 1)
+	p := r.Pairs[0]
+	require.Equal(t, 2, p.Locks)
+	require.Equal(t, decimal.EtherToWei("4"), p.LockVolume)
+	require.Equal(t, 1, p.Unlocks)
+	require.Equal(t, decimal.EtherToWei("1.5"), p.UnlockVolume)
+	require.Positive(t, p.GasSpent.Sign())
+	require.Equal(t, decimal.EtherToWei("4"), p.LimiterPeak)
+	require.Equal(t, decimal.EtherToWei("10"), p.LimiterLimit)
+	// 2.5 locked is not minted yet
+	require.False(t, p.Reserve.Valid())
+	require.False(t, p.Reserve.Deficit())
+	// owner relays on both chains
+	require.Len(t, r.Relayers, 2)
+	require.Len(t, r.Incidents, 1)
+
+	raw, _ := json.Marshal(r.Message().Blocks)
+	blocks := string(raw)
+	require.Contains(t, blocks, "Bridge digest")
+	require.Contains(t, blocks, `*Locks*\n2, 4`)
+	require.Contains(t, blocks, `*Unlocks*\n1, 1.5`)
+	require.Contains(t, blocks, "4 of 10 (40%)")
+	require.Contains(t, blocks, "locked 4 exceeds minted 1.5")
+	require.Contains(t, blocks, "paused")
+
+	t.Run("Retry", func(t *testing.T) {
+		r, err := b.Build(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, r.Incidents, 1)
+		require.Equal(t, decimal.EtherToWei("4"), r.Pairs[0].LimiterPeak)
+	})
+
+	t.Run("Consumed", func(t *testing.T) {
+		b.Done(now.Add(time.Hour))
+		r, err := b.Build(ctx, now.Add(time.Hour), now.Add(3*time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, r.Pairs[0].Locks)
+		require.Equal(t, []digest.Incident{{Time: now.Add(2 * time.Hour), Text: "later"}}, r.Incidents)
+	})
+}
+
+func TestNext(t *testing.T) {
+	at := 9 * time.Hour
+	require.Equal(t, time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC), digest.Next(time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC), at))
+	require.Equal(t, time.Date(2021, 6, 2, 9, 0, 0, 0, time.UTC), digest.Next(time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC), at))
+}
@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/slack"
+)
+
+// Message returns report formatted as Slack blocks, amounts are in token unit
+func (r Report) Message() slack.Message {
+	title := "Bridge digest " + r.From.UTC().Format("2006-01-02")
+	m := slack.Message{
+		ResponseType: "in_channel",
+		Text:         title,
+		Blocks: []slack.Block{
+			slack.Header(title),
+			slack.Context(fmt.Sprintf("%s to %s UTC", r.From.UTC().Format("2006-01-02 15:04"), r.To.UTC().Format("2006-01-02 15:04"))),
+		},
+	}
+
+	for _, p := range r.Pairs {
+		m.Blocks = append(m.Blocks,
+			slack.Section("*"+p.Name+"*"),
+			slack.Fields(
+				fmt.Sprintf("*Locks*\n%d, %s", p.Locks, decimal.FromUnit(p.LockVolume, p.Decimals)),
+				fmt.Sprintf("*Unlocks*\n%d, %s", p.Unlocks, decimal.FromUnit(p.UnlockVolume, p.Decimals)),
+				fmt.Sprintf("*Fees collected*\n%s native", decimal.FromUnit(p.Fees, 18)),
+				fmt.Sprintf("*Relayer gas*\n%s native", decimal.FromUnit(p.GasSpent, 18)),
+				"*Limiter peak*\n"+p.limiter(),
+				"*Reserve*\n"+p.reserve(),
+			),
+		)
+	}
+
+	m.Blocks = append(m.Blocks, slack.Divider())
+	var relayers []string
+	for _, rl := range r.Relayers {
+		relayers = append(relayers, relayer(rl))
+	}
+	if len(relayers) > 0 {
+		m.Blocks = append(m.Blocks, slack.Section("*Relayers*\n"+strings.Join(relayers, "\n")))
+	}
+
+	incidents := "none"
+	if len(r.Incidents) > 0 {
+		var lines []string
+		for _, i := range r.Incidents {
+			lines = append(lines, fmt.Sprintf("• %s %s", i.Time.UTC().Format("15:04"), i.Text))
+		}
+		incidents = strings.Join(lines, "\n")
+	}
+	m.Blocks = append(m.Blocks, slack.Section("*Incidents*\n"+incidents))
+	return m
+}
+
+func (p Pair) limiter() string {
+	switch {
+	case p.LimiterLimit == nil:
+		return "no limiter"
+	case p.LimiterPeak == nil:
+		return "not sampled"
+	case p.LimiterLimit.Sign() == 0:
+		return decimal.FromUnit(p.LimiterPeak, p.Decimals) + " of unlimited"
+	}
+	pct := new(big.Int).Div(new(big.Int).Mul(p.LimiterPeak, big.NewInt(100)), p.LimiterLimit)
+	return fmt.Sprintf("%s of %s (%s%%)", decimal.FromUnit(p.LimiterPeak, p.Decimals), decimal.FromUnit(p.LimiterLimit, p.Decimals), pct)
+}
+
+func (p Pair) reserve() string {
+	r := p.Reserve
+	switch {
+	case r == nil:
+		return ":x: " + p.ReserveErr
+	case r.Deficit():
+		return fmt.Sprintf(":rotating_light: minted %s exceeds locked %s", decimal.FromUnit(r.Minted, p.Decimals), decimal.FromUnit(r.Locked, p.Decimals))
+	case !r.Valid():
+		return fmt.Sprintf(":warning: locked %s exceeds minted %s", decimal.FromUnit(r.Locked, p.Decimals), decimal.FromUnit(r.Minted, p.Decimals))
+	}
+	return fmt.Sprintf(":white_check_mark: %s locked = minted", decimal.FromUnit(r.Locked, p.Decimals))
+}
+
+func relayer(r monitor.Runway) string {
+	mark := ":white_check_mark:"
+	switch r.Level {
+	case monitor.LevelWarning:
+		mark = ":warning:"
+	case monitor.LevelCritical:
+		mark = ":rotating_light:"
+	}
+	return fmt.Sprintf("%s %s `%s` balance %s, %d unlocks", mark, r.Chain, r.Account.Hex(), decimal.FromUnit(r.Balance, 18), r.Unlocks)
+}
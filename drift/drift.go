@@ -0,0 +1,220 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+)
+
+// Fields of bridge state
+const (
+	FieldFee       = "fee"
+	FieldFeeAmount = "feeAmount"
+	FieldLimiter   = "limiter"
+	FieldLimit     = "limit"
+	FieldMinter    = "minter"
+	FieldPaused    = "paused"
+	FieldOwner     = "owner"
+)
+
+// Diff is a field of bridge which differs from desired state
+type Diff struct {
+	Bridge  config.Bridge
+	Field   string
+	Current string
+	Desired string
+
+	change func(ctx context.Context) (admin.Change, error)
+}
+
+// String returns readable diff
+func (d Diff) String() string {
+	return fmt.Sprintf("%s %s: %s => %s", d.Bridge, d.Field, d.Current, d.Desired)
+}
+
+// Change returns admin change which converges the field,
+// it reads live state so diffs must be changed and applied in order
+func (d Diff) Change(ctx context.Context) (admin.Change, error) {
+	return d.change(ctx)
+}
+
+// Plan compares live state of bridge with its desired state,
+// diffs are returned in apply order, owner is always the last
+func Plan(ctx context.Context, b *admin.Bridge) ([]Diff, error) {
+	desired := b.State
+	if desired == nil {
+		return nil, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	var diffs []Diff
+	add := func(field, current, want string, change func(ctx context.Context) (admin.Change, error)) {
+		if current != want {
+			diffs = append(diffs, Diff{Bridge: b.Bridge, Field: field, Current: current, Desired: want, change: change})
+		}
+	}
+
+	fee, err := b.Fee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if desired.Fee != nil {
+		want := *desired.Fee
+		add(FieldFee, fee.Hex(), want.Hex(), func(ctx context.Context) (admin.Change, error) {
+			return b.SetFee(ctx, want)
+		})
+		fee = want
+	}
+	if desired.FeeAmount != "" {
+		want, err := decimal.ToUnit(desired.FeeAmount, 18)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee amount of %s; %w", b, err)
+		}
+		if fee == (common.Address{}) {
+			return nil, fmt.Errorf("%s has no fee contract", b)
+		}
+		feeFixed, _ := abi.NewFeeFixed(fee, b.Backend)
+		current, err := feeFixed.Fee(opts)
+		if err != nil {
+			return nil, fmt.Errorf("can not get fee amount of %s; %w", b, err)
+		}
+		add(FieldFeeAmount, decimal.FromUnit(current, 18), decimal.FromUnit(want, 18), func(ctx context.Context) (admin.Change, error) {
+			return b.SetFeeAmount(ctx, want)
+		})
+	}
+
+	limiter, err := b.Limiter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if desired.Limiter != nil {
+		want := *desired.Limiter
+		add(FieldLimiter, limiter.Hex(), want.Hex(), func(ctx context.Context) (admin.Change, error) {
+			return b.SetLimiter(ctx, want)
+		})
+		limiter = want
+	}
+	if desired.Limit != "" {
+		decimals, err := b.Decimals(ctx)
+		if err != nil {
+			return nil, err
+		}
+		want, err := decimal.ToUnit(desired.Limit, decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit of %s; %w", b, err)
+		}
+		if limiter == (common.Address{}) {
+			return nil, fmt.Errorf("%s has no limiter", b)
+		}
+		limiterDaily, _ := abi.NewLimiterDaily(limiter, b.Backend)
+		current, err := limiterDaily.GetLimit(opts, b.Address)
+		if err != nil {
+			return nil, fmt.Errorf("can not get limit of %s; %w", b, err)
+		}
+		add(FieldLimit, decimal.FromUnit(current, decimals), decimal.FromUnit(want, decimals), func(ctx context.Context) (admin.Change, error) {
+			return b.SetLimit(ctx, want)
+		})
+	}
+
+	if desired.Minters != nil {
+		minterDiffs, err := planMinters(ctx, b, desired.Minters)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, minterDiffs...)
+	}
+
+	if desired.Paused != nil {
+		want := *desired.Paused
+		bridge, _ := abi.NewBridgeBase(b.Address, b.Backend)
+		current, err := bridge.Paused(opts)
+		if err != nil {
+			return nil, fmt.Errorf("can not get paused of %s; %w", b, err)
+		}
+		add(FieldPaused, fmt.Sprint(current), fmt.Sprint(want), func(ctx context.Context) (admin.Change, error) {
+			if want {
+				return b.Pause(ctx)
+			}
+			return b.Unpause(ctx)
+		})
+	}
+
+	if desired.Owner != nil {
+		want := *desired.Owner
+		ownable, _ := abi.NewOwnable(b.Address, b.Backend)
+		current, err := ownable.Owner(opts)
+		if err != nil {
+			return nil, fmt.Errorf("can not get owner of %s; %w", b, err)
+		}
+		add(FieldOwner, current.Hex(), want.Hex(), func(ctx context.Context) (admin.Change, error) {
+			return b.TransferOwnership(ctx, admin.OwnableBridge, want)
+		})
+	}
+
+	return diffs, nil
+}
+
+// planMinters adds missing minters before removing unexpected ones
+func planMinters(ctx context.Context, b *admin.Bridge, want []common.Address) ([]Diff, error) {
+	current, err := b.Minters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	has := map[common.Address]bool{}
+	for _, m := range current {
+		has[m] = true
+	}
+	wanted := map[common.Address]bool{}
+	for _, m := range want {
+		wanted[m] = true
+	}
+
+	var diffs []Diff
+	for _, m := range want {
+		m := m
+		if !has[m] {
+			diffs = append(diffs, Diff{Bridge: b.Bridge, Field: FieldMinter, Current: "absent " + m.Hex(), Desired: "minter " + m.Hex(), change: func(ctx context.Context) (admin.Change, error) {
+				return b.AddMinter(ctx, m)
+			}})
+		}
+	}
+	for _, m := range current {
+		m := m
+		if !wanted[m] {
+			diffs = append(diffs, Diff{Bridge: b.Bridge, Field: FieldMinter, Current: "minter " + m.Hex(), Desired: "absent " + m.Hex(), change: func(ctx context.Context) (admin.Change, error) {
+				return b.RemoveMinter(ctx, m)
+			}})
+		}
+	}
+	return diffs, nil
+}
+
+// Execute applies an admin change
+type Execute func(ctx context.Context, chain string, c admin.Change) error
+
+// Apply converges diffs in order, changes are created right before executed
+// so each change sees the state left by the previous one
+func Apply(ctx context.Context, diffs []Diff, execute Execute) error {
+	for _, d := range diffs {
+		c, err := d.Change(ctx)
+		if errors.Is(err, admin.ErrNoChange) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can not apply %s; %w", d, err)
+		}
+		if err := execute(ctx, d.Bridge.Chain, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
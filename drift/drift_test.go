@@ -0,0 +1,77 @@
+package drift_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/drift"
+	"killswitch/bridge/testutil"
+)
+
+func TestPlanApply(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, tokenAddr, "kDAI Burner", decimal.EtherToWei("0.1"))
+	limiterAddr, _, limiter, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	paused := false
+	state := &config.State{
+		Owner:     &owner.Address,
+		FeeAmount: "0.2",
+		Limiter:   &limiterAddr,
+		Limit:     "100",
+		Paused:    &paused,
+		Minters:   []common.Address{burnerAddr},
+	}
+	b := admin.NewBridge(config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, State: state, Pair: "DAI <=> kDAI", Side: config.SideDestination}, ctx.Backend)
+
+	diffs, err := drift.Plan(ctx, b)
+	require.NoError(t, err)
+
+	var fields []string
+	for _, d := range diffs {
+		fields = append(fields, d.Field)
+	}
+	require.Equal(t, []string{drift.FieldFeeAmount, drift.FieldLimiter, drift.FieldLimit, drift.FieldMinter}, fields)
+	require.Equal(t, "0.1", diffs[0].Current)
+	require.Equal(t, "0.2", diffs[0].Desired)
+	require.Equal(t, "0", diffs[2].Current)
+	require.Equal(t, "100", diffs[2].Desired)
+
+	testutil.AutoCommit(t, ctx)
+
+	var actions []string
+	err = drift.Apply(ctx, diffs, func(execCtx context.Context, chain string, c admin.Change) error {
+		require.Equal(t, "bkc", chain)
+		actions = append(actions, c.Action)
+		_, _, err := c.Execute(execCtx, owner.TxOpts, ctx.Backend)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"FeeFixed.setFee", "setLimiter", "LimiterDaily.setLimit", "WrappedToken.addMinter"}, actions)
+
+	fee, err := burner.CalculateFee(nil, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	require.Equal(t, decimal.EtherToWei("0.2").String(), fee.String())
+	limit, err := limiter.GetLimit(nil, burnerAddr)
+	require.NoError(t, err)
+	require.Equal(t, decimal.EtherToWei("100").String(), limit.String())
+	mintable, err := token.Mintable(nil, burnerAddr)
+	require.NoError(t, err)
+	require.True(t, mintable)
+
+	diffs, err = drift.Plan(ctx, b)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
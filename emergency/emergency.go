@@ -0,0 +1,180 @@
+package emergency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+)
+
+// ErrUnconfirmed is returned when pause tx is not mined within timeout of its chain
+var ErrUnconfirmed = errors.New("emergency: tx not confirmed")
+
+// DefaultTimeout is wait for pause tx on chain without timeout
+const DefaultTimeout = 2 * time.Minute
+
+// Timeouts returns wait of blocks block times for every chain,
+// chains without block time use DefaultTimeout
+func Timeouts(chains map[string]config.Chain, blocks int) map[string]time.Duration {
+	r := map[string]time.Duration{}
+	for name, c := range chains {
+		if c.BlockTime > 0 {
+			r[name] = time.Duration(blocks) * c.BlockTime
+		}
+	}
+	return r
+}
+
+// Result is pause result of a bridge
+type Result struct {
+	Bridge        config.Bridge
+	AlreadyPaused bool
+	Tx            *types.Transaction
+	Receipt       *types.Receipt
+	Err           error
+}
+
+// OK reports whether bridge is paused
+func (r Result) OK() bool {
+	return r.Err == nil
+}
+
+// String returns readable result
+func (r Result) String() string {
+	switch {
+	case errors.Is(r.Err, ErrUnconfirmed):
+		return fmt.Sprintf("WAIT %s tx %s is sent but not confirmed", r.Bridge, r.Tx.Hash().Hex())
+	case r.Err != nil:
+		return fmt.Sprintf("FAIL %s; %v", r.Bridge, r.Err)
+	case r.AlreadyPaused:
+		return fmt.Sprintf("OK   %s already paused", r.Bridge)
+	default:
+		return fmt.Sprintf("OK   %s paused tx %s", r.Bridge, r.Tx.Hash().Hex())
+	}
+}
+
+// TransactOpts returns signing options of chain
+type TransactOpts func(ctx context.Context, chain string) (*bind.TransactOpts, error)
+
+// Pauser pauses every bridge, chains are processed in parallel
+type Pauser struct {
+	Bridges []*admin.Bridge
+	Opts    TransactOpts
+	// Timeouts bound wait for each pause tx per chain, default DefaultTimeout
+	Timeouts map[string]time.Duration
+}
+
+// PauseAll pauses all bridges and returns result of each bridge in the same order,
+// transactions of a chain are sent back to back with consecutive nonces
+// then all receipts are awaited in parallel
+func (p *Pauser) PauseAll(ctx context.Context) []Result {
+	results := make([]Result, len(p.Bridges))
+
+	chains := map[string][]int{}
+	for i, b := range p.Bridges {
+		results[i].Bridge = b.Bridge
+		chains[b.Chain] = append(chains[b.Chain], i)
+	}
+
+	var wg sync.WaitGroup
+	for chain, idx := range chains {
+		wg.Add(1)
+		go func(chain string, idx []int) {
+			defer wg.Done()
+			p.pauseChain(ctx, chain, idx, results)
+		}(chain, idx)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (p *Pauser) pauseChain(ctx context.Context, chain string, idx []int, results []Result) {
+	opts, err := p.Opts(ctx, chain)
+	if err != nil {
+		for _, i := range idx {
+			results[i].Err = err
+		}
+		return
+	}
+
+	var nonce uint64
+	if len(idx) > 0 {
+		nonce, err = p.Bridges[idx[0]].Backend.PendingNonceAt(ctx, opts.From)
+		if err != nil {
+			for _, i := range idx {
+				results[i].Err = fmt.Errorf("can not get nonce; %w", err)
+			}
+			return
+		}
+	}
+
+	timeout := p.Timeouts[chain]
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range idx {
+		b := p.Bridges[i]
+
+		c, err := b.Pause(ctx)
+		if errors.Is(err, admin.ErrNoChange) {
+			results[i].AlreadyPaused = true
+			continue
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		o := *opts
+		o.Context = ctx
+		o.Nonce = new(big.Int).SetUint64(nonce)
+		tx, err := c.Send(&o)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		nonce++
+		results[i].Tx = tx
+
+		wg.Add(1)
+		go func(i int, b *admin.Bridge, tx *types.Transaction) {
+			defer wg.Done()
+
+			wait, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			receipt, err := bind.WaitMined(wait, b.Backend, tx)
+			results[i].Receipt = receipt
+			if err != nil && ctx.Err() == nil && errors.Is(wait.Err(), context.DeadlineExceeded) {
+				results[i].Err = fmt.Errorf("tx %s is not mined in %s; %w", tx.Hash().Hex(), timeout, ErrUnconfirmed)
+			} else if err != nil {
+				results[i].Err = fmt.Errorf("can not wait tx %s; %w", tx.Hash().Hex(), err)
+			} else if receipt.Status != types.ReceiptStatusSuccessful {
+				results[i].Err = fmt.Errorf("tx %s reverted", tx.Hash().Hex())
+			}
+		}(i, b, tx)
+	}
+	wg.Wait()
+}
+
+// Write writes results and returns whether all bridges are paused
+func Write(w io.Writer, results []Result) bool {
+	ok := true
+	for _, r := range results {
+		fmt.Fprintln(w, r)
+		ok = ok && r.OK()
+	}
+	return ok
+}
@@ -0,0 +1,114 @@
+package emergency_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/testutil"
+)
+
+func TestPauseAll(t *testing.T) {
+	ctx := testutil.Setup(t)
+
+	// addr0 => bsc owner
+	// addr1 => bkc owner
+	// addr2 => owner of bridge not owned by operator
+	bscOwner := ctx.Wallets[0]
+	bkcOwner := ctx.Wallets[1]
+
+	_, tokenAddr := testutil.DeployToken(ctx, bscOwner)
+	locker1, locker1Addr := testutil.DeployBridgeLocker(ctx, bscOwner, tokenAddr, "Locker 1", decimal.EtherToWei("0"))
+	locker2, locker2Addr := testutil.DeployBridgeLocker(ctx, bscOwner, tokenAddr, "Locker 2", decimal.EtherToWei("0"))
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, bkcOwner, "Ether", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, bkcOwner, tokenAddr, "Burner", decimal.EtherToWei("0"))
+	_, foreignAddr := testutil.DeployBridgeEther(ctx, ctx.Wallets[2], "Foreign", decimal.EtherToWei("0"))
+
+	// already paused
+	_, err := burner.Pause(bkcOwner.TxOpts)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	testutil.AutoCommit(t, ctx)
+
+	bridge := func(chain, typ, pair string, b config.Bridge) *admin.Bridge {
+		b.Chain, b.Type, b.Pair = chain, typ, pair
+		return admin.NewBridge(b, ctx.Backend)
+	}
+	pauser := &emergency.Pauser{
+		Bridges: []*admin.Bridge{
+			bridge("bsc", config.TypeLocker, "locker1", config.Bridge{Address: locker1Addr}),
+			bridge("bsc", config.TypeLocker, "locker2", config.Bridge{Address: locker2Addr}),
+			bridge("bkc", config.TypeEther, "ether", config.Bridge{Address: etherAddr}),
+			bridge("bkc", config.TypeBurner, "burner", config.Bridge{Address: burnerAddr}),
+			bridge("bkc", config.TypeEther, "foreign", config.Bridge{Address: foreignAddr}),
+		},
+		Opts: func(_ context.Context, chain string) (*bind.TransactOpts, error) {
+			switch chain {
+			case "bsc":
+				return bscOwner.TxOpts, nil
+			case "bkc":
+				return bkcOwner.TxOpts, nil
+			}
+			return nil, errors.New("unknown chain")
+		},
+	}
+
+	results := pauser.PauseAll(ctx)
+	require.Len(t, results, 5)
+
+	for i, name := range []string{"locker1", "locker2", "ether"} {
+		require.Equal(t, name, results[i].Bridge.Pair)
+		require.NoError(t, results[i].Err)
+		require.NotNil(t, results[i].Tx)
+		require.NotNil(t, results[i].Receipt)
+	}
+	require.True(t, results[3].AlreadyPaused)
+	require.NoError(t, results[3].Err)
+	require.Error(t, results[4].Err)
+
+	for _, b := range []interface {
+		Paused(opts *bind.CallOpts) (bool, error)
+	}{locker1, locker2, ether, burner} {
+		paused, err := b.Paused(nil)
+		require.NoError(t, err)
+		require.True(t, paused)
+	}
+
+	var buf bytes.Buffer
+	require.False(t, emergency.Write(&buf, results))
+	require.Contains(t, buf.String(), "FAIL")
+	require.Contains(t, buf.String(), "already paused")
+}
+
+func TestPauseUnconfirmed(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	_, etherAddr := testutil.DeployBridgeEther(ctx, owner, "Ether", decimal.EtherToWei("0"))
+
+	// nothing is mined, the pause tx stays pending
+	pauser := &emergency.Pauser{
+		Bridges: []*admin.Bridge{admin.NewBridge(config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr}, ctx.Backend)},
+		Opts: func(context.Context, string) (*bind.TransactOpts, error) {
+			return owner.TxOpts, nil
+		},
+		Timeouts: map[string]time.Duration{"bsc": 100 * time.Millisecond},
+	}
+
+	results := pauser.PauseAll(ctx)
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Err, emergency.ErrUnconfirmed)
+	require.NotNil(t, results[0].Tx)
+	require.False(t, results[0].OK())
+	require.Contains(t, results[0].String(), "not confirmed")
+}
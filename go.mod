@@ -7,10 +7,13 @@ require (
 	github.com/ethereum/go-ethereum v1.10.3
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/google/go-cmp v0.5.6 // indirect
-	github.com/shopspring/decimal v1.2.0 // indirect
+	github.com/google/uuid v1.1.5
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/prometheus/client_golang v1.12.2
+	github.com/shopspring/decimal v1.2.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/net v0.0.0-20210525063256-abc453219eb5 // indirect
-	golang.org/x/sys v0.0.0-20210525143221-35b2ab0089ea // indirect
+	golang.org/x/sys v0.7.0 // indirect
 	google.golang.org/api v0.47.0 // indirect
 	google.golang.org/genproto v0.0.0-20210524171403-669157292da3 // indirect
 	google.golang.org/grpc v1.38.0 // indirect
@@ -0,0 +1,205 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+)
+
+// Check names
+const (
+	CheckRPC     = "rpc"
+	CheckStall   = "stall"
+	CheckLag     = "lag"
+	CheckBalance = "balance"
+)
+
+// Result is a readiness check of a chain
+type Result struct {
+	Check  string `json:"check"`
+	Chain  string `json:"chain"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Processed returns the last block processed by worker on chain, ok is false when nothing is processed yet
+type Processed func(ctx context.Context, chain string) (block uint64, ok bool, err error)
+
+// Checker checks readiness of a worker on every configured chain, create it with New
+type Checker struct {
+	Chains  map[string]config.Chain
+	Clients map[string]multiclient.Backend
+
+	// StallFactor is number of block times the head may not advance, default 10,
+	// chains without block time are not checked
+	StallFactor int
+	// Processed is checked to lag head at most MaxLag blocks on chains with bridges, optional
+	Processed Processed
+	// MaxLag default 100 blocks
+	MaxLag uint64
+	// Signer must afford an unlock on every chain, optional
+	Signer *common.Address
+	// UnlockGas is gas of an unlock, default 150000
+	UnlockGas uint64
+	// Timeout of all checks, default 10s
+	Timeout time.Duration
+
+	bridged map[string]bool
+	now     func() time.Time
+}
+
+// New returns checker of chains with defaults, processed blocks are checked
+// only on chains with a bridge of pairs, other chains are never indexed
+func New(chains map[string]config.Chain, clients map[string]multiclient.Backend, pairs []config.Pair) *Checker {
+	bridged := map[string]bool{}
+	for _, p := range pairs {
+		bridged[p.Source.Chain] = true
+		bridged[p.Destination.Chain] = true
+	}
+	return &Checker{
+		Chains:      chains,
+		Clients:     clients,
+		StallFactor: 10,
+		MaxLag:      100,
+		UnlockGas:   150000,
+		Timeout:     10 * time.Second,
+		bridged:     bridged,
+		now:         time.Now,
+	}
+}
+
+// Check runs all checks of every chain, ordered by chain
+func (c *Checker) Check(ctx context.Context) []Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var chains []string
+	for name := range c.Clients {
+		chains = append(chains, name)
+	}
+	sort.Strings(chains)
+
+	var r []Result
+	for _, name := range chains {
+		r = append(r, c.chain(ctx, name)...)
+	}
+	return r
+}
+
+func (c *Checker) chain(ctx context.Context, name string) []Result {
+	client := c.Clients[name]
+	chain := c.Chains[name]
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return []Result{{Check: CheckRPC, Chain: name, Detail: err.Error()}}
+	}
+	head := header.Number.Uint64()
+	r := []Result{{Check: CheckRPC, Chain: name, OK: true}}
+
+	if chain.BlockTime > 0 {
+		res := Result{Check: CheckStall, Chain: name, OK: true}
+		age := c.now().Sub(time.Unix(int64(header.Time), 0))
+		if max := time.Duration(c.StallFactor) * chain.BlockTime; age > max {
+			res.OK = false
+			res.Detail = fmt.Sprintf("head %d is %s old, expected block every %s", head, age.Round(time.Second), chain.BlockTime)
+		}
+		r = append(r, res)
+	}
+
+	if c.Processed != nil && c.bridged[name] {
+		res := Result{Check: CheckLag, Chain: name, OK: true}
+		processed, ok, err := c.Processed(ctx, name)
+		switch {
+		case err != nil:
+			res.OK = false
+			res.Detail = err.Error()
+		case !ok:
+			res.OK = false
+			res.Detail = "nothing processed yet"
+		case head > processed && head-processed > c.MaxLag:
+			res.OK = false
+			res.Detail = fmt.Sprintf("processed block %d lags head %d by %d blocks", processed, head, head-processed)
+		}
+		r = append(r, res)
+	}
+
+	if c.Signer != nil {
+		r = append(r, c.balance(ctx, name, client))
+	}
+	return r
+}
+
+// balance checks signer can pay gas of an unlock at current gas price
+func (c *Checker) balance(ctx context.Context, chain string, client multiclient.Backend) Result {
+	res := Result{Check: CheckBalance, Chain: chain}
+
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		res.Detail = fmt.Sprintf("can not get gas price; %v", err)
+		return res
+	}
+	balance, err := client.BalanceAt(ctx, *c.Signer, nil)
+	if err != nil {
+		res.Detail = fmt.Sprintf("can not get balance of %s; %v", c.Signer.Hex(), err)
+		return res
+	}
+
+	min := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(c.UnlockGas))
+	if balance.Cmp(min) < 0 {
+		res.Detail = fmt.Sprintf("signer %s balance %s is below %s needed for an unlock", c.Signer.Hex(), decimal.FromUnit(balance, 18), decimal.FromUnit(min, 18))
+		return res
+	}
+	res.OK = true
+	return res
+}
+
+// Ready reports whether all results are ok
+func Ready(results []Result) bool {
+	for _, r := range results {
+		if !r.OK {
+			return false
+		}
+	}
+	return true
+}
+
+type readyResponse struct {
+	Ready  bool     `json:"ready"`
+	Checks []Result `json:"checks"`
+}
+
+// Handler serves kubernetes probes:
+//
+//	GET /healthz  200 while process serves requests
+//	GET /readyz   200 when all checks pass, 503 otherwise, with results
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		results := c.Check(r.Context())
+		resp := readyResponse{Ready: Ready(results), Checks: results}
+
+		code := http.StatusOK
+		if !resp.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	return mux
+}
@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+type unreachable struct {
+	multiclient.Backend
+}
+
+func (unreachable) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestChecker(t *testing.T) {
+	ctx := testutil.Setup(t)
+	signer := ctx.Wallets[0].Address
+
+	for i := 0; i < 5; i++ {
+		ctx.Backend.Commit()
+	}
+	head, err := ctx.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	headTime := time.Unix(int64(head.Time), 0)
+
+	processed := uint64(0)
+	c := New(map[string]config.Chain{
+		"bsc": {Name: "bsc", BlockTime: 3 * time.Second},
+		"bkc": {Name: "bkc", BlockTime: 5 * time.Second},
+		// configured without bridges, never indexed
+		"matic": {Name: "matic"},
+	}, map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend, "matic": ctx.Backend}, []config.Pair{{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc"},
+		Destination: config.Bridge{Chain: "bkc"},
+	}})
+	c.Processed = func(ctx context.Context, chain string) (uint64, bool, error) {
+		if chain == "matic" {
+			return 0, false, nil
+		}
+		return processed, true, nil
+	}
+	c.MaxLag = 3
+	c.Signer = &signer
+	c.now = func() time.Time { return headTime.Add(40 * time.Second) }
+
+	failed := func() map[string]bool {
+		r := map[string]bool{}
+		for _, res := range c.Check(ctx) {
+			if !res.OK {
+				r[res.Check+" "+res.Chain] = true
+			}
+		}
+		return r
+	}
+
+	t.Run("Not ready", func(t *testing.T) {
+		// bsc is stalled after 30s, processed block lags 5 blocks
+		require.Equal(t, map[string]bool{"stall bsc": true, "lag bsc": true, "lag bkc": true}, failed())
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		processed = head.Number.Uint64() - 1
+		c.now = func() time.Time { return headTime.Add(time.Second) }
+		require.Empty(t, failed())
+	})
+
+	t.Run("Signer balance", func(t *testing.T) {
+		empty := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+		c.Signer = &empty
+		defer func() { c.Signer = &signer }()
+		require.Equal(t, map[string]bool{"balance bsc": true, "balance bkc": true, "balance matic": true}, failed())
+	})
+
+	t.Run("RPC unreachable", func(t *testing.T) {
+		c.Clients["bkc"] = unreachable{ctx.Backend}
+		defer func() { c.Clients["bkc"] = ctx.Backend }()
+
+		results := c.Check(ctx)
+		require.Equal(t, Result{Check: CheckRPC, Chain: "bkc", Detail: "connection refused"}, results[0])
+		require.False(t, Ready(results))
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		server := httptest.NewServer(c.Handler())
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/healthz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		ready := func() (int, readyResponse) {
+			resp, err := http.Get(server.URL + "/readyz")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var v readyResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+			return resp.StatusCode, v
+		}
+
+		code, v := ready()
+		require.Equal(t, http.StatusOK, code)
+		require.True(t, v.Ready)
+		require.Len(t, v.Checks, 10)
+
+		processed = 0
+		code, v = ready()
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.False(t, v.Ready)
+	})
+}
@@ -0,0 +1,608 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	// sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNotFound is returned when transfer is not indexed
+var ErrNotFound = errors.New("indexer: transfer not found")
+
+// Status of a transfer
+type Status string
+
+const (
+	// StatusLocked is locked and not unlocked on the other bridge yet
+	StatusLocked Status = "locked"
+	// StatusUnlocked is unlocked on the other bridge with the locked account and amount
+	StatusUnlocked Status = "unlocked"
+	// StatusMismatch is unlocked on the other bridge with different account or amount
+	StatusMismatch Status = "mismatch"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS locks (
+	hash TEXT NOT NULL,
+	pair TEXT NOT NULL,
+	side TEXT NOT NULL,
+	chain TEXT NOT NULL,
+	account TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	fee TEXT NOT NULL,
+	block INTEGER NOT NULL,
+	log_index INTEGER NOT NULL,
+	time INTEGER NOT NULL,
+	PRIMARY KEY (hash, pair, side)
+);
+CREATE INDEX IF NOT EXISTS locks_account ON locks (account, block);
+CREATE INDEX IF NOT EXISTS locks_pair ON locks (pair, side, block);
+CREATE INDEX IF NOT EXISTS locks_time ON locks (time);
+
+CREATE TABLE IF NOT EXISTS unlocks (
+	hash TEXT NOT NULL,
+	pair TEXT NOT NULL,
+	side TEXT NOT NULL,
+	chain TEXT NOT NULL,
+	account TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	tx TEXT NOT NULL,
+	block INTEGER NOT NULL,
+	log_index INTEGER NOT NULL,
+	time INTEGER NOT NULL,
+	gas_fee TEXT NOT NULL,
+	PRIMARY KEY (hash, pair, side)
+);
+CREATE INDEX IF NOT EXISTS unlocks_pair ON unlocks (pair, side, block);
+CREATE INDEX IF NOT EXISTS unlocks_time ON unlocks (time);
+
+CREATE TABLE IF NOT EXISTS cursors (
+	pair TEXT NOT NULL,
+	side TEXT NOT NULL,
+	block INTEGER NOT NULL,
+	PRIMARY KEY (pair, side)
+);
+`
+
+// Lock is an indexed Locked event, a transfer from either bridge of a pair to the other
+type Lock struct {
+	// Hash is the canonical unlock hash, the lock transaction hash
+	Hash common.Hash
+	Pair string
+	// Side of bridge which emitted the event, source for transfers from source to destination
+	Side    string
+	Chain   string
+	Account common.Address
+	Amount  *big.Int
+	// Fee is native fee of lock amount charged by the bridge
+	Fee   *big.Int
+	Block uint64
+	Index uint
+	Time  time.Time
+}
+
+// Unlock is an indexed Unlocked event, it completes the lock of the other bridge of pair
+type Unlock struct {
+	Hash common.Hash
+	Pair string
+	// Side of bridge which emitted the event, destination for transfers from source to destination
+	Side    string
+	Chain   string
+	Account common.Address
+	Amount  *big.Int
+	Tx      common.Hash
+	Block   uint64
+	Index   uint
+	Time    time.Time
+	// GasFee is native fee paid by relayer for unlock tx
+	GasFee *big.Int
+}
+
+// Transfer is a lock joined with its unlock
+type Transfer struct {
+	Lock   Lock
+	Unlock *Unlock
+	Status Status
+	// Latency is time from lock to unlock
+	Latency time.Duration
+	// Seq is order in which lock was indexed, locks indexed later have greater seq
+	Seq int64
+}
+
+// DB is the sqlite transfer history
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens or creates sqlite database at path
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("can not open %s; %w", path, err)
+	}
+	// sqlite allows a single writer
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can not create schema; %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes database
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Cursor returns the last indexed block of pair side
+func (db *DB) Cursor(ctx context.Context, pair, side string) (uint64, bool, error) {
+	var block uint64
+	err := db.db.QueryRowContext(ctx, `SELECT block FROM cursors WHERE pair = ? AND side = ?`, pair, side).Scan(&block)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("can not get cursor of %s:%s; %w", pair, side, err)
+	}
+	return block, true, nil
+}
+
+// Save replaces indexed locks and unlocks of pair side in block range
+// and moves cursor of side to end of range atomically
+func (db *DB) Save(ctx context.Context, pair, side string, from, to uint64, locks []Lock, unlocks []Unlock) error {
+	return db.save(ctx, side, pair, from, to, func(tx *sql.Tx) error {
+		for _, l := range locks {
+			_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO locks (hash, pair, side, chain, account, amount, fee, block, log_index, time) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				l.Hash.Hex(), pair, side, l.Chain, l.Account.Hex(), l.Amount.String(), l.Fee.String(), l.Block, l.Index, l.Time.Unix())
+			if err != nil {
+				return fmt.Errorf("can not save lock %s; %w", l.Hash.Hex(), err)
+			}
+		}
+		for _, u := range unlocks {
+			gasFee := "0"
+			if u.GasFee != nil {
+				gasFee = u.GasFee.String()
+			}
+			_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO unlocks (hash, pair, side, chain, account, amount, tx, block, log_index, time, gas_fee) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				u.Hash.Hex(), pair, side, u.Chain, u.Account.Hex(), u.Amount.String(), u.Tx.Hex(), u.Block, u.Index, u.Time.Unix(), gasFee)
+			if err != nil {
+				return fmt.Errorf("can not save unlock %s; %w", u.Hash.Hex(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// save deletes locks and unlocks of pair side in block range so reorged events disappear,
+// then inserts rows and moves cursor
+func (db *DB) save(ctx context.Context, side, pair string, from, to uint64, insert func(tx *sql.Tx) error) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"locks", "unlocks"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE pair = ? AND side = ? AND block BETWEEN ? AND ?`, pair, side, from, to); err != nil {
+			return fmt.Errorf("can not delete %s of %s:%s; %w", table, pair, side, err)
+		}
+	}
+	if err := insert(tx); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO cursors (pair, side, block) VALUES (?, ?, ?)`, pair, side, to)
+	if err != nil {
+		return fmt.Errorf("can not save cursor of %s:%s; %w", pair, side, err)
+	}
+	return tx.Commit()
+}
+
+// ResetCursors removes all cursors so next sync indexes from the start
+func (db *DB) ResetCursors(ctx context.Context) error {
+	_, err := db.db.ExecContext(ctx, `DELETE FROM cursors`)
+	return err
+}
+
+// matched is condition of an unlock with the locked account and amount,
+// amounts are canonical decimal strings so equal strings are equal amounts
+const matched = `u.account = l.account AND u.amount = l.amount`
+
+// joined joins every lock with its unlock by the other bridge of pair
+const joined = `locks l LEFT JOIN unlocks u ON u.hash = l.hash AND u.pair = l.pair AND u.side != l.side`
+
+const selectTransfer = `SELECT
+	l.rowid, l.hash, l.pair, l.side, l.chain, l.account, l.amount, l.fee, l.block, l.log_index, l.time,
+	u.side, u.chain, u.account, u.amount, u.tx, u.block, u.log_index, u.time, u.gas_fee
+FROM ` + joined
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransfer(row scanner) (Transfer, error) {
+	var (
+		t                                              Transfer
+		hash, account, amount, fee                     string
+		lockTime                                       int64
+		uSide, uChain, uAccount, uAmount, uTx, uGasFee sql.NullString
+		uBlock, uIndex, uTime                          sql.NullInt64
+	)
+	err := row.Scan(&t.Seq, &hash, &t.Lock.Pair, &t.Lock.Side, &t.Lock.Chain, &account, &amount, &fee, &t.Lock.Block, &t.Lock.Index, &lockTime,
+		&uSide, &uChain, &uAccount, &uAmount, &uTx, &uBlock, &uIndex, &uTime, &uGasFee)
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	t.Lock.Hash = common.HexToHash(hash)
+	t.Lock.Account = common.HexToAddress(account)
+	t.Lock.Amount, _ = new(big.Int).SetString(amount, 10)
+	t.Lock.Fee, _ = new(big.Int).SetString(fee, 10)
+	t.Lock.Time = time.Unix(lockTime, 0).UTC()
+	t.Status = StatusLocked
+
+	if uTx.Valid {
+		u := &Unlock{
+			Hash:    t.Lock.Hash,
+			Pair:    t.Lock.Pair,
+			Side:    uSide.String,
+			Chain:   uChain.String,
+			Account: common.HexToAddress(uAccount.String),
+			Tx:      common.HexToHash(uTx.String),
+			Block:   uint64(uBlock.Int64),
+			Index:   uint(uIndex.Int64),
+			Time:    time.Unix(uTime.Int64, 0).UTC(),
+		}
+		u.Amount, _ = new(big.Int).SetString(uAmount.String, 10)
+		u.GasFee, _ = new(big.Int).SetString(uGasFee.String, 10)
+		t.Unlock = u
+		t.Latency = u.Time.Sub(t.Lock.Time)
+		t.Status = StatusUnlocked
+		if u.Account != t.Lock.Account || u.Amount.Cmp(t.Lock.Amount) != 0 {
+			t.Status = StatusMismatch
+		}
+	}
+	return t, nil
+}
+
+// Transfer returns transfer by lock tx hash
+func (db *DB) Transfer(ctx context.Context, hash common.Hash) (Transfer, error) {
+	t, err := scanTransfer(db.db.QueryRowContext(ctx, selectTransfer+` WHERE l.hash = ?`, hash.Hex()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transfer{}, ErrNotFound
+	}
+	if err != nil {
+		return Transfer{}, fmt.Errorf("can not get transfer %s; %w", hash.Hex(), err)
+	}
+	return t, nil
+}
+
+// Query filters transfers, zero fields are not filtered
+type Query struct {
+	Pair    string
+	Account *common.Address
+	Status  Status
+	// From and To select transfers locked or unlocked in [From, To), zero is unbounded
+	From time.Time
+	To   time.Time
+	// After selects transfers indexed after seq, in indexing order instead of latest first
+	After  *int64
+	Limit  int
+	Offset int
+}
+
+// Transfers returns transfers matching query, latest first
+func (db *DB) Transfers(ctx context.Context, q Query) ([]Transfer, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if q.Pair != "" {
+		where = append(where, "l.pair = ?")
+		args = append(args, q.Pair)
+	}
+	if q.Account != nil {
+		where = append(where, "l.account = ?")
+		args = append(args, q.Account.Hex())
+	}
+	switch q.Status {
+	case StatusLocked:
+		where = append(where, "u.tx IS NULL")
+	case StatusUnlocked:
+		where = append(where, "u.tx IS NOT NULL AND "+matched)
+	case StatusMismatch:
+		where = append(where, "u.tx IS NOT NULL AND NOT ("+matched+")")
+	}
+
+	if !q.From.IsZero() || !q.To.IsZero() {
+		from, to := int64(math.MinInt64), int64(math.MaxInt64)
+		if !q.From.IsZero() {
+			from = q.From.Unix()
+		}
+		if !q.To.IsZero() {
+			to = q.To.Unix()
+		}
+		where = append(where, "((l.time >= ? AND l.time < ?) OR (u.time >= ? AND u.time < ?))")
+		args = append(args, from, to, from, to)
+	}
+
+	order := " ORDER BY l.time DESC, l.hash"
+	if q.After != nil {
+		where = append(where, "l.rowid > ?")
+		args = append(args, *q.After)
+		order = " ORDER BY l.rowid"
+	}
+
+	query := selectTransfer
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += order
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := q.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, q.Offset)
+	}
+
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not query transfers; %w", err)
+	}
+	defer rows.Close()
+
+	var r []Transfer
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, t)
+	}
+	return r, rows.Err()
+}
+
+// Stats is aggregate of transfers of a pair
+type Stats struct {
+	Pair      string
+	Transfers int
+	Locked    int
+	Unlocked  int
+	Mismatch  int
+	// Volume is total locked amount
+	Volume *big.Int
+	// Fees is total native fee
+	Fees *big.Int
+	// GasFees is total native fee paid by relayer for unlocks
+	GasFees *big.Int
+	// AvgLatency is average time from lock to unlock of unlocked transfers
+	AvgLatency time.Duration
+	LastLock   time.Time
+}
+
+// Stats returns stats of every indexed pair ordered by pair name,
+// pair filters a single pair when not empty
+func (db *DB) Stats(ctx context.Context, pair string) ([]Stats, error) {
+	where, args := "", []interface{}{}
+	if pair != "" {
+		where, args = " WHERE l.pair = ?", append(args, pair)
+	}
+
+	rows, err := db.db.QueryContext(ctx, `SELECT l.pair, COUNT(*),
+	SUM(u.tx IS NULL),
+	SUM(u.tx IS NOT NULL AND `+matched+`),
+	SUM(u.tx IS NOT NULL AND NOT (`+matched+`)),
+	COALESCE(SUM(CASE WHEN u.tx IS NOT NULL AND `+matched+` THEN u.time - l.time END), 0),
+	MAX(l.time)
+FROM `+joined+where+`
+GROUP BY l.pair ORDER BY l.pair`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not query stats; %w", err)
+	}
+	defer rows.Close()
+
+	var r []Stats
+	byPair := map[string]*Stats{}
+	for rows.Next() {
+		var (
+			s             = Stats{Volume: new(big.Int), Fees: new(big.Int), GasFees: new(big.Int)}
+			latency, last int64
+		)
+		if err := rows.Scan(&s.Pair, &s.Transfers, &s.Locked, &s.Unlocked, &s.Mismatch, &latency, &last); err != nil {
+			return nil, err
+		}
+		if s.Unlocked > 0 {
+			s.AvgLatency = time.Duration(latency) * time.Second / time.Duration(s.Unlocked)
+		}
+		s.LastLock = time.Unix(last, 0).UTC()
+		r = append(r, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range r {
+		byPair[r[i].Pair] = &r[i]
+	}
+
+	// amounts are decimal strings beyond int64 which sqlite can not sum exactly,
+	// they are summed while streaming only the amount columns
+	sums, err := db.db.QueryContext(ctx, `SELECT l.pair, l.amount, l.fee, COALESCE(u.gas_fee, '0')
+FROM `+joined+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not query stats; %w", err)
+	}
+	defer sums.Close()
+
+	for sums.Next() {
+		var name, amount, fee, gasFee string
+		if err := sums.Scan(&name, &amount, &fee, &gasFee); err != nil {
+			return nil, err
+		}
+		s, ok := byPair[name]
+		if !ok {
+			continue
+		}
+		add(s.Volume, amount)
+		add(s.Fees, fee)
+		add(s.GasFees, gasFee)
+	}
+	return r, sums.Err()
+}
+
+// Latency is histogram of time from lock to unlock of unlocked transfers of a pair
+type Latency struct {
+	Pair  string
+	Count uint64
+	// Sum is total latency in seconds
+	Sum float64
+	// Buckets are cumulative counts of latencies up to each bound in seconds
+	Buckets map[float64]uint64
+}
+
+// Latencies returns latency histogram with bounds in seconds of every pair ordered by pair name,
+// transfers are bucketed by sqlite
+func (db *DB) Latencies(ctx context.Context, bounds []float64) ([]Latency, error) {
+	var (
+		columns string
+		args    []interface{}
+	)
+	for _, b := range bounds {
+		columns += ", SUM(u.time - l.time <= ?)"
+		args = append(args, b)
+	}
+	rows, err := db.db.QueryContext(ctx, `SELECT l.pair, COUNT(*), SUM(u.time - l.time)`+columns+`
+FROM `+joined+` WHERE u.tx IS NOT NULL AND `+matched+`
+GROUP BY l.pair ORDER BY l.pair`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not query latencies; %w", err)
+	}
+	defer rows.Close()
+
+	var r []Latency
+	for rows.Next() {
+		var (
+			l       = Latency{Buckets: map[float64]uint64{}}
+			sum     int64
+			buckets = make([]uint64, len(bounds))
+			dest    = []interface{}{&l.Pair, &l.Count, &sum}
+		)
+		for i := range buckets {
+			dest = append(dest, &buckets[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		l.Sum = float64(sum)
+		for i, b := range bounds {
+			l.Buckets[b] = buckets[i]
+		}
+		r = append(r, l)
+	}
+	return r, rows.Err()
+}
+
+// Activity is aggregate of events emitted by one bridge of a pair in a period
+type Activity struct {
+	Pair string
+	Side string
+	// Locks is number of Locked events, LockVolume and Fees their amount and native fee
+	Locks      int
+	LockVolume *big.Int
+	Fees       *big.Int
+	// Unlocks is number of Unlocked events, UnlockVolume and GasFees their amount and relayer gas
+	Unlocks      int
+	UnlockVolume *big.Int
+	GasFees      *big.Int
+}
+
+// Activity returns events of every bridge in period [from, to) ordered by pair and side,
+// bridges without events in period are omitted
+func (db *DB) Activity(ctx context.Context, from, to time.Time) ([]Activity, error) {
+	var (
+		r     []Activity
+		index = map[[2]string]int{}
+	)
+	get := func(pair, side string) *Activity {
+		i, ok := index[[2]string{pair, side}]
+		if !ok {
+			i = len(r)
+			index[[2]string{pair, side}] = i
+			r = append(r, Activity{Pair: pair, Side: side, LockVolume: new(big.Int), Fees: new(big.Int), UnlockVolume: new(big.Int), GasFees: new(big.Int)})
+		}
+		return &r[i]
+	}
+
+	// amounts are decimal strings beyond int64 which sqlite can not sum exactly,
+	// they are concatenated per bridge and summed here
+	for _, table := range []struct {
+		name, sums string
+		set        func(a *Activity, n int, amounts, fees []string)
+	}{
+		{"locks", "fee", func(a *Activity, n int, amounts, fees []string) {
+			a.Locks = n
+			sum(a.LockVolume, amounts)
+			sum(a.Fees, fees)
+		}},
+		{"unlocks", "gas_fee", func(a *Activity, n int, amounts, gasFees []string) {
+			a.Unlocks = n
+			sum(a.UnlockVolume, amounts)
+			sum(a.GasFees, gasFees)
+		}},
+	} {
+		rows, err := db.db.QueryContext(ctx, `SELECT pair, side, COUNT(*), group_concat(amount), group_concat(`+table.sums+`)
+FROM `+table.name+` WHERE time >= ? AND time < ? GROUP BY pair, side`, from.Unix(), to.Unix())
+		if err != nil {
+			return nil, fmt.Errorf("can not query %s activity; %w", table.name, err)
+		}
+		for rows.Next() {
+			var (
+				pair, side, amounts, fees string
+				n                         int
+			)
+			if err := rows.Scan(&pair, &side, &n, &amounts, &fees); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			table.set(get(pair, side), n, strings.Split(amounts, ","), strings.Split(fees, ","))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Pair != r[j].Pair {
+			return r[i].Pair < r[j].Pair
+		}
+		return r[i].Side < r[j].Side
+	})
+	return r, nil
+}
+
+// add adds decimal string v to sum
+func add(sum *big.Int, v string) {
+	if n, ok := new(big.Int).SetString(v, 10); ok {
+		sum.Add(sum, n)
+	}
+}
+
+// sum adds decimal strings vs to total
+func sum(total *big.Int, vs []string) {
+	for _, v := range vs {
+		add(total, v)
+	}
+}
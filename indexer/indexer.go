@@ -0,0 +1,251 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/backfill"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// Indexer ingests Locked and Unlocked events of both bridges of all pairs into DB
+type Indexer struct {
+	DB      *DB
+	Pairs   []config.Pair
+	Chains  map[string]config.Chain
+	Clients map[string]multiclient.Backend
+
+	// Start is the first block of chain indexed by backfill, default 0
+	Start map[string]uint64
+	// Chunk is initial block range of a query, it adapts to provider limits, default 5000
+	Chunk uint64
+	// Concurrency is number of parallel queries of a bridge, default 4
+	Concurrency int
+	// Log reports progress, optional
+	Log func(format string, args ...interface{})
+}
+
+// Backfill re-indexes all pairs from start blocks to head
+func (ix *Indexer) Backfill(ctx context.Context) error {
+	if err := ix.DB.ResetCursors(ctx); err != nil {
+		return err
+	}
+	return ix.Sync(ctx)
+}
+
+// Sync indexes all pairs from their cursors to head,
+// the last confirmations blocks before cursor are indexed again to follow reorgs
+func (ix *Indexer) Sync(ctx context.Context) error {
+	heads := map[string]uint64{}
+	for _, p := range ix.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			head, ok := heads[b.Chain]
+			if !ok {
+				h, err := ix.Clients[b.Chain].HeaderByNumber(ctx, nil)
+				if err != nil {
+					return fmt.Errorf("can not get head of %s; %w", b.Chain, err)
+				}
+				head = h.Number.Uint64()
+				heads[b.Chain] = head
+			}
+			if err := ix.sync(ctx, b, head); err != nil {
+				return fmt.Errorf("can not index %s; %w", b, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Processed returns the lowest indexed block of all bridges on chain,
+// ok is false until every bridge on chain is indexed
+func (ix *Indexer) Processed(ctx context.Context, chain string) (uint64, bool, error) {
+	var (
+		lowest uint64
+		found  bool
+	)
+	for _, p := range ix.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			if b.Chain != chain {
+				continue
+			}
+			cursor, ok, err := ix.DB.Cursor(ctx, b.Pair, b.Side)
+			if err != nil || !ok {
+				return 0, false, err
+			}
+			if !found || cursor < lowest {
+				lowest, found = cursor, true
+			}
+		}
+	}
+	return lowest, found, nil
+}
+
+func (ix *Indexer) sync(ctx context.Context, b config.Bridge, head uint64) error {
+	cursor, ok, err := ix.DB.Cursor(ctx, b.Pair, b.Side)
+	if err != nil {
+		return err
+	}
+
+	from := ix.Start[b.Chain]
+	if ok {
+		from = cursor + 1
+		if confirmations := ix.Chains[b.Chain].Confirmations; from > confirmations {
+			from -= confirmations
+		} else {
+			from = 0
+		}
+	}
+
+	// pairs are bidirectional, both bridges lock and unlock
+	bf := &backfill.Backfiller{
+		Filterer:    ix.Clients[b.Chain],
+		Query:       ethereum.FilterQuery{Addresses: []common.Address{b.Address}, Topics: [][]common.Hash{{unlocker.LockedTopic, unlocker.UnlockedTopic}}},
+		Chunk:       ix.Chunk,
+		Concurrency: ix.Concurrency,
+	}
+
+	return bf.Run(ctx, from, head, func(from, to uint64, logs []types.Log) error {
+		var locked, unlocked []types.Log
+		for _, l := range logs {
+			if len(l.Topics) > 0 && l.Topics[0] == unlocker.LockedTopic {
+				locked = append(locked, l)
+			} else {
+				unlocked = append(unlocked, l)
+			}
+		}
+		locks, err := ix.locks(ctx, b, locked)
+		if err != nil {
+			return err
+		}
+		unlocks, err := ix.unlocks(ctx, b, unlocked)
+		if err != nil {
+			return err
+		}
+		if err := ix.DB.Save(ctx, b.Pair, b.Side, from, to, locks, unlocks); err != nil {
+			return err
+		}
+		if ix.Log != nil {
+			ix.Log("%s blocks %d-%d: %d locks, %d unlocks", b, from, to, len(locks), len(unlocks))
+		}
+		return nil
+	})
+}
+
+func (ix *Indexer) locks(ctx context.Context, b config.Bridge, logs []types.Log) ([]Lock, error) {
+	client := ix.Clients[b.Chain]
+	times := blockTimes{client: client, times: map[uint64]time.Time{}}
+	bridge, _ := abi.NewBridgeBase(b.Address, client)
+
+	var locks []Lock
+	for _, l := range logs {
+		job, err := unlocker.NewJob(l)
+		if err != nil {
+			return nil, err
+		}
+		at, err := times.get(ctx, l.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		// fee is not in the Locked event and lock may be called by a contract with any value,
+		// it is the bridge fee of amount at the lock block
+		fee, err := bridge.CalculateFee(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(l.BlockNumber)}, job.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("can not get fee of lock %s; %w", l.TxHash.Hex(), err)
+		}
+
+		locks = append(locks, Lock{
+			Hash:    job.Hash,
+			Pair:    b.Pair,
+			Side:    b.Side,
+			Chain:   b.Chain,
+			Account: job.Account,
+			Amount:  job.Amount,
+			Fee:     fee,
+			Block:   l.BlockNumber,
+			Index:   l.Index,
+			Time:    at,
+		})
+	}
+	return locks, nil
+}
+
+func (ix *Indexer) unlocks(ctx context.Context, b config.Bridge, logs []types.Log) ([]Unlock, error) {
+	client := ix.Clients[b.Chain]
+	times := blockTimes{client: client, times: map[uint64]time.Time{}}
+
+	var unlocks []Unlock
+	for _, l := range logs {
+		u, err := unlocker.NewUnlock(ctx, client, l)
+		if err != nil {
+			return nil, err
+		}
+		at, err := times.get(ctx, l.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		gasFee, err := gasFee(ctx, client, l.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		unlocks = append(unlocks, Unlock{
+			Hash:    u.Hash,
+			Pair:    b.Pair,
+			Side:    b.Side,
+			Chain:   b.Chain,
+			Account: u.Account,
+			Amount:  u.Amount,
+			Tx:      l.TxHash,
+			Block:   l.BlockNumber,
+			Index:   l.Index,
+			Time:    at,
+			GasFee:  gasFee,
+		})
+	}
+	return unlocks, nil
+}
+
+// gasFee returns native fee paid for transaction
+func gasFee(ctx context.Context, client multiclient.Backend, hash common.Hash) (*big.Int, error) {
+	tx, _, err := client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("can not get tx %s; %w", hash.Hex(), err)
+	}
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if err == nil && receipt == nil {
+		err = ethereum.NotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not get receipt %s; %w", hash.Hex(), err)
+	}
+	return new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipt.GasUsed)), nil
+}
+
+// blockTimes caches block timestamps
+type blockTimes struct {
+	client multiclient.Backend
+	times  map[uint64]time.Time
+}
+
+func (t blockTimes) get(ctx context.Context, block uint64) (time.Time, error) {
+	if at, ok := t.times[block]; ok {
+		return at, nil
+	}
+	header, err := t.client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("can not get header %d; %w", block, err)
+	}
+	at := time.Unix(int64(header.Time), 0).UTC()
+	t.times[block] = at
+	return at, nil
+}
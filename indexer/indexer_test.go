@@ -0,0 +1,246 @@
+package indexer_test
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestIndexer(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0.01"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	_, err := wrapped.AddMinter(owner.TxOpts, burnerAddr)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+	}
+
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	ix := &indexer.Indexer{
+		DB:      db,
+		Pairs:   []config.Pair{pair},
+		Chains:  map[string]config.Chain{"bsc": {Confirmations: 2}, "bkc": {Confirmations: 2}},
+		Clients: map[string]multiclient.Backend{"bsc": ctx.Archive(), "bkc": ctx.Archive()},
+		Chunk:   3,
+	}
+
+	lock := func(amount string) common.Hash {
+		opts := *user.TxOpts
+		opts.Value = decimal.EtherToWei(amount)
+		opts.Value.Add(opts.Value, decimal.EtherToWei("0.01"))
+		tx, err := ether.Lock(&opts, decimal.EtherToWei(amount))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		return tx.Hash()
+	}
+	unlock := func(hash common.Hash, amount string) common.Hash {
+		tx, err := burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei(amount), hash)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		return tx.Hash()
+	}
+
+	locks := []common.Hash{lock("1"), lock("2"), lock("3")}
+	unlockTx := unlock(locks[0], "1")
+	unlock(locks[1], "5")
+
+	require.NoError(t, ix.Sync(ctx))
+
+	t.Run("Transfer", func(t *testing.T) {
+		tr, err := db.Transfer(ctx, locks[0])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusUnlocked, tr.Status)
+		require.Equal(t, "BNB <=> kBNB", tr.Lock.Pair)
+		require.Equal(t, user.Address, tr.Lock.Account)
+		require.Equal(t, decimal.EtherToWei("1"), tr.Lock.Amount)
+		require.Equal(t, decimal.EtherToWei("0.01"), tr.Lock.Fee)
+		require.Equal(t, unlockTx, tr.Unlock.Tx)
+		require.Equal(t, "bkc", tr.Unlock.Chain)
+		require.Positive(t, tr.Unlock.GasFee.Sign())
+		require.Positive(t, int64(tr.Latency))
+
+		tr, err = db.Transfer(ctx, locks[1])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusMismatch, tr.Status)
+
+		tr, err = db.Transfer(ctx, locks[2])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusLocked, tr.Status)
+		require.Nil(t, tr.Unlock)
+
+		_, err = db.Transfer(ctx, common.Hash{1})
+		require.ErrorIs(t, err, indexer.ErrNotFound)
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		unlock(locks[2], "3")
+		locks = append(locks, lock("4"))
+		require.NoError(t, ix.Sync(ctx))
+
+		tr, err := db.Transfer(ctx, locks[2])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusUnlocked, tr.Status)
+
+		tr, err = db.Transfer(ctx, locks[3])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusLocked, tr.Status)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		all, err := db.Transfers(ctx, indexer.Query{Account: &user.Address})
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+		require.Equal(t, locks[3], all[0].Lock.Hash)
+
+		page, err := db.Transfers(ctx, indexer.Query{Account: &user.Address, Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		require.Equal(t, all[1:3], page)
+
+		r, err := db.Transfers(ctx, indexer.Query{Status: indexer.StatusUnlocked})
+		require.NoError(t, err)
+		require.Len(t, r, 2)
+		r, err = db.Transfers(ctx, indexer.Query{Status: indexer.StatusUnlocked, Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, r, 1)
+		require.Equal(t, indexer.StatusUnlocked, r[0].Status)
+		r, err = db.Transfers(ctx, indexer.Query{Status: indexer.StatusLocked})
+		require.NoError(t, err)
+		require.Len(t, r, 1)
+		r, err = db.Transfers(ctx, indexer.Query{Status: indexer.StatusMismatch})
+		require.NoError(t, err)
+		require.Len(t, r, 1)
+		require.Equal(t, indexer.StatusMismatch, r[0].Status)
+
+		r, err = db.Transfers(ctx, indexer.Query{Offset: 3})
+		require.NoError(t, err)
+		require.Equal(t, all[3:], r)
+
+		r, err = db.Transfers(ctx, indexer.Query{Pair: "unknown"})
+		require.NoError(t, err)
+		require.Empty(t, r)
+	})
+
+	t.Run("Processed", func(t *testing.T) {
+		head, err := ctx.Backend.HeaderByNumber(ctx, nil)
+		require.NoError(t, err)
+		block, ok, err := ix.Processed(ctx, "bkc")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, head.Number.Uint64(), block)
+
+		_, ok, err = ix.Processed(ctx, "matic")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := db.Stats(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		s := stats[0]
+		require.Equal(t, "BNB <=> kBNB", s.Pair)
+		require.Equal(t, 4, s.Transfers)
+		require.Equal(t, 1, s.Locked)
+		require.Equal(t, 2, s.Unlocked)
+		require.Equal(t, 1, s.Mismatch)
+		require.Equal(t, decimal.EtherToWei("10"), s.Volume)
+		require.Equal(t, decimal.EtherToWei("0.04"), s.Fees)
+		require.Positive(t, s.GasFees.Sign())
+		require.Positive(t, int64(s.AvgLatency))
+	})
+
+	t.Run("Latencies", func(t *testing.T) {
+		l, err := db.Latencies(ctx, []float64{0, math.MaxInt32})
+		require.NoError(t, err)
+		require.Len(t, l, 1)
+		require.Equal(t, "BNB <=> kBNB", l[0].Pair)
+		require.Equal(t, uint64(2), l[0].Count)
+		require.Positive(t, l[0].Sum)
+		require.Equal(t, map[float64]uint64{0: 0, math.MaxInt32: 2}, l[0].Buckets)
+	})
+
+	t.Run("Activity", func(t *testing.T) {
+		end := time.Unix(math.MaxInt32, 0)
+		a, err := db.Activity(ctx, time.Unix(0, 0), end)
+		require.NoError(t, err)
+		require.Len(t, a, 2)
+		require.Equal(t, config.SideDestination, a[0].Side)
+		require.Zero(t, a[0].Locks)
+		require.Equal(t, 3, a[0].Unlocks)
+		require.Equal(t, decimal.EtherToWei("9"), a[0].UnlockVolume)
+		require.Positive(t, a[0].GasFees.Sign())
+		require.Equal(t, config.SideSource, a[1].Side)
+		require.Equal(t, 4, a[1].Locks)
+		require.Equal(t, decimal.EtherToWei("10"), a[1].LockVolume)
+		require.Equal(t, decimal.EtherToWei("0.04"), a[1].Fees)
+		require.Zero(t, a[1].Unlocks)
+
+		a, err = db.Activity(ctx, end, end.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, a)
+
+		r, err := db.Transfers(ctx, indexer.Query{To: end})
+		require.NoError(t, err)
+		require.Len(t, r, 4)
+		r, err = db.Transfers(ctx, indexer.Query{From: end})
+		require.NoError(t, err)
+		require.Empty(t, r)
+	})
+
+	t.Run("Backfill", func(t *testing.T) {
+		require.NoError(t, ix.Backfill(ctx))
+		all, err := db.Transfers(ctx, indexer.Query{})
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		// kBNB burned on destination, released from BNB custody on source
+		_, err := wrapped.Approve(user.TxOpts, burnerAddr, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		burn, err := burner.Lock(user.TxOpts, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		release, err := ether.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), burn.Hash())
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		require.NoError(t, ix.Sync(ctx))
+
+		tr, err := db.Transfer(ctx, burn.Hash())
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusUnlocked, tr.Status)
+		require.Equal(t, config.SideDestination, tr.Lock.Side)
+		require.Equal(t, "bkc", tr.Lock.Chain)
+		require.Zero(t, tr.Lock.Fee.Sign())
+		require.Equal(t, config.SideSource, tr.Unlock.Side)
+		require.Equal(t, release.Hash(), tr.Unlock.Tx)
+
+		// source lock is not completed by the release of custody
+		tr, err = db.Transfer(ctx, locks[3])
+		require.NoError(t, err)
+		require.Equal(t, indexer.StatusLocked, tr.Status)
+	})
+}
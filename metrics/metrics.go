@@ -0,0 +1,308 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+)
+
+// DefaultBuckets are lock to unlock latency buckets in seconds
+var DefaultBuckets = []float64{15, 30, 60, 120, 300, 600, 1800, 3600, 4 * 3600}
+
+var (
+	headBlock = prometheus.NewDesc("bridge_head_block",
+		"Head block of chain.", []string{"chain"}, nil)
+	processedBlock = prometheus.NewDesc("bridge_processed_block",
+		"Last block indexed of bridge.", []string{"pair", "side", "chain"}, nil)
+	eventLag = prometheus.NewDesc("bridge_event_lag_blocks",
+		"Blocks between chain head and last block indexed of bridge.", []string{"pair", "side", "chain"}, nil)
+	pendingUnlocks = prometheus.NewDesc("bridge_pending_unlocks",
+		"Locks without unlock.", []string{"pair"}, nil)
+	failedUnlocks = prometheus.NewDesc("bridge_failed_unlocks",
+		"Unlocks with different account or amount than lock.", []string{"pair"}, nil)
+	unlockLatency = prometheus.NewDesc("bridge_unlock_latency_seconds",
+		"Time from lock to unlock.", []string{"pair"}, nil)
+	relayerBalance = prometheus.NewDesc("bridge_relayer_balance",
+		"Native balance of relayer wallet.", []string{"chain", "account"}, nil)
+	gasSpent = prometheus.NewDesc("bridge_gas_spent_total",
+		"Native fee paid by relayer for unlocks.", []string{"pair", "chain"}, nil)
+	limiterUsage = prometheus.NewDesc("bridge_limiter_usage",
+		"Amount used of daily limit of bridge.", []string{"pair", "side", "chain"}, nil)
+	limiterLimit = prometheus.NewDesc("bridge_limiter_limit",
+		"Daily limit of bridge.", []string{"pair", "side", "chain"}, nil)
+	reserveLocked = prometheus.NewDesc("bridge_reserve_locked",
+		"Asset locked on source bridge.", []string{"pair"}, nil)
+	reserveMinted = prometheus.NewDesc("bridge_reserve_minted",
+		"Wrapped token minted on destination.", []string{"pair"}, nil)
+	reserveDelta = prometheus.NewDesc("bridge_reserve_delta",
+		"Locked minus minted, negative is a deficit.", []string{"pair"}, nil)
+	updateErrors = prometheus.NewDesc("bridge_metrics_errors",
+		"Metrics which could not be read in last update.", nil, nil)
+)
+
+// Collector exports bridge metrics to prometheus,
+// Update reads them from chains, transfer index and reserve reconciliation
+// and scrapes return the last update
+type Collector struct {
+	Pairs   []config.Pair
+	Clients map[string]multiclient.Backend
+	// DB is transfer index, transfer metrics are not exported when nil
+	DB *indexer.DB
+	// Buckets of unlock latency histogram in seconds, default DefaultBuckets
+	Buckets []float64
+
+	mu      sync.Mutex
+	metrics []prometheus.Metric
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		headBlock, processedBlock, eventLag, pendingUnlocks, failedUnlocks, unlockLatency,
+		relayerBalance, gasSpent, limiterUsage, limiterLimit, reserveLocked, reserveMinted, reserveDelta, updateErrors,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Handler returns /metrics handler of collector and go runtime metrics
+func (c *Collector) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c, prometheus.NewGoCollector())
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// update collects metrics of an update, failed reads are counted and skipped
+type update struct {
+	metrics []prometheus.Metric
+	errs    []string
+}
+
+func (u *update) add(desc *prometheus.Desc, t prometheus.ValueType, v float64, labels ...string) {
+	u.metrics = append(u.metrics, prometheus.MustNewConstMetric(desc, t, v, labels...))
+}
+
+func (u *update) fail(err error) {
+	u.errs = append(u.errs, err.Error())
+}
+
+// Update reads all metrics, metrics which can not be read are omitted until next update
+func (c *Collector) Update(ctx context.Context) error {
+	u := &update{}
+
+	heads := c.heads(ctx, u)
+	if c.DB != nil {
+		c.transfers(ctx, u, heads)
+	}
+	c.relayers(ctx, u)
+	c.limiters(ctx, u)
+	c.reserves(ctx, u)
+	u.add(updateErrors, prometheus.GaugeValue, float64(len(u.errs)))
+
+	c.mu.Lock()
+	c.metrics = u.metrics
+	c.mu.Unlock()
+
+	if len(u.errs) > 0 {
+		return fmt.Errorf("can not read %d metrics; %s", len(u.errs), strings.Join(u.errs, "; "))
+	}
+	return nil
+}
+
+func (c *Collector) chains() []string {
+	seen := map[string]bool{}
+	var r []string
+	for _, p := range c.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			if !seen[b.Chain] {
+				seen[b.Chain] = true
+				r = append(r, b.Chain)
+			}
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+func (c *Collector) heads(ctx context.Context, u *update) map[string]uint64 {
+	heads := map[string]uint64{}
+	for _, chain := range c.chains() {
+		h, err := c.Clients[chain].HeaderByNumber(ctx, nil)
+		if err != nil {
+			u.fail(fmt.Errorf("can not get head of %s; %w", chain, err))
+			continue
+		}
+		heads[chain] = h.Number.Uint64()
+		u.add(headBlock, prometheus.GaugeValue, float64(heads[chain]), chain)
+	}
+	return heads
+}
+
+func (c *Collector) transfers(ctx context.Context, u *update, heads map[string]uint64) {
+	for _, p := range c.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			cursor, ok, err := c.DB.Cursor(ctx, b.Pair, b.Side)
+			if err != nil {
+				u.fail(err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			u.add(processedBlock, prometheus.GaugeValue, float64(cursor), b.Pair, b.Side, b.Chain)
+			if head, ok := heads[b.Chain]; ok {
+				lag := 0.0
+				if head > cursor {
+					lag = float64(head - cursor)
+				}
+				u.add(eventLag, prometheus.GaugeValue, lag, b.Pair, b.Side, b.Chain)
+			}
+		}
+	}
+
+	stats, err := c.DB.Stats(ctx, "")
+	if err != nil {
+		u.fail(err)
+		return
+	}
+	chains := map[string]string{}
+	for _, p := range c.Pairs {
+		chains[p.Name] = p.Destination.Chain
+	}
+	for _, s := range stats {
+		u.add(pendingUnlocks, prometheus.GaugeValue, float64(s.Locked), s.Pair)
+		u.add(failedUnlocks, prometheus.GaugeValue, float64(s.Mismatch), s.Pair)
+		u.add(gasSpent, prometheus.CounterValue, units(s.GasFees, 18), s.Pair, chains[s.Pair])
+	}
+
+	buckets := c.Buckets
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	latencies, err := c.DB.Latencies(ctx, buckets)
+	if err != nil {
+		u.fail(err)
+		return
+	}
+	for _, l := range latencies {
+		u.metrics = append(u.metrics, prometheus.MustNewConstHistogram(unlockLatency, l.Count, l.Sum, l.Buckets, l.Pair))
+	}
+}
+
+// relayers exports balances of owners of all bridges, owner is the unlock relayer
+func (c *Collector) relayers(ctx context.Context, u *update) {
+	type wallet struct {
+		chain   string
+		account common.Address
+	}
+	seen := map[wallet]bool{}
+	for _, p := range c.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			client := c.Clients[b.Chain]
+			ownable, _ := abi.NewOwnable(b.Address, client)
+			owner, err := ownable.Owner(&bind.CallOpts{Context: ctx})
+			if err != nil {
+				u.fail(fmt.Errorf("can not get owner of %s; %w", b, err))
+				continue
+			}
+			w := wallet{b.Chain, owner}
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+
+			balance, err := client.BalanceAt(ctx, owner, nil)
+			if err != nil {
+				u.fail(fmt.Errorf("can not get balance of %s on %s; %w", owner.Hex(), b.Chain, err))
+				continue
+			}
+			u.add(relayerBalance, prometheus.GaugeValue, units(balance, 18), b.Chain, owner.Hex())
+		}
+	}
+}
+
+func (c *Collector) limiters(ctx context.Context, u *update) {
+	for _, p := range c.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			bridge := admin.NewBridge(b, c.Clients[b.Chain])
+			limiterAddr, err := bridge.Limiter(ctx)
+			if err != nil {
+				u.fail(err)
+				continue
+			}
+			if limiterAddr == (common.Address{}) {
+				continue
+			}
+			decimals, err := bridge.Decimals(ctx)
+			if err != nil {
+				u.fail(err)
+				continue
+			}
+
+			limiter, _ := abi.NewLimiterDaily(limiterAddr, bridge.Backend)
+			opts := &bind.CallOpts{Context: ctx}
+			usage, err := limiter.GetUsage(opts, b.Address)
+			if err != nil {
+				u.fail(fmt.Errorf("can not get usage of %s; %w", b, err))
+				continue
+			}
+			limit, err := limiter.GetLimit(opts, b.Address)
+			if err != nil {
+				u.fail(fmt.Errorf("can not get limit of %s; %w", b, err))
+				continue
+			}
+			u.add(limiterUsage, prometheus.GaugeValue, units(usage, decimals), b.Pair, b.Side, b.Chain)
+			u.add(limiterLimit, prometheus.GaugeValue, units(limit, decimals), b.Pair, b.Side, b.Chain)
+		}
+	}
+}
+
+func (c *Collector) reserves(ctx context.Context, u *update) {
+	for _, p := range c.Pairs {
+		r, err := reserve.Check(ctx, p, c.Clients[p.Source.Chain], c.Clients[p.Destination.Chain])
+		if err != nil {
+			u.fail(err)
+			continue
+		}
+		decimals, err := admin.NewBridge(p.Source, c.Clients[p.Source.Chain]).Decimals(ctx)
+		if err != nil {
+			u.fail(err)
+			continue
+		}
+		u.add(reserveLocked, prometheus.GaugeValue, units(r.Locked, decimals), p.Name)
+		u.add(reserveMinted, prometheus.GaugeValue, units(r.Minted, decimals), p.Name)
+		u.add(reserveDelta, prometheus.GaugeValue, units(r.Delta(), decimals), p.Name)
+	}
+}
+
+// units converts amount in smallest unit to float of whole units
+func units(v *big.Int, decimals uint8) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(v), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
+	return f
+}
@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/metrics"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	send := func(tx interface{}, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	limiterAddr, _, limiter, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	send(wrapped.AddMinter(owner.TxOpts, burnerAddr))
+	send(ether.SetLimiter(owner.TxOpts, limiterAddr))
+	send(limiter.SetLimit(owner.TxOpts, etherAddr, decimal.EtherToWei("100")))
+
+	lock := func(amount string) common.Hash {
+		opts := *user.TxOpts
+		opts.Value = decimal.EtherToWei(amount)
+		tx, err := ether.Lock(&opts, decimal.EtherToWei(amount))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		return tx.Hash()
+	}
+	// second unlock has wrong amount
+	send(burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), lock("1")))
+	send(burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("2"), lock("3")))
+
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+	}
+	clients := map[string]multiclient.Backend{"bsc": ctx.Archive(), "bkc": ctx.Archive()}
+
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ix := &indexer.Indexer{
+		DB:      db,
+		Pairs:   []config.Pair{pair},
+		Chains:  map[string]config.Chain{"bsc": {}, "bkc": {}},
+		Clients: clients,
+	}
+	require.NoError(t, ix.Sync(ctx))
+	// blocks after index are lag
+	ctx.Backend.Commit()
+	ctx.Backend.Commit()
+
+	c := &metrics.Collector{Pairs: []config.Pair{pair}, Clients: clients, DB: db}
+	require.NoError(t, c.Update(ctx))
+
+	server := httptest.NewServer(c.Handler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	text := string(body)
+
+	for _, line := range []string{
+		`bridge_event_lag_blocks{chain="bsc",pair="BNB <=> kBNB",side="source"} 2`,
+		`bridge_failed_unlocks{pair="BNB <=> kBNB"} 1`,
+		`bridge_pending_unlocks{pair="BNB <=> kBNB"} 0`,
+		`bridge_limiter_usage{chain="bsc",pair="BNB <=> kBNB",side="source"} 4`,
+		`bridge_limiter_limit{chain="bsc",pair="BNB <=> kBNB",side="source"} 100`,
+		`bridge_reserve_locked{pair="BNB <=> kBNB"} 4`,
+		`bridge_reserve_minted{pair="BNB <=> kBNB"} 3`,
+		`bridge_reserve_delta{pair="BNB <=> kBNB"} 1`,
+		`bridge_relayer_balance{account="` + owner.Address.Hex() + `",chain="bkc"}`,
+		`bridge_gas_spent_total{chain="bkc",pair="BNB <=> kBNB"}`,
+		`bridge_unlock_latency_seconds_count{pair="BNB <=> kBNB"} 1`,
+		`bridge_unlock_latency_seconds_bucket{pair="BNB <=> kBNB",le="+Inf"} 1`,
+		`bridge_metrics_errors 0`,
+	} {
+		require.Contains(t, text, line)
+	}
+	require.Contains(t, text, `bridge_head_block{chain="bsc"}`)
+	// burner has no limiter
+	require.NotContains(t, text, `bridge_limiter_limit{chain="bkc"`)
+}
@@ -0,0 +1,167 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+)
+
+// Level is severity of a relayer runway
+type Level string
+
+// Runway levels
+const (
+	LevelOK       Level = "ok"
+	LevelWarning  Level = "warning"
+	LevelCritical Level = "critical"
+)
+
+// Runway is how many unlocks relayer balance can still pay on a chain
+type Runway struct {
+	Chain   string
+	Account common.Address
+	Balance *big.Int
+	// GasPrice is current gas price of chain
+	GasPrice *big.Int
+	// UnlockCost is native fee of a single unlock at gas price
+	UnlockCost *big.Int
+	Unlocks    uint64
+	Level      Level
+}
+
+// String returns readable runway
+func (r Runway) String() string {
+	return fmt.Sprintf("%s relayer %s on %s: balance %s pays %d unlocks at %s each",
+		r.Level, r.Account.Hex(), r.Chain, decimal.FromUnit(r.Balance, 18), r.Unlocks, decimal.FromUnit(r.UnlockCost, 18))
+}
+
+// BalanceWatcher tracks native balance of the owner calling unlock on every chain,
+// the owner of each bridge is the relayer of unlocks into it, pairs unlock on both bridges
+type BalanceWatcher struct {
+	Pairs   []config.Pair
+	Clients map[string]multiclient.Backend
+	Config  config.Monitor
+
+	// OnAlert is called when level of a relayer changes, including recovery to ok
+	OnAlert func(r Runway)
+
+	mu     sync.Mutex
+	levels map[string]Level
+}
+
+// Check returns runway of every relayer ordered by chain, alerts on level changes
+func (w *BalanceWatcher) Check(ctx context.Context) ([]Runway, error) {
+	type wallet struct {
+		chain   string
+		account common.Address
+	}
+	seen := map[wallet]bool{}
+
+	var r []Runway
+	for _, p := range w.Pairs {
+		for _, b := range []config.Bridge{p.Source, p.Destination} {
+			client := w.Clients[b.Chain]
+
+			ownable, _ := abi.NewOwnable(b.Address, client)
+			owner, err := ownable.Owner(&bind.CallOpts{Context: ctx})
+			if err != nil {
+				return r, fmt.Errorf("can not get owner of %s; %w", b, err)
+			}
+			if seen[wallet{b.Chain, owner}] {
+				continue
+			}
+			seen[wallet{b.Chain, owner}] = true
+
+			runway, err := w.runway(ctx, b.Chain, client, owner)
+			if err != nil {
+				return r, err
+			}
+			r = append(r, runway)
+		}
+	}
+
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Chain < r[j].Chain
+	})
+	w.alert(r)
+	return r, nil
+}
+
+func (w *BalanceWatcher) runway(ctx context.Context, chain string, client multiclient.Backend, account common.Address) (Runway, error) {
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return Runway{}, fmt.Errorf("can not get gas price of %s; %w", chain, err)
+	}
+	balance, err := client.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return Runway{}, fmt.Errorf("can not get balance of %s on %s; %w", account.Hex(), chain, err)
+	}
+
+	gas, warning, critical := w.Config.UnlockGas, w.Config.RunwayWarning, w.Config.RunwayCritical
+	if gas == 0 {
+		gas = 150000
+	}
+	if warning == 0 {
+		warning = 500
+	}
+	if critical == 0 {
+		critical = 100
+	}
+
+	r := Runway{
+		Chain:      chain,
+		Account:    account,
+		Balance:    balance,
+		GasPrice:   gasPrice,
+		UnlockCost: new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas)),
+		Level:      LevelOK,
+	}
+	if r.UnlockCost.Sign() > 0 {
+		unlocks := new(big.Int).Div(balance, r.UnlockCost)
+		if unlocks.IsUint64() {
+			r.Unlocks = unlocks.Uint64()
+		} else {
+			r.Unlocks = ^uint64(0)
+		}
+	} else {
+		r.Unlocks = ^uint64(0)
+	}
+
+	switch {
+	case r.Unlocks < critical:
+		r.Level = LevelCritical
+	case r.Unlocks < warning:
+		r.Level = LevelWarning
+	}
+	return r, nil
+}
+
+func (w *BalanceWatcher) alert(runways []Runway) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.levels == nil {
+		w.levels = map[string]Level{}
+	}
+	for _, r := range runways {
+		key := r.Chain + " " + r.Account.Hex()
+		last, ok := w.levels[key]
+		w.levels[key] = r.Level
+		if !ok {
+			last = LevelOK
+		}
+		if r.Level != last && w.OnAlert != nil {
+			w.OnAlert(r)
+		}
+	}
+}
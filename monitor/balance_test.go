@@ -0,0 +1,94 @@
+package monitor_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestBalanceWatcher(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	relayer := ctx.Wallets[1]
+
+	_, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	_, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	_, err := burner.TransferOwnership(owner.TxOpts, relayer.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	gasPrice, err := ctx.Backend.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	unlockCost := new(big.Int).Mul(gasPrice, big.NewInt(100000))
+
+	var alerts []monitor.Runway
+	w := &monitor.BalanceWatcher{
+		Pairs: []config.Pair{{
+			Name:        "BNB <=> kBNB",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+		}},
+		Clients: map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend},
+		Config:  config.Monitor{UnlockGas: 100000, RunwayWarning: 20, RunwayCritical: 10},
+		OnAlert: func(r monitor.Runway) {
+			alerts = append(alerts, r)
+		},
+	}
+
+	// drain relayer balance to pay given number of unlocks
+	drain := func(unlocks int64) {
+		t.Helper()
+		fee := new(big.Int).Mul(gasPrice, big.NewInt(21000))
+		value := new(big.Int).Sub(testutil.BalanceETH(ctx, relayer.Address), new(big.Int).Mul(unlockCost, big.NewInt(unlocks)))
+		value.Sub(value, fee)
+
+		nonce, err := ctx.Backend.PendingNonceAt(ctx, relayer.Address)
+		require.NoError(t, err)
+		tx := types.NewTransaction(nonce, common.Address{1}, value, 21000, gasPrice, nil)
+		tx, err = relayer.TxOpts.Signer(relayer.Address, tx)
+		require.NoError(t, err)
+		require.NoError(t, ctx.Backend.SendTransaction(ctx, tx))
+		ctx.Backend.Commit()
+	}
+
+	r, err := w.Check(ctx)
+	require.NoError(t, err)
+	// owner of source bridge unlocks burns from custody on bsc
+	require.Len(t, r, 2)
+	require.Equal(t, "bsc", r[1].Chain)
+	require.Equal(t, owner.Address, r[1].Account)
+	require.Equal(t, relayer.Address, r[0].Account)
+	require.Equal(t, monitor.LevelOK, r[0].Level)
+	require.Equal(t, unlockCost, r[0].UnlockCost)
+	require.Empty(t, alerts)
+
+	drain(15)
+	r, err = w.Check(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(15), r[0].Unlocks)
+	require.Equal(t, monitor.LevelWarning, r[0].Level)
+	require.Len(t, alerts, 1)
+
+	// same level does not alert again
+	_, err = w.Check(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	drain(3)
+	_, err = w.Check(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.Equal(t, monitor.LevelCritical, alerts[1].Level)
+	require.Equal(t, uint64(3), alerts[1].Unlocks)
+	require.Contains(t, alerts[1].String(), "critical relayer "+relayer.Address.Hex()+" on bkc")
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+
+	"killswitch/bridge/emergency"
+)
+
+// Breaker is a circuit breaker which pauses every bridge
+// when a critical anomaly is detected, it trips only once
+type Breaker struct {
+	Pauser *emergency.Pauser
+
+	// OnTrip is called with reason and pause results after breaker tripped
+	OnTrip func(reason string, results []emergency.Result)
+
+	mu      sync.Mutex
+	tripped bool
+}
+
+// Tripped reports whether breaker has tripped
+func (b *Breaker) Tripped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tripped
+}
+
+// Trip pauses all bridges, returns false when breaker already tripped
+func (b *Breaker) Trip(ctx context.Context, reason string) bool {
+	b.mu.Lock()
+	if b.tripped {
+		b.mu.Unlock()
+		return false
+	}
+	b.tripped = true
+	b.mu.Unlock()
+
+	results := b.Pauser.PauseAll(ctx)
+	if b.OnTrip != nil {
+		b.OnTrip(reason, results)
+	}
+	return true
+}
+
+// Reset re-arms breaker after incident is resolved
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tripped = false
+}
@@ -0,0 +1,88 @@
+package monitor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestBreaker(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+	}
+	clients := map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend}
+
+	var reasons []string
+	breaker := &monitor.Breaker{
+		Pauser: &emergency.Pauser{
+			// same chain, so both pauses share nonce sequence
+			Bridges: []*admin.Bridge{
+				admin.NewBridge(config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr}, ctx.Backend),
+				admin.NewBridge(config.Bridge{Chain: "bsc", Type: config.TypeBurner, Address: burnerAddr}, ctx.Backend),
+			},
+			Opts: func(context.Context, string) (*bind.TransactOpts, error) {
+				return owner.TxOpts, nil
+			},
+		},
+		OnTrip: func(reason string, results []emergency.Result) {
+			reasons = append(reasons, reason)
+			for _, r := range results {
+				require.NoError(t, r.Err)
+			}
+		},
+	}
+
+	reserves := &monitor.ReserveWatcher{Pairs: []config.Pair{pair}, Clients: clients, Breaker: breaker}
+	results, err := reserves.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, results[0].Valid())
+	require.False(t, breaker.Tripped())
+
+	// minted without lock
+	_, err = wrapped.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = wrapped.Mint(owner.TxOpts, owner.Address, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	testutil.AutoCommit(t, ctx)
+
+	results, err = reserves.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, results[0].Deficit())
+	require.True(t, breaker.Tripped())
+	require.Len(t, reasons, 1)
+	require.Contains(t, reasons[0], "BNB <=> kBNB")
+
+	for _, b := range []interface {
+		Paused(opts *bind.CallOpts) (bool, error)
+	}{ether, burner} {
+		paused, err := b.Paused(nil)
+		require.NoError(t, err)
+		require.True(t, paused)
+	}
+
+	// trips only once
+	require.False(t, breaker.Trip(ctx, "again"))
+	require.Len(t, reasons, 1)
+}
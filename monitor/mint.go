@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+)
+
+// Mint is a mint of wrapped token of a burner bridge
+type Mint struct {
+	Bridge  config.Bridge
+	Token   common.Address
+	Account common.Address
+	Amount  *big.Int
+	Log     types.Log
+}
+
+// String returns readable mint
+func (m Mint) String() string {
+	return fmt.Sprintf("%s token %s minted %s to %s in tx %s", m.Bridge, m.Token.Hex(), m.Amount, m.Account.Hex(), m.Log.TxHash.Hex())
+}
+
+// UnauthorizedMints returns mints of wrapped token of burner in block range
+// which have no matching Unlocked event of the burner in the same transaction
+func UnauthorizedMints(ctx context.Context, b *admin.Bridge, from, to uint64) ([]Mint, error) {
+	if b.Type != config.TypeBurner {
+		return nil, fmt.Errorf("%s is not a burner", b)
+	}
+
+	tokenAddr, err := b.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+
+	// unlocks not yet matched by tx hash, an unlock matches one mint of same account and amount
+	unlocks := map[common.Hash][]*abi.IBridgeUnlocked{}
+	bridge, _ := abi.NewIBridgeFilterer(b.Address, b.Backend)
+	unlocked, err := bridge.FilterUnlocked(opts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not filter unlocked of %s; %w", b, err)
+	}
+	defer unlocked.Close()
+	for unlocked.Next() {
+		e := unlocked.Event
+		unlocks[e.Raw.TxHash] = append(unlocks[e.Raw.TxHash], e)
+	}
+	if err := unlocked.Error(); err != nil {
+		return nil, err
+	}
+
+	token, _ := abi.NewWrappedTokenFilterer(tokenAddr, b.Backend)
+	minted, err := token.FilterTransfer(opts, []common.Address{{}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not filter mints of %s; %w", tokenAddr.Hex(), err)
+	}
+	defer minted.Close()
+
+	var r []Mint
+	for minted.Next() {
+		e := minted.Event
+		if matchUnlock(unlocks, e.Raw.TxHash, e.To, e.Value) {
+			continue
+		}
+		r = append(r, Mint{Bridge: b.Bridge, Token: tokenAddr, Account: e.To, Amount: e.Value, Log: e.Raw})
+	}
+	return r, minted.Error()
+}
+
+func matchUnlock(unlocks map[common.Hash][]*abi.IBridgeUnlocked, tx common.Hash, account common.Address, amount *big.Int) bool {
+	for i, u := range unlocks[tx] {
+		if u.Sender == account && u.Amount.Cmp(amount) == 0 {
+			unlocks[tx] = append(unlocks[tx][:i], unlocks[tx][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MintWatcher raises critical alert for unauthorized mints of wrapped tokens
+// and optionally trips the breaker which pauses every bridge
+type MintWatcher struct {
+	// OnUnauthorized is called for each unauthorized mint
+	OnUnauthorized func(m Mint)
+	// Breaker is tripped on unauthorized mint, nil only alerts
+	Breaker *Breaker
+}
+
+// Check checks block range of burner bridge
+func (w *MintWatcher) Check(ctx context.Context, b *admin.Bridge, from, to uint64) ([]Mint, error) {
+	mints, err := UnauthorizedMints(ctx, b, from, to)
+	if err != nil || len(mints) == 0 {
+		return mints, err
+	}
+
+	if w.OnUnauthorized != nil {
+		for _, m := range mints {
+			w.OnUnauthorized(m)
+		}
+	}
+	if w.Breaker != nil {
+		w.Breaker.Trip(ctx, fmt.Sprintf("unauthorized mint: %s", mints[0]))
+	}
+	return mints, nil
+}
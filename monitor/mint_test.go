@@ -0,0 +1,91 @@
+package monitor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/testutil"
+)
+
+func TestMintWatcher(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	b := admin.NewBridge(config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "BNB <=> kBNB", Side: config.SideDestination}, ctx.Backend)
+
+	_, err := wrapped.AddMinter(owner.TxOpts, burnerAddr)
+	require.NoError(t, err)
+	_, err = wrapped.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	// authorized mints by unlock
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), common.Hash{1})
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("2"), common.Hash{2})
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	var alerts []monitor.Mint
+	var reasons []string
+	w := &monitor.MintWatcher{
+		OnUnauthorized: func(m monitor.Mint) {
+			alerts = append(alerts, m)
+		},
+		Breaker: &monitor.Breaker{
+			Pauser: &emergency.Pauser{
+				Bridges: []*admin.Bridge{b},
+				Opts: func(context.Context, string) (*bind.TransactOpts, error) {
+					return owner.TxOpts, nil
+				},
+			},
+			OnTrip: func(reason string, results []emergency.Result) {
+				reasons = append(reasons, reason)
+			},
+		},
+	}
+
+	head := func() uint64 {
+		h, err := ctx.Backend.HeaderByNumber(ctx, nil)
+		require.NoError(t, err)
+		return h.Number.Uint64()
+	}
+
+	mints, err := w.Check(ctx, b, 0, head())
+	require.NoError(t, err)
+	require.Empty(t, mints)
+	require.False(t, w.Breaker.Tripped())
+
+	// compromised minter
+	tx, err := wrapped.Mint(owner.TxOpts, owner.Address, decimal.EtherToWei("1000"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	testutil.AutoCommit(t, ctx)
+
+	mints, err = w.Check(ctx, b, 0, head())
+	require.NoError(t, err)
+	require.Len(t, mints, 1)
+	require.Equal(t, tx.Hash(), mints[0].Log.TxHash)
+	require.Equal(t, owner.Address, mints[0].Account)
+	require.Equal(t, wrappedAddr, mints[0].Token)
+	require.Len(t, alerts, 1)
+	require.Len(t, reasons, 1)
+	require.True(t, w.Breaker.Tripped())
+
+	paused, err := burner.Paused(nil)
+	require.NoError(t, err)
+	require.True(t, paused)
+}
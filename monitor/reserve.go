@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+)
+
+// ReserveWatcher reconciles reserves of every pair and alerts
+// when minted supply of a pair starts or stops exceeding its locked reserve
+type ReserveWatcher struct {
+	Pairs   []config.Pair
+	Clients map[string]multiclient.Backend
+
+	// OnChange is called when deficit of a pair starts or is resolved
+	OnChange func(r reserve.Result)
+	// Breaker is tripped on deficit, nil only alerts
+	Breaker *Breaker
+
+	mu       sync.Mutex
+	deficits map[string]bool
+}
+
+// Check reconciles all pairs, alerts on deficit changes and trips breaker on deficit
+func (w *ReserveWatcher) Check(ctx context.Context) ([]reserve.Result, error) {
+	results, err := reserve.CheckAll(ctx, w.Pairs, w.Clients)
+	if err != nil {
+		return results, err
+	}
+
+	w.mu.Lock()
+	if w.deficits == nil {
+		w.deficits = map[string]bool{}
+	}
+	var changed []reserve.Result
+	for _, r := range results {
+		if r.Deficit() != w.deficits[r.Pair.Name] {
+			w.deficits[r.Pair.Name] = r.Deficit()
+			changed = append(changed, r)
+		}
+	}
+	w.mu.Unlock()
+
+	if w.OnChange != nil {
+		for _, r := range changed {
+			w.OnChange(r)
+		}
+	}
+	if w.Breaker != nil {
+		for _, r := range results {
+			if r.Deficit() {
+				w.Breaker.Trip(ctx, deficit(r))
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+func deficit(r reserve.Result) string {
+	return fmt.Sprintf("%s minted %s exceeds locked %s", r.Pair.Name, r.Minted, r.Locked)
+}
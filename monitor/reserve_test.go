@@ -0,0 +1,68 @@
+package monitor_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+	"killswitch/bridge/testutil"
+)
+
+func TestReserveWatcher(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	_, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+
+	var alerts []reserve.Result
+	w := &monitor.ReserveWatcher{
+		Pairs: []config.Pair{{
+			Name:        "BNB <=> kBNB",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+		}},
+		Clients: map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend},
+		OnChange: func(r reserve.Result) {
+			alerts = append(alerts, r)
+		},
+	}
+
+	_, err := w.Check(ctx)
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	// minted without lock
+	_, err = wrapped.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = wrapped.Mint(owner.TxOpts, owner.Address, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	for i := 0; i < 2; i++ {
+		results, err := w.Check(ctx)
+		require.NoError(t, err)
+		require.True(t, results[0].Deficit())
+		require.Len(t, alerts, 1)
+		require.True(t, alerts[0].Deficit())
+	}
+
+	// locked reserve covers supply again
+	opts := *owner.TxOpts
+	opts.Value = decimal.EtherToWei("1")
+	_, err = ether.Lock(&opts, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	_, err = w.Check(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.False(t, alerts[1].Deficit())
+}
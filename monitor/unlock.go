@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// Kinds of unlock findings
+const (
+	// KindOrphanUnlock is an Unlocked event without source Locked log, funds released without deposit
+	KindOrphanUnlock = "orphan unlock"
+	// KindMissingUnlock is a Locked event which is not unlocked in time
+	KindMissingUnlock = "missing unlock"
+)
+
+// Finding is an unlock correlation problem of a pair
+type Finding struct {
+	Kind string
+	// Bridge emitted Log, unlocking bridge for orphan unlock and locking bridge for missing unlock
+	Bridge  config.Bridge
+	Hash    common.Hash
+	Account common.Address
+	Amount  *big.Int
+	Log     types.Log
+	Detail  string
+}
+
+// String returns readable finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s hash %s: %s %s to %s", f.Kind, f.Bridge, f.Hash.Hex(), f.Detail, f.Amount, f.Account.Hex())
+}
+
+// Correlator matches Locked and Unlocked events of a pair by canonical unlock hash,
+// it checks transfers from source to destination bridge, see Reverse for the other direction
+type Correlator struct {
+	Pair        config.Pair
+	Source      multiclient.Backend
+	Destination multiclient.Backend
+
+	// MaxAge is how long a lock may wait for its unlock
+	MaxAge time.Duration
+
+	// OnFinding is called for each finding
+	OnFinding func(f Finding)
+
+	now func() time.Time
+}
+
+// Reverse returns correlator of transfers from destination to source bridge,
+// locks (burns) of destination bridge which are unlocked from custody of source bridge
+func (c *Correlator) Reverse() *Correlator {
+	r := *c
+	r.Pair.Source, r.Pair.Destination = c.Pair.Destination, c.Pair.Source
+	r.Source, r.Destination = c.Destination, c.Source
+	return &r
+}
+
+func (c *Correlator) alert(findings []Finding) {
+	if c.OnFinding == nil {
+		return
+	}
+	for _, f := range findings {
+		c.OnFinding(f)
+	}
+}
+
+// Orphans returns unlocks of destination bridge in block range
+// whose hash maps to no matching Locked log of source bridge
+func (c *Correlator) Orphans(ctx context.Context, from, to uint64) ([]Finding, error) {
+	unlocks, err := unlocker.FilterUnlocks(ctx, c.Destination, c.Pair.Destination.Address, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []Finding
+	for _, u := range unlocks {
+		detail, err := c.orphan(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if detail != "" {
+			r = append(r, Finding{
+				Kind:    KindOrphanUnlock,
+				Bridge:  c.Pair.Destination,
+				Hash:    u.Hash,
+				Account: u.Account,
+				Amount:  u.Amount,
+				Log:     u.Log,
+				Detail:  detail,
+			})
+		}
+	}
+
+	c.alert(r)
+	return r, nil
+}
+
+// orphan returns why unlock has no source lock, empty when lock is found
+func (c *Correlator) orphan(ctx context.Context, u unlocker.Unlock) (string, error) {
+	receipt, err := c.Source.TransactionReceipt(ctx, u.Hash)
+	if errors.Is(err, ethereum.NotFound) || (err == nil && receipt == nil) {
+		return "no lock transaction", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("can not get lock receipt %s; %w", u.Hash.Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return "lock transaction reverted", nil
+	}
+
+	detail := fmt.Sprintf("no Locked log of %s", c.Pair.Source.Address.Hex())
+	for _, l := range receipt.Logs {
+		if l.Address != c.Pair.Source.Address || len(l.Topics) == 0 || l.Topics[0] != unlocker.LockedTopic {
+			continue
+		}
+		job, err := unlocker.NewJob(*l)
+		if err != nil {
+			return "", err
+		}
+		if job.Account == u.Account && job.Amount.Cmp(u.Amount) == 0 {
+			return "", nil
+		}
+		detail = fmt.Sprintf("locked %s by %s", job.Amount, job.Account.Hex())
+	}
+	return detail, nil
+}
+
+// Missing returns locks of source bridge in block range which are older than MaxAge
+// and not completed on destination bridge, younger locks are not checked
+func (c *Correlator) Missing(ctx context.Context, from, to uint64) ([]Finding, error) {
+	if c.now == nil {
+		c.now = time.Now
+	}
+
+	jobs, err := unlocker.FilterJobs(ctx, c.Source, c.Pair.Source.Address, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	bridge, _ := abi.NewBridgeBase(c.Pair.Destination.Address, c.Destination)
+	times := map[uint64]time.Time{}
+
+	var r []Finding
+	for _, job := range jobs {
+		at, ok := times[job.Lock.BlockNumber]
+		if !ok {
+			header, err := c.Source.HeaderByNumber(ctx, new(big.Int).SetUint64(job.Lock.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("can not get header %d; %w", job.Lock.BlockNumber, err)
+			}
+			at = time.Unix(int64(header.Time), 0)
+			times[job.Lock.BlockNumber] = at
+		}
+		age := c.now().Sub(at)
+		if age < c.MaxAge {
+			continue
+		}
+
+		completed, err := bridge.IsUnlockCompleted(&bind.CallOpts{Context: ctx}, job.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("can not check unlock completed %s; %w", job.Hash.Hex(), err)
+		}
+		if !completed {
+			r = append(r, Finding{
+				Kind:    KindMissingUnlock,
+				Bridge:  c.Pair.Source,
+				Hash:    job.Hash,
+				Account: job.Account,
+				Amount:  job.Amount,
+				Log:     job.Lock,
+				Detail:  fmt.Sprintf("locked %s ago", age.Truncate(time.Second)),
+			})
+		}
+	}
+
+	c.alert(r)
+	return r, nil
+}
@@ -0,0 +1,139 @@
+package monitor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/testutil"
+)
+
+func TestCorrelator(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	_, err := token.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = token.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+
+	_, err = wrapped.AddMinter(owner.TxOpts, burnerAddr)
+	require.NoError(t, err)
+	_, err = token.Approve(user.TxOpts, lockerAddr, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	var locks []common.Hash
+	for i := 0; i < 3; i++ {
+		tx, err := locker.Lock(user.TxOpts, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		locks = append(locks, tx.Hash())
+	}
+
+	// lock 0 unlocked, lock 1 unlocked with wrong amount, lock 2 not unlocked,
+	// plus an unlock of a hash which is not a transaction
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), locks[0])
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("2"), locks[1])
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("5"), common.Hash{1})
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	head := ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+
+	var alerts []monitor.Finding
+	c := &monitor.Correlator{
+		Pair: config.Pair{
+			Name:        "DAI <=> kDAI",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+		},
+		Source:      ctx.Backend,
+		Destination: ctx.Backend,
+		OnFinding: func(f monitor.Finding) {
+			alerts = append(alerts, f)
+		},
+	}
+
+	t.Run("Orphans", func(t *testing.T) {
+		alerts = nil
+		r, err := c.Orphans(ctx, 0, head)
+		require.NoError(t, err)
+		require.Len(t, r, 2)
+
+		require.Equal(t, monitor.KindOrphanUnlock, r[0].Kind)
+		require.Equal(t, locks[1], r[0].Hash)
+		require.Contains(t, r[0].Detail, "locked 1000000000000000000")
+		require.Equal(t, common.Hash{1}, r[1].Hash)
+		require.Equal(t, "no lock transaction", r[1].Detail)
+		require.Equal(t, r, alerts)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		alerts = nil
+		r, err := c.Missing(ctx, 0, head)
+		require.NoError(t, err)
+		require.Len(t, r, 1)
+		require.Equal(t, monitor.KindMissingUnlock, r[0].Kind)
+		require.Equal(t, locks[2], r[0].Hash)
+		require.Equal(t, user.Address, r[0].Account)
+		require.Equal(t, r, alerts)
+
+		// simulated blocks start at unix 0, so locks are younger than this
+		c.MaxAge = time.Since(time.Unix(0, 0)) + time.Hour
+		r, err = c.Missing(ctx, 0, head)
+		require.NoError(t, err)
+		require.Empty(t, r)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		// burns of kDAI unlocked from DAI custody: burn 0 unlocked, burn 1 not unlocked,
+		// plus an unlock of custody without burn
+		_, err := wrapped.Approve(user.TxOpts, burnerAddr, decimal.EtherToWei("2"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		var burns []common.Hash
+		for i := 0; i < 2; i++ {
+			tx, err := burner.Lock(user.TxOpts, decimal.EtherToWei("1"))
+			require.NoError(t, err)
+			ctx.Backend.Commit()
+			burns = append(burns, tx.Hash())
+		}
+		_, err = locker.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), burns[0])
+		require.NoError(t, err)
+		_, err = locker.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), common.Hash{2})
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		head := ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+
+		reverse := c.Reverse()
+		reverse.MaxAge = 0
+		alerts = nil
+		orphans, err := reverse.Orphans(ctx, 0, head)
+		require.NoError(t, err)
+		require.Len(t, orphans, 1)
+		require.Equal(t, common.Hash{2}, orphans[0].Hash)
+		require.Equal(t, lockerAddr, orphans[0].Bridge.Address)
+
+		missing, err := reverse.Missing(ctx, 0, head)
+		require.NoError(t, err)
+		require.Len(t, missing, 1)
+		require.Equal(t, burns[1], missing[0].Hash)
+		require.Equal(t, burnerAddr, missing[0].Bridge.Address)
+		require.Equal(t, append(orphans, missing...), alerts)
+	})
+}
@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+)
+
+// Watcher correlates unlocks of every pair in both directions and checks mints of wrapped tokens
+// on new blocks, for a long-lived process, it remembers next block to check of each direction
+// in memory and starts Window blocks before head
+type Watcher struct {
+	Pairs   []config.Pair
+	Chains  map[string]config.Chain
+	Clients map[string]multiclient.Backend
+
+	// MaxAge is how long a lock may wait for its unlock, see Correlator
+	MaxAge time.Duration
+	// Window is number of blocks before head checked on first run
+	Window uint64
+
+	// OnFinding is called for each finding
+	OnFinding func(f Finding)
+	// Mints checks burner destinations for unauthorized mints, nil skips the check
+	Mints *MintWatcher
+
+	mu      sync.Mutex
+	orphans map[string]uint64
+	missing map[string]uint64
+	mints   map[string]uint64
+	now     func() time.Time
+}
+
+// Check correlates unlocks in confirmed blocks since last check,
+// locks are checked for missing unlocks once their block is older than MaxAge
+// so a lock is never skipped while it may still be unlocked in time
+func (w *Watcher) Check(ctx context.Context) ([]Finding, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.orphans == nil {
+		w.orphans, w.missing, w.mints = map[string]uint64{}, map[string]uint64{}, map[string]uint64{}
+	}
+	if w.now == nil {
+		w.now = time.Now
+	}
+
+	heads, aged := map[string]uint64{}, map[string]uint64{}
+	var r []Finding
+	for _, p := range w.Pairs {
+		c := &Correlator{
+			Pair:        p,
+			Source:      w.Clients[p.Source.Chain],
+			Destination: w.Clients[p.Destination.Chain],
+			MaxAge:      w.MaxAge,
+			OnFinding:   w.OnFinding,
+			now:         w.now,
+		}
+		for _, c := range []*Correlator{c, c.Reverse()} {
+			f, err := w.correlate(ctx, c, heads, aged)
+			r = append(r, f...)
+			if err != nil {
+				return r, fmt.Errorf("%s; %w", p.Name, err)
+			}
+		}
+
+		if w.Mints != nil && p.Destination.Type == config.TypeBurner {
+			to, err := w.head(ctx, p.Destination.Chain, heads)
+			if err != nil {
+				return r, err
+			}
+			if from, ok := w.from(w.mints, p.Name, to); ok {
+				if _, err := w.Mints.Check(ctx, admin.NewBridge(p.Destination, c.Destination), from, to); err != nil {
+					return r, fmt.Errorf("%s; %w", p.Name, err)
+				}
+				w.mints[p.Name] = to + 1
+			}
+		}
+	}
+	return r, nil
+}
+
+// correlate checks orphan and missing unlocks of the direction of c since last check,
+// directions are keyed by their locking bridge
+func (w *Watcher) correlate(ctx context.Context, c *Correlator, heads, aged map[string]uint64) ([]Finding, error) {
+	key := c.Pair.Source.String()
+
+	var r []Finding
+	to, err := w.head(ctx, c.Pair.Destination.Chain, heads)
+	if err != nil {
+		return r, err
+	}
+	if from, ok := w.from(w.orphans, key, to); ok {
+		f, err := c.Orphans(ctx, from, to)
+		if err != nil {
+			return r, err
+		}
+		r = append(r, f...)
+		w.orphans[key] = to + 1
+	}
+
+	if to, err = w.aged(ctx, c.Pair.Source.Chain, heads, aged); err != nil {
+		return r, err
+	}
+	if from, ok := w.from(w.missing, key, to); ok {
+		f, err := c.Missing(ctx, from, to)
+		if err != nil {
+			return r, err
+		}
+		r = append(r, f...)
+		w.missing[key] = to + 1
+	}
+	return r, nil
+}
+
+// from returns first block of key to check up to to, false when there is no new block
+func (w *Watcher) from(next map[string]uint64, key string, to uint64) (uint64, bool) {
+	from, ok := next[key]
+	if !ok && to > w.Window {
+		from = to - w.Window
+	}
+	return from, from <= to
+}
+
+// head returns last confirmed block of chain
+func (w *Watcher) head(ctx context.Context, chain string, heads map[string]uint64) (uint64, error) {
+	if n, ok := heads[chain]; ok {
+		return n, nil
+	}
+	client, ok := w.Clients[chain]
+	if !ok {
+		return 0, fmt.Errorf("unknown chain %s", chain)
+	}
+	h, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("can not get head of %s; %w", chain, err)
+	}
+	n := h.Number.Uint64()
+	if confirmations := w.Chains[chain].Confirmations; n > confirmations {
+		n -= confirmations
+	} else {
+		n = 0
+	}
+	heads[chain] = n
+	return n, nil
+}
+
+// aged returns last confirmed block of chain mined at least MaxAge ago
+func (w *Watcher) aged(ctx context.Context, chain string, heads, aged map[string]uint64) (uint64, error) {
+	if n, ok := aged[chain]; ok {
+		return n, nil
+	}
+	head, err := w.head(ctx, chain, heads)
+	if err != nil {
+		return 0, err
+	}
+	n, err := reserve.BlockAt(ctx, w.Clients[chain], w.now().Add(-w.MaxAge))
+	if err != nil {
+		return 0, fmt.Errorf("can not find aged block of %s; %w", chain, err)
+	}
+	if n > head {
+		n = head
+	}
+	aged[chain] = n
+	return n, nil
+}
@@ -0,0 +1,125 @@
+package monitor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func TestWatcher(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	_, err := token.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = token.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+	_, err = wrapped.AddMinter(owner.TxOpts, burnerAddr)
+	require.NoError(t, err)
+	_, err = token.Approve(user.TxOpts, lockerAddr, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	// lock is not unlocked, unlock has no lock
+	lock, err := locker.Lock(user.TxOpts, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), common.Hash{1})
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	var alerts []monitor.Finding
+	w := &monitor.Watcher{
+		Pairs: []config.Pair{{
+			Name:        "DAI <=> kDAI",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+		}},
+		Clients: map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend},
+		// simulated blocks start at unix 0, blocks before the time adjustment below are aged
+		MaxAge: time.Since(time.Unix(0, 0)) - time.Hour,
+		Window: 1000,
+		OnFinding: func(f monitor.Finding) {
+			alerts = append(alerts, f)
+		},
+	}
+
+	r, err := w.Check(ctx)
+	require.NoError(t, err)
+	require.Len(t, r, 2)
+	require.Equal(t, monitor.KindOrphanUnlock, r[0].Kind)
+	require.Equal(t, common.Hash{1}, r[0].Hash)
+	require.Equal(t, monitor.KindMissingUnlock, r[1].Kind)
+	require.Equal(t, lock.Hash(), r[1].Hash)
+	require.Equal(t, r, alerts)
+
+	t.Run("NoNewBlocks", func(t *testing.T) {
+		r, err := w.Check(ctx)
+		require.NoError(t, err)
+		require.Empty(t, r)
+	})
+
+	t.Run("NewBlocks", func(t *testing.T) {
+		// new lock is younger than MaxAge and not reported yet
+		require.NoError(t, ctx.Backend.AdjustTime(2*time.Hour))
+		ctx.Backend.Commit()
+		_, err := locker.Lock(user.TxOpts, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("2"), common.Hash{2})
+		require.NoError(t, err)
+		// custody released without burn
+		_, err = locker.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), common.Hash{3})
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		r, err := w.Check(ctx)
+		require.NoError(t, err)
+		require.Len(t, r, 2)
+		require.Equal(t, monitor.KindOrphanUnlock, r[0].Kind)
+		require.Equal(t, common.Hash{2}, r[0].Hash)
+		require.Equal(t, monitor.KindOrphanUnlock, r[1].Kind)
+		require.Equal(t, common.Hash{3}, r[1].Hash)
+		require.Equal(t, lockerAddr, r[1].Bridge.Address)
+	})
+
+	t.Run("Mints", func(t *testing.T) {
+		var mints []monitor.Mint
+		w.Mints = &monitor.MintWatcher{
+			OnUnauthorized: func(m monitor.Mint) {
+				mints = append(mints, m)
+			},
+		}
+
+		// compromised minter, unlocks before are checked on first run
+		_, err := wrapped.AddMinter(owner.TxOpts, owner.Address)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		_, err = wrapped.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("1000"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		_, err = w.Check(ctx)
+		require.NoError(t, err)
+		require.Len(t, mints, 1)
+		require.Equal(t, decimal.EtherToWei("1000"), mints[0].Amount)
+
+		_, err = w.Check(ctx)
+		require.NoError(t, err)
+		require.Len(t, mints, 1)
+	})
+}
@@ -0,0 +1,359 @@
+package multiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"killswitch/bridge/backfill"
+)
+
+// ErrNoEndpoint is returned when client has no endpoint to send request to
+var ErrNoEndpoint = errors.New("multiclient: no endpoint")
+
+// Backend is the chain access used by workers,
+// it is satisfied by Client, Quorum and the simulated backend
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+var _ Backend = (*Client)(nil)
+
+// Conn is a Backend owning its endpoints, it is satisfied by Client and Quorum
+type Conn interface {
+	Backend
+	BlockNumber(ctx context.Context) (uint64, error)
+	Close()
+}
+
+var _ Conn = (*Quorum)(nil)
+
+// Client is a bind.ContractBackend over multiple rpc endpoints,
+// requests go to the healthiest endpoint and fail over to the next one
+// when an endpoint can not be reached
+type Client struct {
+	endpoints []*endpoint
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// Option configures Client
+type Option func(*Client)
+
+// WithCooldown sets how long a failed endpoint is skipped before retry
+func WithCooldown(d time.Duration) Option {
+	return func(c *Client) {
+		c.cooldown = d
+	}
+}
+
+type endpoint struct {
+	url    string
+	client *ethclient.Client
+
+	mu        sync.Mutex
+	failures  int
+	downUntil time.Time
+	lastErr   error
+	latency   time.Duration
+}
+
+// Status is the health of an endpoint
+type Status struct {
+	URL      string
+	Healthy  bool
+	Failures int
+	LastErr  error
+	Latency  time.Duration
+}
+
+// Dial connects to all urls, the order of urls is the preference order
+func Dial(ctx context.Context, urls []string, opts ...Option) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, ErrNoEndpoint
+	}
+
+	c := &Client{
+		cooldown: 30 * time.Second,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	for _, url := range urls {
+		rc, err := rpc.DialContext(ctx, url)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("can not dial %s; %w", url, err)
+		}
+		c.endpoints = append(c.endpoints, &endpoint{url: url, client: ethclient.NewClient(rc)})
+	}
+
+	return c, nil
+}
+
+// Close closes all endpoints
+func (c *Client) Close() {
+	for _, e := range c.endpoints {
+		e.client.Close()
+	}
+}
+
+// Health returns status of all endpoints in preference order
+func (c *Client) Health() []Status {
+	now := c.now()
+
+	var r []Status
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		r = append(r, Status{
+			URL:      e.url,
+			Healthy:  !now.Before(e.downUntil),
+			Failures: e.failures,
+			LastErr:  e.lastErr,
+			Latency:  e.latency,
+		})
+		e.mu.Unlock()
+	}
+
+	return r
+}
+
+// ordered returns healthy endpoints first (fewest failures first),
+// endpoints in cooldown are kept at the end as last resort
+func (c *Client) ordered() []*endpoint {
+	now := c.now()
+
+	type item struct {
+		e        *endpoint
+		down     bool
+		failures int
+	}
+	items := make([]item, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		items = append(items, item{e, now.Before(e.downUntil), e.failures})
+		e.mu.Unlock()
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].down != items[j].down {
+			return !items[i].down
+		}
+		return items[i].failures < items[j].failures
+	})
+
+	r := make([]*endpoint, len(items))
+	for i, it := range items {
+		r[i] = it.e
+	}
+	return r
+}
+
+func (c *Client) report(e *endpoint, start time.Time, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err == nil {
+		e.failures = 0
+		e.downUntil = time.Time{}
+		e.latency = c.now().Sub(start)
+		return
+	}
+	e.failures++
+	e.lastErr = err
+	e.downUntil = c.now().Add(c.cooldown)
+}
+
+// isEndpointError reports whether err is caused by endpoint (transport, rate limit)
+// rather than the request itself (reverted call, invalid params, log query over too large range
+// which backfill.Backfiller retries with a smaller range)
+func isEndpointError(err error) bool {
+	if err == nil || errors.Is(err, ethereum.NotFound) || backfill.IsRangeError(err) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// -32005 limit exceeded, -32603 internal error
+		return rpcErr.ErrorCode() == -32005 || rpcErr.ErrorCode() == -32603
+	}
+	return true
+}
+
+// observe records health of endpoint from result of a request
+func (c *Client) observe(ctx context.Context, e *endpoint, start time.Time, err error) {
+	if isEndpointError(err) {
+		c.report(e, start, err)
+	} else if ctx.Err() == nil {
+		c.report(e, start, nil)
+	}
+}
+
+// do calls f on endpoints in order until one succeeds or returns request error
+func (c *Client) do(ctx context.Context, f func(*ethclient.Client) error) error {
+	err := ErrNoEndpoint
+	for _, e := range c.ordered() {
+		start := c.now()
+		err = f(e.client)
+		c.observe(ctx, e, start, err)
+		if !isEndpointError(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// ChainID retrieves the chain id
+func (c *Client) ChainID(ctx context.Context) (r *big.Int, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.ChainID(ctx)
+		return
+	})
+	return
+}
+
+// BlockNumber returns the most recent block number
+func (c *Client) BlockNumber(ctx context.Context) (r uint64, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.BlockNumber(ctx)
+		return
+	})
+	return
+}
+
+// HeaderByNumber returns a block header, nil number returns the latest header
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (r *types.Header, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.HeaderByNumber(ctx, number)
+		return
+	})
+	return
+}
+
+// BalanceAt returns the wei balance of account
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (r *big.Int, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.BalanceAt(ctx, account, blockNumber)
+		return
+	})
+	return
+}
+
+// TransactionByHash returns the transaction with the given hash
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, pending bool, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		tx, pending, err = ec.TransactionByHash(ctx, hash)
+		return
+	})
+	return
+}
+
+// TransactionReceipt returns the receipt of a mined transaction
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (r *types.Receipt, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.TransactionReceipt(ctx, txHash)
+		return
+	})
+	return
+}
+
+// CodeAt returns the contract code of the given account
+func (c *Client) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (r []byte, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.CodeAt(ctx, contract, blockNumber)
+		return
+	})
+	return
+}
+
+// CallContract executes a message call transaction
+func (c *Client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (r []byte, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.CallContract(ctx, call, blockNumber)
+		return
+	})
+	return
+}
+
+// PendingCodeAt returns the contract code of the given account in the pending state
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) (r []byte, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.PendingCodeAt(ctx, account)
+		return
+	})
+	return
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (r uint64, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.PendingNonceAt(ctx, account)
+		return
+	})
+	return
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price
+func (c *Client) SuggestGasPrice(ctx context.Context) (r *big.Int, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.SuggestGasPrice(ctx)
+		return
+	})
+	return
+}
+
+// EstimateGas estimates the gas needed to execute call
+func (c *Client) EstimateGas(ctx context.Context, call ethereum.CallMsg) (r uint64, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.EstimateGas(ctx, call)
+		return
+	})
+	return
+}
+
+// SendTransaction injects a signed transaction into the pending pool
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.do(ctx, func(ec *ethclient.Client) error {
+		return ec.SendTransaction(ctx, tx)
+	})
+}
+
+// FilterLogs executes a filter query
+func (c *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (r []types.Log, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.FilterLogs(ctx, q)
+		return
+	})
+	return
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (r ethereum.Subscription, err error) {
+	err = c.do(ctx, func(ec *ethclient.Client) (err error) {
+		r, err = ec.SubscribeFilterLogs(ctx, q, ch)
+		return
+	})
+	return
+}
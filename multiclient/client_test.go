@@ -0,0 +1,205 @@
+package multiclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/backfill"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+)
+
+func dial(t *testing.T, servers ...*testutil.RPCServer) *multiclient.Client {
+	t.Helper()
+
+	var urls []string
+	for _, s := range servers {
+		urls = append(urls, s.URL)
+	}
+
+	c, err := multiclient.Dial(context.Background(), urls)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("No endpoint", func(t *testing.T) {
+		_, err := multiclient.Dial(ctx, nil)
+		require.ErrorIs(t, err, multiclient.ErrNoEndpoint)
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		s1 := testutil.NewRPCServer(t)
+		s1.HandleResult("eth_chainId", "0x38")
+		s1.SetDown(true)
+		s2 := testutil.NewRPCServer(t)
+		s2.HandleResult("eth_chainId", "0x38")
+
+		c := dial(t, s1, s2)
+
+		chainID, err := c.ChainID(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "56", chainID.String())
+		require.Equal(t, 1, s1.Calls("eth_chainId"))
+		require.Equal(t, 1, s2.Calls("eth_chainId"))
+
+		health := c.Health()
+		require.False(t, health[0].Healthy)
+		require.Equal(t, 1, health[0].Failures)
+		require.True(t, health[1].Healthy)
+
+		// unhealthy endpoint is skipped while cooling down
+		_, err = c.ChainID(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, s1.Calls("eth_chainId"))
+		require.Equal(t, 2, s2.Calls("eth_chainId"))
+	})
+
+	t.Run("All down", func(t *testing.T) {
+		s1 := testutil.NewRPCServer(t)
+		s1.SetDown(true)
+		s2 := testutil.NewRPCServer(t)
+		s2.SetDown(true)
+
+		c := dial(t, s1, s2)
+
+		_, err := c.BlockNumber(ctx)
+		require.Error(t, err)
+		require.Equal(t, 1, s1.Calls("eth_blockNumber"))
+		require.Equal(t, 1, s2.Calls("eth_blockNumber"))
+	})
+
+	t.Run("Request error does not fail over", func(t *testing.T) {
+		reverted := func([]json.RawMessage) (interface{}, error) {
+			return nil, errors.New("execution reverted")
+		}
+		s1 := testutil.NewRPCServer(t)
+		s1.Handle("eth_call", reverted)
+		s2 := testutil.NewRPCServer(t)
+		s2.Handle("eth_call", reverted)
+
+		c := dial(t, s1, s2)
+
+		_, err := c.CallContract(ctx, ethereum.CallMsg{}, nil)
+		require.EqualError(t, err, "execution reverted")
+		require.Equal(t, 1, s1.Calls("eth_call"))
+		require.Equal(t, 0, s2.Calls("eth_call"))
+		require.True(t, c.Health()[0].Healthy)
+	})
+
+	t.Run("Range error does not fail over", func(t *testing.T) {
+		tooLarge := func([]json.RawMessage) (interface{}, error) {
+			return nil, testutil.RPCError{Code: -32005, Message: "query returned more than 10000 results"}
+		}
+		s1 := testutil.NewRPCServer(t)
+		s1.Handle("eth_getLogs", tooLarge)
+		s2 := testutil.NewRPCServer(t)
+		s2.Handle("eth_getLogs", tooLarge)
+
+		c := dial(t, s1, s2)
+
+		_, err := c.FilterLogs(ctx, ethereum.FilterQuery{})
+		require.True(t, backfill.IsRangeError(err))
+		require.Equal(t, 1, s1.Calls("eth_getLogs"))
+		require.Equal(t, 0, s2.Calls("eth_getLogs"))
+		require.True(t, c.Health()[0].Healthy)
+	})
+}
+
+func TestQuorum(t *testing.T) {
+	ctx := context.Background()
+	account := common.HexToAddress("0xa4e3a7DE03D4138620EEc38766C06d175dF64963")
+
+	newServer := func(t *testing.T, head, balance string) *testutil.RPCServer {
+		s := testutil.NewRPCServer(t)
+		s.HandleResult("eth_blockNumber", head)
+		s.Handle("eth_getBalance", func(params []json.RawMessage) (interface{}, error) {
+			var block string
+			require.NoError(t, json.Unmarshal(params[1], &block))
+			require.Equal(t, "0x10", block)
+			return balance, nil
+		})
+		return s
+	}
+
+	t.Run("Agree", func(t *testing.T) {
+		c := dial(t,
+			newServer(t, "0x10", "0x100"),
+			newServer(t, "0x11", "0x200"),
+			newServer(t, "0x12", "0x100"),
+		)
+
+		balance, err := c.Quorum(2).BalanceAt(ctx, account, nil)
+		require.NoError(t, err)
+		require.Equal(t, "256", balance.String())
+	})
+
+	t.Run("Disagree", func(t *testing.T) {
+		c := dial(t,
+			newServer(t, "0x10", "0x100"),
+			newServer(t, "0x10", "0x200"),
+			newServer(t, "0x10", "0x300"),
+		)
+
+		_, err := c.Quorum(2).BalanceAt(ctx, account, nil)
+		require.ErrorIs(t, err, multiclient.ErrNoQuorum)
+	})
+
+	t.Run("Endpoint down", func(t *testing.T) {
+		s := newServer(t, "0x10", "0x100")
+		s.SetDown(true)
+
+		c := dial(t,
+			s,
+			newServer(t, "0x10", "0x100"),
+			newServer(t, "0x10", "0x100"),
+		)
+
+		balance, err := c.Quorum(2).BalanceAt(ctx, account, nil)
+		require.NoError(t, err)
+		require.Equal(t, "256", balance.String())
+
+		_, err = c.Quorum(3).BalanceAt(ctx, account, nil)
+		require.ErrorIs(t, err, multiclient.ErrNoQuorum)
+	})
+
+	t.Run("Logs", func(t *testing.T) {
+		logs := []map[string]interface{}{{
+			"address":          account.Hex(),
+			"topics":           []string{common.Hash{1}.Hex()},
+			"data":             "0x",
+			"blockNumber":      "0x10",
+			"transactionHash":  common.Hash{2}.Hex(),
+			"transactionIndex": "0x0",
+			"blockHash":        common.Hash{3}.Hex(),
+			"logIndex":         "0x0",
+			"removed":          false,
+		}}
+
+		s1 := newServer(t, "0x10", "0x0")
+		s1.HandleResult("eth_getLogs", logs)
+		s2 := newServer(t, "0x10", "0x0")
+		s2.HandleResult("eth_getLogs", []interface{}{})
+
+		c := dial(t, s1, s2)
+
+		_, err := c.Quorum(2).FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{account}})
+		require.ErrorIs(t, err, multiclient.ErrNoQuorum)
+
+		s2.HandleResult("eth_getLogs", logs)
+		r, err := c.Quorum(2).FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{account}})
+		require.NoError(t, err)
+		require.Len(t, r, 1)
+		require.Equal(t, common.Hash{2}, r[0].TxHash)
+	})
+}
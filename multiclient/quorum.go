@@ -0,0 +1,195 @@
+package multiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ErrNoQuorum is returned when not enough endpoints agree on a result
+var ErrNoQuorum = errors.New("multiclient: no quorum")
+
+var _ Backend = (*Quorum)(nil)
+
+// Quorum is a Client view which requires n endpoints to agree
+// on critical reads (CallContract, BalanceAt and FilterLogs),
+// other requests behave the same as Client
+type Quorum struct {
+	*Client
+	n int
+}
+
+// Quorum returns view of client which requires n-of-m agreement on critical reads,
+// n is capped by number of endpoints
+func (c *Client) Quorum(n int) *Quorum {
+	if n > len(c.endpoints) {
+		n = len(c.endpoints)
+	}
+	if n < 1 {
+		n = 1
+	}
+	return &Quorum{Client: c, n: n}
+}
+
+type answer struct {
+	key   []byte
+	value interface{}
+}
+
+// agree calls f on all endpoints concurrently and returns the value
+// returned by at least n endpoints
+func (q *Quorum) agree(ctx context.Context, f func(*ethclient.Client) (interface{}, []byte, error)) (interface{}, error) {
+	endpoints := q.ordered()
+
+	var wg sync.WaitGroup
+	answers := make([]*answer, len(endpoints))
+	errs := make([]error, len(endpoints))
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+
+			start := q.now()
+			value, key, err := f(e.client)
+			q.observe(ctx, e, start, err)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			answers[i] = &answer{key, value}
+		}(i, e)
+	}
+	wg.Wait()
+
+	for i, a := range answers {
+		if a == nil {
+			continue
+		}
+		count := 0
+		for _, b := range answers[i:] {
+			if b != nil && bytes.Equal(a.key, b.key) {
+				count++
+			}
+		}
+		if count >= q.n {
+			return a.value, nil
+		}
+	}
+
+	// request errors (reverted call) are the same on every endpoint
+	for _, err := range errs {
+		if err != nil && !isEndpointError(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w; %d of %d endpoints required", ErrNoQuorum, q.n, len(endpoints))
+}
+
+// pin resolves latest block to the lowest head of endpoints
+// so every endpoint answers for the same block
+func (q *Quorum) pin(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber != nil {
+		return blockNumber, nil
+	}
+
+	var (
+		mu   sync.Mutex
+		head *big.Int
+		wg   sync.WaitGroup
+	)
+	for _, e := range q.ordered() {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+
+			start := q.now()
+			n, err := e.client.BlockNumber(ctx)
+			q.observe(ctx, e, start, err)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if v := new(big.Int).SetUint64(n); head == nil || v.Cmp(head) < 0 {
+				head = v
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	if head == nil {
+		return nil, fmt.Errorf("%w; can not get block number", ErrNoQuorum)
+	}
+	return head, nil
+}
+
+// CallContract executes a message call transaction, result must be agreed by quorum
+func (q *Quorum) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	blockNumber, err := q.pin(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := q.agree(ctx, func(ec *ethclient.Client) (interface{}, []byte, error) {
+		r, err := ec.CallContract(ctx, call, blockNumber)
+		return r, r, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// BalanceAt returns the wei balance of account, result must be agreed by quorum
+func (q *Quorum) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	blockNumber, err := q.pin(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := q.agree(ctx, func(ec *ethclient.Client) (interface{}, []byte, error) {
+		r, err := ec.BalanceAt(ctx, account, blockNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, r.Bytes(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*big.Int), nil
+}
+
+// FilterLogs executes a filter query, result must be agreed by quorum
+func (q *Quorum) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if query.BlockHash == nil {
+		to, err := q.pin(ctx, query.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+		query.ToBlock = to
+	}
+
+	v, err := q.agree(ctx, func(ec *ethclient.Client) (interface{}, []byte, error) {
+		r, err := ec.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := json.Marshal(r)
+		return r, key, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]types.Log), nil
+}
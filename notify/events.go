@@ -0,0 +1,226 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/reserve"
+	"killswitch/bridge/unlocker"
+)
+
+// Event sources
+const (
+	SourceMonitor = "monitor"
+	SourceRelayer = "relayer"
+)
+
+// Event kinds
+const (
+	KindRunway    = "runway"
+	KindPaused    = "paused"
+	KindUnpaused  = "unpaused"
+	KindLiquidity = "liquidity"
+	KindHeld      = "held"
+	KindApproval  = "approval"
+	KindOrphan    = "orphan"
+	KindMissing   = "missing"
+	KindMint      = "mint"
+	KindBreaker   = "breaker"
+	KindDeficit   = "deficit"
+)
+
+// Runway returns event of relayer runway level change, ok is a recovery
+func Runway(r monitor.Runway) Event {
+	e := Event{
+		Source: SourceMonitor,
+		Kind:   KindRunway,
+		Title:  fmt.Sprintf("relayer on %s is %s", r.Chain, r.Level),
+		Text:   r.String(),
+		Fields: map[string]string{
+			"chain":   r.Chain,
+			"account": r.Account.Hex(),
+			"balance": decimal.FromUnit(r.Balance, 18),
+			"unlocks": fmt.Sprint(r.Unlocks),
+		},
+	}
+	switch r.Level {
+	case monitor.LevelCritical:
+		e.Severity = Critical
+	case monitor.LevelWarning:
+		e.Severity = Warning
+	default:
+		e.Title = fmt.Sprintf("relayer on %s recovered", r.Chain)
+	}
+	return e
+}
+
+// Paused returns event of bridge paused or unpaused, pause is critical
+func Paused(b config.Bridge, paused bool, l types.Log) Event {
+	e := Event{
+		Source:   SourceMonitor,
+		Kind:     KindUnpaused,
+		Severity: Warning,
+		Title:    fmt.Sprintf("%s unpaused", b),
+		Text:     fmt.Sprintf("%s was unpaused in block %d by tx %s", b, l.BlockNumber, l.TxHash.Hex()),
+	}
+	if paused {
+		e.Kind, e.Severity = KindPaused, Critical
+		e.Title = fmt.Sprintf("%s paused", b)
+		e.Text = fmt.Sprintf("%s was paused in block %d by tx %s", b, l.BlockNumber, l.TxHash.Hex())
+	}
+	e.Fields = map[string]string{
+		"bridge": b.Address.Hex(),
+		"chain":  b.Chain,
+		"tx":     l.TxHash.Hex(),
+	}
+	return e
+}
+
+// Unlock returns event of unlock correlation finding, orphan unlock releases funds
+// without deposit and is critical
+func Unlock(f monitor.Finding) Event {
+	e := Event{
+		Source:   SourceMonitor,
+		Kind:     KindMissing,
+		Severity: Warning,
+		Title:    fmt.Sprintf("missing unlock on %s", f.Bridge),
+		Text:     f.String(),
+		Fields: map[string]string{
+			"bridge":  f.Bridge.Address.Hex(),
+			"chain":   f.Bridge.Chain,
+			"lock":    f.Hash.Hex(),
+			"account": f.Account.Hex(),
+			"amount":  f.Amount.String(),
+			"tx":      f.Log.TxHash.Hex(),
+		},
+	}
+	if f.Kind == monitor.KindOrphanUnlock {
+		e.Kind, e.Severity = KindOrphan, Critical
+		e.Title = fmt.Sprintf("orphan unlock on %s", f.Bridge)
+	}
+	return e
+}
+
+// Mint returns critical event of wrapped token minted without unlock
+func Mint(m monitor.Mint) Event {
+	return Event{
+		Source:   SourceMonitor,
+		Kind:     KindMint,
+		Severity: Critical,
+		Title:    fmt.Sprintf("unauthorized mint on %s", m.Bridge),
+		Text:     m.String(),
+		Fields: map[string]string{
+			"bridge":  m.Bridge.Address.Hex(),
+			"chain":   m.Bridge.Chain,
+			"token":   m.Token.Hex(),
+			"account": m.Account.Hex(),
+			"amount":  m.Amount.String(),
+			"tx":      m.Log.TxHash.Hex(),
+		},
+	}
+}
+
+// Deficit returns critical event of minted supply exceeding locked reserve of pair,
+// a resolved deficit is info
+func Deficit(r reserve.Result) Event {
+	e := Event{
+		Source:   SourceMonitor,
+		Kind:     KindDeficit,
+		Severity: Info,
+		Title:    fmt.Sprintf("%s reserve deficit resolved", r.Pair.Name),
+		Text:     fmt.Sprintf("%s locked %s covers minted %s", r.Pair.Name, r.Locked, r.Minted),
+		Fields: map[string]string{
+			"pair":   r.Pair.Name,
+			"locked": r.Locked.String(),
+			"minted": r.Minted.String(),
+		},
+	}
+	if r.Deficit() {
+		e.Severity = Critical
+		e.Title = fmt.Sprintf("%s reserve deficit", r.Pair.Name)
+		e.Text = fmt.Sprintf("%s minted %s exceeds locked %s", r.Pair.Name, r.Minted, r.Locked)
+	}
+	return e
+}
+
+// Tripped returns critical event of breaker which paused every bridge
+func Tripped(reason string, results []emergency.Result) Event {
+	lines := make([]string, len(results))
+	failed := 0
+	for i, r := range results {
+		lines[i] = r.String()
+		if !r.OK() {
+			failed++
+		}
+	}
+	return Event{
+		Source:   SourceMonitor,
+		Kind:     KindBreaker,
+		Severity: Critical,
+		Title:    fmt.Sprintf("breaker paused %d of %d bridges", len(results)-failed, len(results)),
+		Text:     reason + "\n" + strings.Join(lines, "\n"),
+		Fields: map[string]string{
+			"reason": reason,
+			"failed": fmt.Sprint(failed),
+		},
+	}
+}
+
+func jobFields(b config.Bridge, job unlocker.Job) map[string]string {
+	return map[string]string{
+		"bridge":  b.Address.Hex(),
+		"chain":   b.Chain,
+		"lock":    job.Hash.Hex(),
+		"account": job.Account.Hex(),
+		"amount":  job.Amount.String(),
+	}
+}
+
+// Waiting returns event of unlock held until custody of bridge can pay it
+func Waiting(b config.Bridge, h unlocker.Held) Event {
+	return Event{
+		Source:   SourceRelayer,
+		Kind:     KindLiquidity,
+		Severity: Warning,
+		Title:    fmt.Sprintf("unlock on %s waits for liquidity", b),
+		Text:     fmt.Sprintf("unlock %s of %s needs %s but %s has %s", h.Job.Hash.Hex(), h.Job.Account.Hex(), h.Job.Amount, b, h.Available),
+		Fields:   jobFields(b, h.Job),
+	}
+}
+
+// Held returns event of unlock held while destination bridge is paused
+func Held(b config.Bridge, job unlocker.Job) Event {
+	return Event{
+		Source:   SourceRelayer,
+		Kind:     KindHeld,
+		Severity: Warning,
+		Title:    fmt.Sprintf("unlock held, %s is paused", b),
+		Text:     fmt.Sprintf("unlock %s of %s is held until %s is unpaused", job.Hash.Hex(), job.Account.Hex(), b),
+		Fields:   jobFields(b, job),
+	}
+}
+
+// Queued returns event of unlock queued for manual approval
+func Queued(r approval.Request) Event {
+	return Event{
+		Source:   SourceRelayer,
+		Kind:     KindApproval,
+		Severity: Warning,
+		Title:    fmt.Sprintf("%s unlock needs approval", r.Pair),
+		Text: fmt.Sprintf("unlock %s of %s to %s needs %d approvals",
+			r.Hash.Hex(), decimal.FromUnit(r.Amount, r.Decimals), r.Account.Hex(), r.Required),
+		Fields: map[string]string{
+			"pair":    r.Pair,
+			"lock":    r.Hash.Hex(),
+			"account": r.Account.Hex(),
+			"amount":  decimal.FromUnit(r.Amount, r.Decimals),
+		},
+	}
+}
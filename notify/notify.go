@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Severity of an event
+type Severity int
+
+// Severities in increasing order
+const (
+	Info Severity = iota
+	Warning
+	Critical
+)
+
+var severities = []string{"info", "warning", "critical"}
+
+// String returns severity name
+func (s Severity) String() string {
+	if s < Info || s > Critical {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return severities[s]
+}
+
+// MarshalText encodes severity as its name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity parses severity name, empty is info
+func ParseSeverity(s string) (Severity, error) {
+	if s == "" {
+		return Info, nil
+	}
+	for i, name := range severities {
+		if strings.EqualFold(name, s) {
+			return Severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("notify: unknown severity %q", s)
+}
+
+// Event is a monitor alert or relayer event
+type Event struct {
+	Time     time.Time `json:"time"`
+	Severity Severity  `json:"severity"`
+	// Source is component which raised event, e.g. monitor or relayer
+	Source string `json:"source"`
+	// Kind is event type used for routing, e.g. runway or paused
+	Kind   string            `json:"kind"`
+	Title  string            `json:"title"`
+	Text   string            `json:"text"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Notifier sends events to a sink
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Route sends events of minimum severity and one of kinds to notifier,
+// empty kinds match every kind
+type Route struct {
+	Severity Severity
+	Kinds    []string
+	Notifier Notifier
+}
+
+// Match reports whether route wants event
+func (r Route) Match(e Event) bool {
+	if e.Severity < r.Severity {
+		return false
+	}
+	if len(r.Kinds) == 0 {
+		return true
+	}
+	for _, k := range r.Kinds {
+		if k == e.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Router sends every event to all matching routes
+type Router struct {
+	Routes []Route
+}
+
+// Notify sends event to matching routes, a failing route does not stop others
+func (r *Router) Notify(ctx context.Context, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	var errs []string
+	for _, route := range r.Routes {
+		if !route.Match(e) {
+			continue
+		}
+		if err := route.Notifier.Notify(ctx, e); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("notify: %d notifiers failed; %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Default templates of event subject and text
+const (
+	DefaultSubject  = "[{{.Severity}}] {{.Title}}"
+	DefaultTemplate = "{{.Text}}{{range $k, $v := .Fields}}\n{{$k}}: {{$v}}{{end}}\n\n{{.Source}} {{.Kind}} at {{.Time.Format \"2006-01-02 15:04:05 MST\"}}"
+)
+
+// Template renders events with text/template, fields of Event are available
+type Template struct {
+	t *template.Template
+}
+
+// ParseTemplate parses template text, empty text uses fallback
+func ParseTemplate(text, fallback string) (*Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	t, err := template.New("event").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("notify: invalid template; %w", err)
+	}
+	return &Template{t}, nil
+}
+
+// MustTemplate is like ParseTemplate but panics on error, for constant templates
+func MustTemplate(text string) *Template {
+	t, err := ParseTemplate(text, "")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render renders event
+func (t *Template) Render(e Event) (string, error) {
+	var b bytes.Buffer
+	if err := t.t.Execute(&b, e); err != nil {
+		return "", fmt.Errorf("notify: can not render %s event; %w", e.Kind, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,197 @@
+package notify_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/monitor"
+	"killswitch/bridge/notify"
+)
+
+// smtpServer accepts a single mail and sends its data to mails
+func smtpServer(t *testing.T) (string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	mails := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 ok")
+				mails <- data.String()
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return l.Addr().String(), mails
+}
+
+func TestRoute(t *testing.T) {
+	r := notify.Route{Severity: notify.Warning, Kinds: []string{notify.KindPaused}}
+	require.True(t, r.Match(notify.Event{Severity: notify.Critical, Kind: notify.KindPaused}))
+	require.False(t, r.Match(notify.Event{Severity: notify.Info, Kind: notify.KindPaused}))
+	require.False(t, r.Match(notify.Event{Severity: notify.Critical, Kind: notify.KindRunway}))
+
+	s, err := notify.ParseSeverity("Critical")
+	require.NoError(t, err)
+	require.Equal(t, notify.Critical, s)
+	_, err = notify.ParseSeverity("fatal")
+	require.Error(t, err)
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+
+	hooks := make(chan map[string]interface{}, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&m))
+		hooks <- m
+	}))
+	defer hook.Close()
+	addr, mails := smtpServer(t)
+
+	router, err := notify.New([]config.Notifier{
+		{Type: config.NotifierWebhook, Severity: "warning", URL: hook.URL, Template: "{{.Severity}}: {{.Title}}"},
+		{Type: config.NotifierSMTP, Severity: "critical", SMTP: addr, From: "bridge@example.com", To: []string{"ops@example.com"}, Subject: "bridge {{.Severity}}: {{.Title}}"},
+	})
+	require.NoError(t, err)
+	var log bytes.Buffer
+	router.Routes = append(router.Routes, notify.Route{Notifier: &notify.Log{Out: &log}})
+
+	runway := monitor.Runway{Chain: "bkc", Account: common.Address{1}, Balance: new(big.Int), UnlockCost: big.NewInt(1), Level: monitor.LevelWarning}
+	require.NoError(t, router.Notify(ctx, notify.Runway(runway)))
+	m := <-hooks
+	require.Equal(t, "warning", m["severity"])
+	require.Equal(t, notify.KindRunway, m["kind"])
+	require.Equal(t, "warning: relayer on bkc is warning", m["message"])
+	select {
+	case <-mails:
+		t.Fatal("warning should not be emailed")
+	default:
+	}
+
+	runway.Level = monitor.LevelCritical
+	require.NoError(t, router.Notify(ctx, notify.Runway(runway)))
+	<-hooks
+	select {
+	case mail := <-mails:
+		require.Contains(t, mail, "Subject: bridge critical: relayer on bkc is critical\r\n")
+		require.Contains(t, mail, "To: ops@example.com\r\n")
+		require.Contains(t, mail, "account: "+common.Address{1}.Hex())
+	case <-time.After(10 * time.Second):
+		t.Fatal("no mail")
+	}
+
+	runway.Level = monitor.LevelOK
+	require.NoError(t, router.Notify(ctx, notify.Runway(runway)))
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[2], `"severity":"info"`)
+	require.Contains(t, lines[2], `"title":"relayer on bkc recovered"`)
+
+	t.Run("Failing", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer down.Close()
+		var log bytes.Buffer
+		router := &notify.Router{Routes: []notify.Route{
+			{Notifier: &notify.Webhook{URL: down.URL}},
+			{Notifier: &notify.Log{Out: &log}},
+		}}
+		err := router.Notify(ctx, notify.Event{Kind: "test", Title: "test"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "502")
+		require.NotEmpty(t, log.String())
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// accepts connections and never answers
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s := &notify.SMTP{
+		Addr:    l.Addr().String(),
+		From:    "bridge@example.com",
+		To:      []string{"ops@example.com"},
+		Subject: notify.MustTemplate(notify.DefaultSubject),
+		Body:    notify.MustTemplate(notify.DefaultTemplate),
+		Timeout: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	require.Error(t, s.Notify(ctx, notify.Event{Kind: "test", Title: "test"}))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	release := make(chan struct{})
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hang.Close()
+	defer close(release)
+	w := &notify.Webhook{URL: hang.URL, Timeout: 100 * time.Millisecond}
+	start = time.Now()
+	require.Error(t, w.Notify(ctx, notify.Event{Kind: "test", Title: "test"}))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+}
@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"killswitch/bridge/config"
+)
+
+// DefaultTimeout bounds a single send of webhook and SMTP notifiers
+const DefaultTimeout = 10 * time.Second
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d == 0 {
+		d = DefaultTimeout
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Webhook posts events as JSON, message is event rendered with template
+type Webhook struct {
+	URL string
+	// Template renders message, nil sends event text
+	Template *Template
+	// Client is http client, nil is http.DefaultClient
+	Client *http.Client
+	// Timeout bounds the request, default DefaultTimeout
+	Timeout time.Duration
+}
+
+type webhookPayload struct {
+	Event
+	Message string `json:"message"`
+}
+
+// Notify posts event
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	p := webhookPayload{Event: e, Message: e.Text}
+	if w.Template != nil {
+		var err error
+		if p.Message, err = w.Template.Render(e); err != nil {
+			return err
+		}
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, w.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("can not post %s event; %w", e.Kind, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("can not post %s event; %s", e.Kind, resp.Status)
+	}
+	return nil
+}
+
+// SMTP emails events as plain text
+type SMTP struct {
+	// Addr is SMTP server host:port
+	Addr string
+	// Auth is nil for servers without authentication
+	Auth    smtp.Auth
+	From    string
+	To      []string
+	Subject *Template
+	Body    *Template
+	// Timeout bounds dial and the whole SMTP conversation, default DefaultTimeout
+	Timeout time.Duration
+}
+
+// Notify sends email of event
+func (s *SMTP) Notify(ctx context.Context, e Event) error {
+	subject, err := s.Subject.Render(e)
+	if err != nil {
+		return err
+	}
+	body, err := s.Body.Render(e)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.ReplaceAll(subject, "\n", " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	ctx, cancel := withTimeout(ctx, s.Timeout)
+	defer cancel()
+	if err := s.send(ctx, msg.Bytes()); err != nil {
+		return fmt.Errorf("can not email %s event; %w", e.Kind, err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail bounded by deadline of ctx
+func (s *SMTP) send(ctx context.Context, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Log writes events as JSON lines
+type Log struct {
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+// Notify writes event
+func (l *Log) Notify(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.Out.Write(append(b, '\n'))
+	return err
+}
+
+// New returns router of configured notifiers, log notifiers write to stderr
+func New(notifiers []config.Notifier) (*Router, error) {
+	r := &Router{}
+	for _, n := range notifiers {
+		severity, err := ParseSeverity(n.Severity)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ParseTemplate(n.Template, DefaultTemplate)
+		if err != nil {
+			return nil, err
+		}
+
+		var notifier Notifier
+		switch n.Type {
+		case config.NotifierWebhook:
+			notifier = &Webhook{URL: n.URL, Template: body}
+		case config.NotifierSMTP:
+			subject, err := ParseTemplate(n.Subject, DefaultSubject)
+			if err != nil {
+				return nil, err
+			}
+			s := &SMTP{Addr: n.SMTP, From: n.From, To: n.To, Subject: subject, Body: body}
+			if n.Username != "" {
+				host := n.SMTP
+				if i := strings.LastIndex(host, ":"); i >= 0 {
+					host = host[:i]
+				}
+				s.Auth = smtp.PlainAuth("", n.Username, os.Getenv("NOTIFY_SMTP_PASSWORD"), host)
+			}
+			notifier = s
+		case config.NotifierLog:
+			notifier = &Log{Out: os.Stderr}
+		default:
+			return nil, fmt.Errorf("notify: unknown notifier type %q", n.Type)
+		}
+		r.Routes = append(r.Routes, Route{Severity: severity, Kinds: n.Kinds, Notifier: notifier})
+	}
+	return r, nil
+}
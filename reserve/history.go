@@ -0,0 +1,207 @@
+package reserve
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/backfill"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// Flow is sum of Locked and Unlocked events of a bridge
+type Flow struct {
+	Locks    int
+	Locked   *big.Int
+	Unlocks  int
+	Unlocked *big.Int
+}
+
+// History is reserve of a pair rebuilt from the full event history, compared with
+// on-chain custody and supply at the same blocks
+type History struct {
+	Pair config.Pair
+	// SourceBlock and DestinationBlock are last replayed blocks, on-chain values are read at them
+	SourceBlock      uint64
+	DestinationBlock uint64
+
+	Source      Flow
+	Destination Flow
+
+	// Custody is expected asset of source bridge, locked - unlocked on source
+	Custody *big.Int
+	// Supply is expected wrapped token, unlocked (minted) - locked (burned) on destination
+	Supply *big.Int
+
+	// Locked is on-chain balance of source bridge
+	Locked *big.Int
+	// Minted is on-chain total supply of wrapped token
+	Minted *big.Int
+}
+
+// CustodyDelta returns on-chain locked - custody, positive is asset sent to the bridge
+// without lock, negative is asset lost by the bridge e.g. by fee-on-transfer tokens
+func (h History) CustodyDelta() *big.Int {
+	return new(big.Int).Sub(h.Locked, h.Custody)
+}
+
+// SupplyDelta returns on-chain minted - supply, positive is wrapped token minted
+// outside of the bridge, negative is wrapped token burned outside of the bridge
+func (h History) SupplyDelta() *big.Int {
+	return new(big.Int).Sub(h.Minted, h.Supply)
+}
+
+// InFlight returns custody - supply, positive is locks not unlocked yet,
+// negative is unlocks of the destination bridge without lock
+func (h History) InFlight() *big.Int {
+	return new(big.Int).Sub(h.Custody, h.Supply)
+}
+
+// Consistent reports whether on-chain values match the history and nothing is in flight
+func (h History) Consistent() bool {
+	return h.Locked.Cmp(h.Custody) == 0 && h.Minted.Cmp(h.Supply) == 0 && h.Custody.Cmp(h.Supply) == 0
+}
+
+// Findings explains where custody, supply and on-chain values diverge,
+// amounts are formatted with decimals of the bridged asset
+func (h History) Findings(decimals uint8) []string {
+	unit := func(v *big.Int) string {
+		return decimal.FromUnit(v, decimals)
+	}
+	var f []string
+	switch d := h.CustodyDelta(); d.Sign() {
+	case 1:
+		f = append(f, fmt.Sprintf("%s holds %s more than locked by events, direct transfer to the bridge", h.Pair.Source, unit(d)))
+	case -1:
+		f = append(f, fmt.Sprintf("%s holds %s less than locked by events, fee-on-transfer loss or withdrawal without unlock", h.Pair.Source, unit(new(big.Int).Neg(d))))
+	}
+	switch d := h.SupplyDelta(); d.Sign() {
+	case 1:
+		f = append(f, fmt.Sprintf("total supply is %s more than minted by %s, mint outside the bridge", unit(d), h.Pair.Destination))
+	case -1:
+		f = append(f, fmt.Sprintf("total supply is %s less than minted by %s, burn outside the bridge", unit(new(big.Int).Neg(d)), h.Pair.Destination))
+	}
+	switch d := h.InFlight(); d.Sign() {
+	case 1:
+		f = append(f, fmt.Sprintf("%s locked is not unlocked yet", unit(d)))
+	case -1:
+		f = append(f, fmt.Sprintf("%s unlocked exceeds locked by %s", h.Pair.Destination, unit(new(big.Int).Neg(d))))
+	}
+	return f
+}
+
+// Replayer rebuilds reserves from Locked and Unlocked events, clients are keyed by chain name
+type Replayer struct {
+	Clients map[string]multiclient.Backend
+	// Start is first block to replay per chain, bridges have no events before deployment
+	Start map[string]uint64
+	// Chunk and Concurrency of log queries, see backfill.Backfiller
+	Chunk       uint64
+	Concurrency int
+}
+
+// Replay rebuilds reserve of pair up to block of each chain in at, missing chains replay to head
+func (r *Replayer) Replay(ctx context.Context, pair config.Pair, at map[string]uint64) (History, error) {
+	h := History{Pair: pair}
+
+	var err error
+	if h.SourceBlock, err = r.block(ctx, pair.Source.Chain, at); err != nil {
+		return History{}, err
+	}
+	if h.DestinationBlock, err = r.block(ctx, pair.Destination.Chain, at); err != nil {
+		return History{}, err
+	}
+	if h.Source, err = r.flow(ctx, pair.Source, h.SourceBlock); err != nil {
+		return History{}, err
+	}
+	if h.Destination, err = r.flow(ctx, pair.Destination, h.DestinationBlock); err != nil {
+		return History{}, err
+	}
+	h.Custody = new(big.Int).Sub(h.Source.Locked, h.Source.Unlocked)
+	h.Supply = new(big.Int).Sub(h.Destination.Unlocked, h.Destination.Locked)
+
+	source, destination := r.Clients[pair.Source.Chain], r.Clients[pair.Destination.Chain]
+	if h.Locked, err = Locked(ctx, pair, source, new(big.Int).SetUint64(h.SourceBlock)); err != nil {
+		return History{}, err
+	}
+	if h.Minted, err = Minted(ctx, pair, destination, new(big.Int).SetUint64(h.DestinationBlock)); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
+
+// ReplayAll rebuilds reserves of all pairs
+func (r *Replayer) ReplayAll(ctx context.Context, pairs []config.Pair, at map[string]uint64) ([]History, error) {
+	var h []History
+	for _, p := range pairs {
+		res, err := r.Replay(ctx, p, at)
+		if err != nil {
+			return h, err
+		}
+		h = append(h, res)
+	}
+	return h, nil
+}
+
+func (r *Replayer) block(ctx context.Context, chain string, at map[string]uint64) (uint64, error) {
+	if n, ok := at[chain]; ok {
+		return n, nil
+	}
+	client, ok := r.Clients[chain]
+	if !ok {
+		return 0, fmt.Errorf("unknown chain %s", chain)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("can not get head of %s; %w", chain, err)
+	}
+	return head.Number.Uint64(), nil
+}
+
+func (r *Replayer) flow(ctx context.Context, b config.Bridge, to uint64) (Flow, error) {
+	f := Flow{Locked: new(big.Int), Unlocked: new(big.Int)}
+	bf := &backfill.Backfiller{
+		Filterer:    r.Clients[b.Chain],
+		Query:       ethereum.FilterQuery{Addresses: []common.Address{b.Address}, Topics: [][]common.Hash{{unlocker.LockedTopic, unlocker.UnlockedTopic}}},
+		Chunk:       r.Chunk,
+		Concurrency: r.Concurrency,
+	}
+	filterer, _ := abi.NewIBridgeFilterer(b.Address, nil)
+
+	err := bf.Run(ctx, r.Start[b.Chain], to, func(from, to uint64, logs []types.Log) error {
+		for _, l := range logs {
+			if l.Removed {
+				continue
+			}
+			switch l.Topics[0] {
+			case unlocker.LockedTopic:
+				ev, err := filterer.ParseLocked(l)
+				if err != nil {
+					return fmt.Errorf("can not parse locked log; %w", err)
+				}
+				f.Locks++
+				f.Locked.Add(f.Locked, ev.Amount)
+			case unlocker.UnlockedTopic:
+				ev, err := filterer.ParseUnlocked(l)
+				if err != nil {
+					return fmt.Errorf("can not parse unlocked log; %w", err)
+				}
+				f.Unlocks++
+				f.Unlocked.Add(f.Unlocked, ev.Amount)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Flow{}, fmt.Errorf("can not replay %s; %w", b, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,298 @@
+package reserve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/signer"
+)
+
+// ErrSignature is returned when proof is not signed by its signer
+var ErrSignature = errors.New("reserve: invalid proof signature")
+
+// BlockAt returns last block of chain mined at or before t by binary search over headers
+func BlockAt(ctx context.Context, client multiclient.Backend, t time.Time) (uint64, error) {
+	at := t.Unix()
+	timeOf := func(n *big.Int) (int64, error) {
+		h, err := client.HeaderByNumber(ctx, n)
+		if err != nil {
+			return 0, fmt.Errorf("can not get header %v; %w", n, err)
+		}
+		return int64(h.Time), nil
+	}
+
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("can not get head; %w", err)
+	}
+	if int64(head.Time) <= at {
+		return head.Number.Uint64(), nil
+	}
+	genesis, err := timeOf(big.NewInt(0))
+	if err != nil {
+		return 0, err
+	}
+	if genesis > at {
+		return 0, fmt.Errorf("%s is before genesis", t.UTC().Format(time.RFC3339))
+	}
+
+	// time of lo <= at < time of hi
+	lo, hi := uint64(0), head.Number.Uint64()
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		ts, err := timeOf(new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if ts <= at {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// BlocksAt returns last block of every chain at or before t
+func BlocksAt(ctx context.Context, clients map[string]multiclient.Backend, t time.Time) (map[string]uint64, error) {
+	blocks := map[string]uint64{}
+	for chain, client := range clients {
+		n, err := BlockAt(ctx, client, t)
+		if err != nil {
+			return nil, fmt.Errorf("can not find block of %s; %w", chain, err)
+		}
+		blocks[chain] = n
+	}
+	return blocks, nil
+}
+
+// Anchor is block of a chain which proof is read at, hash identifies the block
+// so the proof can not be verified against a reorganized chain
+type Anchor struct {
+	Chain string      `json:"chain"`
+	Block uint64      `json:"block"`
+	Hash  common.Hash `json:"hash"`
+	Time  time.Time   `json:"time"`
+}
+
+// Statement is reserve of a pair in proof, amounts are in smallest unit
+type Statement struct {
+	Pair        string         `json:"pair"`
+	SourceChain string         `json:"sourceChain"`
+	Source      common.Address `json:"source"`
+	// SourceType selects how custody is read, ether balance or token balance
+	SourceType       string         `json:"sourceType"`
+	DestinationChain string         `json:"destinationChain"`
+	Destination      common.Address `json:"destination"`
+	Decimals         uint8          `json:"decimals"`
+	Locked           string         `json:"locked"`
+	Minted           string         `json:"minted"`
+	Valid            bool           `json:"valid"`
+}
+
+// Proof is proof of reserves of pairs at a block of every chain
+type Proof struct {
+	// At is requested time, nil when proof is requested at blocks
+	At      *time.Time     `json:"at,omitempty"`
+	Created time.Time      `json:"created"`
+	Chains  []Anchor       `json:"chains"`
+	Pairs   []Statement    `json:"pairs"`
+	Signer  common.Address `json:"signer"`
+}
+
+// Anchor returns anchor of chain
+func (p Proof) Anchor(chain string) (Anchor, bool) {
+	for _, a := range p.Chains {
+		if a.Chain == chain {
+			return a, true
+		}
+	}
+	return Anchor{}, false
+}
+
+// Valid reports whether every pair is fully backed at the anchors
+func (p Proof) Valid() bool {
+	for _, s := range p.Pairs {
+		if !s.Valid {
+			return false
+		}
+	}
+	return true
+}
+
+// Prove reads reserves of pairs at block of each chain in at, missing chains are read at head,
+// reading past state requires archive nodes
+func Prove(ctx context.Context, pairs []config.Pair, clients map[string]multiclient.Backend, at map[string]uint64) (Proof, error) {
+	p := Proof{Created: time.Now().UTC()}
+
+	anchors := map[string]Anchor{}
+	anchor := func(chain string) (Anchor, error) {
+		if a, ok := anchors[chain]; ok {
+			return a, nil
+		}
+		client, ok := clients[chain]
+		if !ok {
+			return Anchor{}, fmt.Errorf("unknown chain %s", chain)
+		}
+		var number *big.Int
+		if n, ok := at[chain]; ok {
+			number = new(big.Int).SetUint64(n)
+		}
+		h, err := client.HeaderByNumber(ctx, number)
+		if err != nil {
+			return Anchor{}, fmt.Errorf("can not get block of %s; %w", chain, err)
+		}
+		a := Anchor{Chain: chain, Block: h.Number.Uint64(), Hash: h.Hash(), Time: time.Unix(int64(h.Time), 0).UTC()}
+		anchors[chain] = a
+		p.Chains = append(p.Chains, a)
+		return a, nil
+	}
+
+	for _, pair := range pairs {
+		source, err := anchor(pair.Source.Chain)
+		if err != nil {
+			return Proof{}, err
+		}
+		destination, err := anchor(pair.Destination.Chain)
+		if err != nil {
+			return Proof{}, err
+		}
+		s, err := statement(ctx, pair, clients, source, destination)
+		if err != nil {
+			return Proof{}, err
+		}
+		p.Pairs = append(p.Pairs, s)
+	}
+
+	sort.Slice(p.Chains, func(i, j int) bool {
+		return p.Chains[i].Chain < p.Chains[j].Chain
+	})
+	return p, nil
+}
+
+func statement(ctx context.Context, pair config.Pair, clients map[string]multiclient.Backend, source, destination Anchor) (Statement, error) {
+	sourceClient, destinationClient := clients[pair.Source.Chain], clients[pair.Destination.Chain]
+
+	decimals, err := admin.NewBridge(pair.Source, sourceClient).Decimals(ctx)
+	if err != nil {
+		return Statement{}, err
+	}
+	locked, err := Locked(ctx, pair, sourceClient, new(big.Int).SetUint64(source.Block))
+	if err != nil {
+		return Statement{}, err
+	}
+	minted, err := Minted(ctx, pair, destinationClient, new(big.Int).SetUint64(destination.Block))
+	if err != nil {
+		return Statement{}, err
+	}
+
+	r := Result{Pair: pair, Locked: locked, Minted: minted}
+	return Statement{
+		Pair:             pair.Name,
+		SourceChain:      pair.Source.Chain,
+		Source:           pair.Source.Address,
+		SourceType:       pair.Source.Type,
+		DestinationChain: pair.Destination.Chain,
+		Destination:      pair.Destination.Address,
+		Decimals:         decimals,
+		Locked:           locked.String(),
+		Minted:           minted.String(),
+		Valid:            !r.Deficit(),
+	}, nil
+}
+
+// Sign sets signer of proof and returns JSON report and its detached signature
+func (p Proof) Sign(ctx context.Context, s signer.Signer) (report, sig []byte, err error) {
+	p.Signer = s.Address()
+
+	// pair names contain <=>, keep them readable
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(p); err != nil {
+		return nil, nil, err
+	}
+	report = b.Bytes()
+	if sig, err = s.SignText(ctx, report); err != nil {
+		return nil, nil, fmt.Errorf("can not sign proof; %w", err)
+	}
+	return report, sig, nil
+}
+
+// Open parses JSON report and checks it is signed by its signer
+func Open(report, sig []byte) (Proof, error) {
+	var p Proof
+	if err := json.Unmarshal(report, &p); err != nil {
+		return Proof{}, fmt.Errorf("can not parse proof; %w", err)
+	}
+	from, err := signer.RecoverText(report, sig)
+	if err != nil {
+		return Proof{}, fmt.Errorf("%w; %v", ErrSignature, err)
+	}
+	if from != p.Signer {
+		return Proof{}, fmt.Errorf("%w; signed by %s, expected %s", ErrSignature, from.Hex(), p.Signer.Hex())
+	}
+	return p, nil
+}
+
+// Verify reads proof again from chains and returns its differences, clients must serve
+// state at the anchor blocks, which usually requires archive nodes
+func Verify(ctx context.Context, p Proof, clients map[string]multiclient.Backend) ([]string, error) {
+	var diffs []string
+	for _, a := range p.Chains {
+		client, ok := clients[a.Chain]
+		if !ok {
+			return nil, fmt.Errorf("unknown chain %s", a.Chain)
+		}
+		h, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(a.Block))
+		if err != nil {
+			return nil, fmt.Errorf("can not get block %d of %s; %w", a.Block, a.Chain, err)
+		}
+		if h.Hash() != a.Hash {
+			diffs = append(diffs, fmt.Sprintf("%s block %d is %s, proof has %s", a.Chain, a.Block, h.Hash().Hex(), a.Hash.Hex()))
+		}
+	}
+
+	for _, s := range p.Pairs {
+		source, ok := p.Anchor(s.SourceChain)
+		if !ok {
+			return nil, fmt.Errorf("%s; no block of %s", s.Pair, s.SourceChain)
+		}
+		destination, ok := p.Anchor(s.DestinationChain)
+		if !ok {
+			return nil, fmt.Errorf("%s; no block of %s", s.Pair, s.DestinationChain)
+		}
+
+		pair := config.Pair{
+			Name:        s.Pair,
+			Source:      config.Bridge{Chain: s.SourceChain, Type: s.SourceType, Address: s.Source},
+			Destination: config.Bridge{Chain: s.DestinationChain, Type: config.TypeBurner, Address: s.Destination},
+		}
+		got, err := statement(ctx, pair, clients, source, destination)
+		if err != nil {
+			return nil, err
+		}
+		if got.Locked != s.Locked {
+			diffs = append(diffs, fmt.Sprintf("%s locked is %s, proof has %s", s.Pair, got.Locked, s.Locked))
+		}
+		if got.Minted != s.Minted {
+			diffs = append(diffs, fmt.Sprintf("%s minted is %s, proof has %s", s.Pair, got.Minted, s.Minted))
+		}
+		if got.Valid != s.Valid {
+			diffs = append(diffs, fmt.Sprintf("%s valid is %t, proof has %t", s.Pair, got.Valid, s.Valid))
+		}
+	}
+	return diffs, nil
+}
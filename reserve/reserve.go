@@ -0,0 +1,105 @@
+package reserve
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+)
+
+// Result is reserve reconciliation of a pair,
+// asset locked on source must equal wrapped token minted on destination
+type Result struct {
+	Pair   config.Pair
+	Locked *big.Int
+	Minted *big.Int
+}
+
+// Valid reports whether locked equals minted
+func (r Result) Valid() bool {
+	return r.Locked.Cmp(r.Minted) == 0
+}
+
+// Deficit reports whether minted exceeds locked, which means wrapped token is not fully backed
+func (r Result) Deficit() bool {
+	return r.Minted.Cmp(r.Locked) > 0
+}
+
+// Delta returns locked - minted
+func (r Result) Delta() *big.Int {
+	return new(big.Int).Sub(r.Locked, r.Minted)
+}
+
+// Locked returns asset held by source bridge of pair
+func Locked(ctx context.Context, pair config.Pair, source multiclient.Backend, block *big.Int) (*big.Int, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: block}
+
+	if pair.Source.Type == config.TypeEther {
+		v, err := source.BalanceAt(ctx, pair.Source.Address, block)
+		if err != nil {
+			return nil, fmt.Errorf("can not get balance of %s; %w", pair.Source, err)
+		}
+		return v, nil
+	}
+
+	locker, _ := abi.NewBridgeLocker(pair.Source.Address, source)
+	tokenAddr, err := locker.Token(opts)
+	if err != nil {
+		return nil, fmt.Errorf("can not get token of %s; %w", pair.Source, err)
+	}
+	token, _ := abi.NewIERC20(tokenAddr, source)
+	v, err := token.BalanceOf(opts, pair.Source.Address)
+	if err != nil {
+		return nil, fmt.Errorf("can not get locked balance of %s; %w", pair.Source, err)
+	}
+	return v, nil
+}
+
+// Minted returns total supply of wrapped token of destination bridge of pair
+func Minted(ctx context.Context, pair config.Pair, destination multiclient.Backend, block *big.Int) (*big.Int, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: block}
+
+	burner, _ := abi.NewBridgeBurner(pair.Destination.Address, destination)
+	tokenAddr, err := burner.Token(opts)
+	if err != nil {
+		return nil, fmt.Errorf("can not get token of %s; %w", pair.Destination, err)
+	}
+	token, _ := abi.NewWrappedToken(tokenAddr, destination)
+	v, err := token.TotalSupply(opts)
+	if err != nil {
+		return nil, fmt.Errorf("can not get total supply of %s; %w", pair.Destination, err)
+	}
+	return v, nil
+}
+
+// Check reconciles locked and minted of pair at latest block
+func Check(ctx context.Context, pair config.Pair, source, destination multiclient.Backend) (Result, error) {
+	locked, err := Locked(ctx, pair, source, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	minted, err := Minted(ctx, pair, destination, nil)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Pair: pair, Locked: locked, Minted: minted}, nil
+}
+
+// CheckAll reconciles all pairs, clients are keyed by chain name
+func CheckAll(ctx context.Context, pairs []config.Pair, clients map[string]multiclient.Backend) ([]Result, error) {
+	var r []Result
+	for _, p := range pairs {
+		res, err := Check(ctx, p, clients[p.Source.Chain], clients[p.Destination.Chain])
+		if err != nil {
+			return r, err
+		}
+		r = append(r, res)
+	}
+	return r, nil
+}
@@ -0,0 +1,275 @@
+package reserve_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/reserve"
+	"killswitch/bridge/signer"
+	"killswitch/bridge/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := testutil.Setup(t)
+
+	// addr0 => bridge and token owner
+	// addr1 => user
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	_, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+
+	_, err := token.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	_, err = wrapped.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = token.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	_, err = token.Approve(user.TxOpts, lockerAddr, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	_, err = locker.Lock(user.TxOpts, decimal.EtherToWei("3"))
+	require.NoError(t, err)
+	_, err = wrapped.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("3"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	clients := map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend}
+	pairs := []config.Pair{
+		{
+			Name:        "DAI <=> kDAI",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+		},
+		{
+			Name:        "BNB <=> kDAI",
+			Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr},
+			Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+		},
+	}
+
+	results, err := reserve.CheckAll(ctx, pairs, clients)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.True(t, results[0].Valid())
+	require.Equal(t, decimal.EtherToWei("3").String(), results[0].Locked.String())
+
+	require.False(t, results[1].Valid())
+	require.True(t, results[1].Deficit())
+	require.Equal(t, decimal.EtherToWei("-3").String(), results[1].Delta().String())
+
+	// lock ether to back minted supply
+	opts := *user.TxOpts
+	opts.Value = decimal.EtherToWei("3")
+	_, err = ether.Lock(&opts, decimal.EtherToWei("3"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	r, err := reserve.Check(ctx, pairs[1], ctx.Backend, ctx.Backend)
+	require.NoError(t, err)
+	require.True(t, r.Valid())
+}
+
+func TestReplay(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	send := func(tx interface{}, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+	send(token.AddMinter(owner.TxOpts, owner.Address))
+	send(wrapped.AddMinter(owner.TxOpts, burnerAddr))
+	send(token.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("10")))
+	send(token.Approve(user.TxOpts, lockerAddr, decimal.EtherToWei("10")))
+	send(wrapped.Approve(user.TxOpts, burnerAddr, decimal.EtherToWei("10")))
+
+	pair := config.Pair{
+		Name:        "DAI <=> kDAI",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr, Pair: "DAI <=> kDAI", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Pair: "DAI <=> kDAI", Side: config.SideDestination},
+	}
+	r := &reserve.Replayer{Clients: map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend}, Chunk: 2}
+
+	// 3 locked and minted, 1 burned and unlocked
+	send(locker.Lock(user.TxOpts, decimal.EtherToWei("3")))
+	send(burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("3"), common.Hash{1}))
+	send(burner.Lock(user.TxOpts, decimal.EtherToWei("1")))
+	send(locker.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), common.Hash{2}))
+
+	h, err := r.Replay(ctx, pair, nil)
+	require.NoError(t, err)
+	require.True(t, h.Consistent())
+	require.Equal(t, 1, h.Source.Locks)
+	require.Equal(t, 1, h.Source.Unlocks)
+	require.Equal(t, decimal.EtherToWei("2"), h.Custody)
+	require.Equal(t, decimal.EtherToWei("2"), h.Supply)
+	require.Empty(t, h.Findings(18))
+	before := h.SourceBlock
+
+	// direct transfer, lock in flight
+	send(token.Transfer(user.TxOpts, lockerAddr, decimal.EtherToWei("0.5")))
+	send(locker.Lock(user.TxOpts, decimal.EtherToWei("1")))
+
+	all, err := r.ReplayAll(ctx, []config.Pair{pair}, nil)
+	require.NoError(t, err)
+	require.False(t, all[0].Consistent())
+	require.Equal(t, decimal.EtherToWei("0.5"), all[0].CustodyDelta())
+	require.Zero(t, all[0].SupplyDelta().Sign())
+	require.Equal(t, decimal.EtherToWei("1"), all[0].InFlight())
+	require.Len(t, all[0].Findings(18), 2)
+	require.Contains(t, all[0].Findings(18)[0], "holds 0.5 more than locked by events, direct transfer")
+
+	// unauthorized mint
+	send(wrapped.AddMinter(owner.TxOpts, owner.Address))
+	send(wrapped.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("4")))
+	all, err = r.ReplayAll(ctx, []config.Pair{pair}, nil)
+	require.NoError(t, err)
+	require.Equal(t, decimal.EtherToWei("4"), all[0].SupplyDelta())
+	require.Contains(t, all[0].Findings(18)[1], "mint outside the bridge")
+
+	// simulated backend reads state at latest block only
+	header, err := ctx.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	head := header.Number.Uint64()
+	at, err := r.Replay(ctx, pair, map[string]uint64{"bsc": head, "bkc": head})
+	require.NoError(t, err)
+	require.Equal(t, head, at.DestinationBlock)
+	require.Greater(t, at.SourceBlock, before)
+	require.Equal(t, all[0].Supply, at.Supply)
+}
+
+func TestBlockAt(t *testing.T) {
+	ctx := testutil.Setup(t)
+
+	for i := 0; i < 9; i++ {
+		require.NoError(t, ctx.Backend.AdjustTime(time.Hour))
+		ctx.Backend.Commit()
+	}
+	header, err := ctx.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	head := header.Number.Uint64()
+
+	for n := uint64(1); n <= head; n++ {
+		h, err := ctx.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		require.NoError(t, err)
+		at := time.Unix(int64(h.Time), 0)
+
+		block, err := reserve.BlockAt(ctx, ctx.Backend, at)
+		require.NoError(t, err)
+		require.Equal(t, n, block)
+
+		block, err = reserve.BlockAt(ctx, ctx.Backend, at.Add(-time.Second))
+		require.NoError(t, err)
+		require.Equal(t, n-1, block)
+	}
+
+	block, err := reserve.BlockAt(ctx, ctx.Backend, time.Unix(int64(header.Time), 0).Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, head, block)
+
+	genesis, err := ctx.Backend.HeaderByNumber(ctx, big.NewInt(0))
+	require.NoError(t, err)
+	_, err = reserve.BlockAt(ctx, ctx.Backend, time.Unix(int64(genesis.Time), 0).Add(-time.Second))
+	require.Error(t, err)
+}
+
+func TestProof(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	_, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	_, err := wrapped.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	opts := *user.TxOpts
+	opts.Value = decimal.EtherToWei("2")
+	_, err = ether.Lock(&opts, decimal.EtherToWei("2"))
+	require.NoError(t, err)
+	_, err = wrapped.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("2"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr},
+	}
+	clients := map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend}
+
+	// simulated backend reads state at latest block only
+	header, err := ctx.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	at, err := reserve.BlocksAt(ctx, clients, time.Unix(int64(header.Time), 0))
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"bsc": header.Number.Uint64(), "bkc": header.Number.Uint64()}, at)
+
+	p, err := reserve.Prove(ctx, []config.Pair{pair}, clients, at)
+	require.NoError(t, err)
+	require.True(t, p.Valid())
+	require.Len(t, p.Chains, 2)
+	require.Equal(t, header.Hash(), p.Chains[0].Hash)
+	require.Equal(t, decimal.EtherToWei("2").String(), p.Pairs[0].Locked)
+
+	operator := signer.NewKey(ctx.Wallets[2].Key)
+	report, sig, err := p.Sign(ctx, operator)
+	require.NoError(t, err)
+	require.Contains(t, string(report), `"signer": "`+strings.ToLower(ctx.Wallets[2].Address.Hex())+`"`)
+	require.Contains(t, string(report), `"pair": "BNB <=> kBNB"`)
+
+	opened, err := reserve.Open(report, sig)
+	require.NoError(t, err)
+	diffs, err := reserve.Verify(ctx, opened, clients)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := strings.Replace(string(report), `"valid": true`, `"valid": false`, 1)
+		_, err := reserve.Open([]byte(tampered), sig)
+		require.ErrorIs(t, err, reserve.ErrSignature)
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		_, err = wrapped.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		// state moved on, a node without history no longer agrees with the proof
+		changed := opened
+		changed.Chains = append([]reserve.Anchor(nil), opened.Chains...)
+		for i := range changed.Chains {
+			changed.Chains[i].Block++
+		}
+		diffs, err := reserve.Verify(ctx, changed, clients)
+		require.NoError(t, err)
+		require.Len(t, diffs, 4)
+		require.Contains(t, diffs[2], "BNB <=> kBNB minted is "+decimal.EtherToWei("3").String())
+		require.Contains(t, diffs[3], "valid is false")
+	})
+}
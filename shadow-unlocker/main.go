@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// shadow-unlocker runs next to production unlocker without sending any transaction,
+// it simulates every unlock and reports the difference with production Unlocked events,
+// unlocks are simulated again at the block before production unlock which requires an archive destination rpc
+func main() {
+	var (
+		sourceRPC         = flag.String("source-rpc", "", "source chain rpc endpoints, comma separated")
+		sourceBridge      = flag.String("source-bridge", "", "source bridge address")
+		sourceStart       = flag.Uint64("source-start", 0, "source block to start from, default is head")
+		destinationRPC    = flag.String("destination-rpc", "", "destination chain rpc endpoints, comma separated")
+		destinationBridge = flag.String("destination-bridge", "", "destination bridge address")
+		destinationStart  = flag.Uint64("destination-start", 0, "destination block to start from, default is head")
+		from              = flag.String("from", "", "production unlocker account")
+		confirmations     = flag.Uint64("confirmations", 15, "source confirmations before simulate unlock")
+		interval          = flag.Duration("interval", 15*time.Second, "poll interval")
+		grace             = flag.Duration("grace", 10*time.Minute, "time to wait for production unlock")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	sourceClient, err := multiclient.Dial(ctx, strings.Split(*sourceRPC, ","))
+	if err != nil {
+		log.Fatalf("can not dial source; %v", err)
+	}
+	defer sourceClient.Close()
+
+	destinationClient, err := multiclient.Dial(ctx, strings.Split(*destinationRPC, ","))
+	if err != nil {
+		log.Fatalf("can not dial destination; %v", err)
+	}
+	defer destinationClient.Close()
+
+	shadow := &unlocker.Shadow{
+		Source:            common.HexToAddress(*sourceBridge),
+		SourceClient:      sourceClient,
+		Destination:       common.HexToAddress(*destinationBridge),
+		DestinationClient: destinationClient,
+		From:              common.HexToAddress(*from),
+		Grace:             *grace,
+	}
+
+	nextSource, nextDestination := *sourceStart, *destinationStart
+	for {
+		sourceHead, err := sourceClient.BlockNumber(ctx)
+		if err != nil {
+			log.Printf("can not get source head; %v", err)
+			time.Sleep(*interval)
+			continue
+		}
+		destinationHead, err := destinationClient.BlockNumber(ctx)
+		if err != nil {
+			log.Printf("can not get destination head; %v", err)
+			time.Sleep(*interval)
+			continue
+		}
+		if nextSource == 0 {
+			nextSource = sourceHead
+		}
+		if nextDestination == 0 {
+			nextDestination = destinationHead
+		}
+
+		var results []unlocker.Result
+		if to := sourceHead - *confirmations; sourceHead > *confirmations && to >= nextSource {
+			r, err := shadow.Locks(ctx, nextSource, to)
+			if err != nil {
+				log.Printf("can not process locks; %v", err)
+			} else {
+				results = append(results, r...)
+				nextSource = to + 1
+			}
+		}
+		if destinationHead >= nextDestination {
+			r, err := shadow.Unlocks(ctx, nextDestination, destinationHead)
+			if err != nil {
+				log.Printf("can not process unlocks; %v", err)
+			} else {
+				results = append(results, r...)
+				nextDestination = destinationHead + 1
+			}
+		}
+		results = append(results, shadow.Expired()...)
+
+		for _, r := range results {
+			r.Write(os.Stdout)
+		}
+
+		time.Sleep(*interval)
+	}
+}
@@ -0,0 +1,33 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKey creates signer from plain private key,
+// it is meant for tests and local development only
+func NewKey(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
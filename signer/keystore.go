@@ -0,0 +1,118 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Passphrase returns keystore passphrase,
+// it is called every time the key is decrypted
+type Passphrase func() (string, error)
+
+// PassphraseFile reads passphrase from file, trailing newline is ignored
+func PassphraseFile(path string) Passphrase {
+	return func() (string, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("can not read passphrase file; %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+}
+
+// PassphraseEnv reads passphrase from environment variable
+func PassphraseEnv(name string) Passphrase {
+	return func() (string, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("passphrase env %s is not set", name)
+		}
+		return v, nil
+	}
+}
+
+type keystoreSigner struct {
+	keyJSON    []byte
+	address    common.Address
+	passphrase Passphrase
+}
+
+// NewKeystore creates signer from go-ethereum encrypted key file,
+// the key is decrypted only while signing and wiped right after,
+// so it is never kept in memory as plaintext
+func NewKeystore(path string, passphrase Passphrase) (Signer, error) {
+	keyJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read keystore; %w", err)
+	}
+
+	var v struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyJSON, &v); err != nil {
+		return nil, fmt.Errorf("can not parse keystore; %w", err)
+	}
+
+	s := &keystoreSigner{
+		keyJSON:    keyJSON,
+		passphrase: passphrase,
+	}
+
+	// verify passphrase early, so misconfiguration fails at startup
+	key, err := s.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	s.address = key.Address
+	wipe(key)
+
+	if v.Address != "" && common.HexToAddress(v.Address) != s.address {
+		return nil, fmt.Errorf("keystore address mismatch; %s", v.Address)
+	}
+
+	return s, nil
+}
+
+func (s *keystoreSigner) decrypt() (*keystore.Key, error) {
+	pass, err := s.passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(s.keyJSON, pass)
+	if err != nil {
+		return nil, fmt.Errorf("can not decrypt keystore; %w", err)
+	}
+	return key, nil
+}
+
+func (s *keystoreSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *keystoreSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := s.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(key)
+
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key.PrivateKey)
+}
+
+// wipe zeroes private key in memory
+func wipe(key *keystore.Key) {
+	b := key.PrivateKey.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,90 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type remoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// signTxArgs is clef account_signTransaction argument
+type signTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+	ChainID  *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+type signTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// NewRemote creates signer which sends transactions to a clef compatible
+// json-rpc signer (account_signTransaction), the key never leaves the signer
+func NewRemote(ctx context.Context, url string, address common.Address) (Signer, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("can not dial remote signer; %w", err)
+	}
+
+	return &remoteSigner{
+		client:  client,
+		address: address,
+	}, nil
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := signTxArgs{
+		From:     s.address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+		ChainID:  (*hexutil.Big)(chainID),
+	}
+
+	var res signTxResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("can not sign with remote signer; %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("can not decode remote signed tx; %w", err)
+	}
+
+	// the signer must sign exactly what we asked for
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("remote signer modified tx")
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote signature; %w", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("remote signer signed with %s, expected %s", from.Hex(), s.address.Hex())
+	}
+
+	return signed, nil
+}
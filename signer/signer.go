@@ -0,0 +1,82 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Signer signs transactions and messages on behalf of an account
+type Signer interface {
+	// Address returns the signing account
+	Address() common.Address
+
+	// SignTx returns the signed copy of tx
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// SignText returns EIP-191 personal message signature of data, see RecoverText
+	SignText(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// Config selects and configures a signer
+type Config struct {
+	// Type is "keystore" or "remote"
+	Type string `yaml:"type"`
+
+	// Keystore is path to go-ethereum encrypted key file
+	Keystore string `yaml:"keystore"`
+	// PassphraseFile is path to file contains keystore passphrase
+	PassphraseFile string `yaml:"passphraseFile"`
+	// PassphraseEnv is name of environment variable contains keystore passphrase
+	PassphraseEnv string `yaml:"passphraseEnv"`
+
+	// URL is remote signer (clef) endpoint
+	URL string `yaml:"url"`
+	// Address is the remote signer account
+	Address string `yaml:"address"`
+}
+
+// New creates signer from config
+func New(ctx context.Context, cfg Config) (Signer, error) {
+	switch cfg.Type {
+	case "keystore":
+		var pass Passphrase
+		switch {
+		case cfg.PassphraseFile != "":
+			pass = PassphraseFile(cfg.PassphraseFile)
+		case cfg.PassphraseEnv != "":
+			pass = PassphraseEnv(cfg.PassphraseEnv)
+		default:
+			return nil, errors.New("signer: keystore requires passphraseFile or passphraseEnv")
+		}
+		return NewKeystore(cfg.Keystore, pass)
+	case "remote":
+		if !common.IsHexAddress(cfg.Address) {
+			return nil, fmt.Errorf("signer: invalid remote address %q", cfg.Address)
+		}
+		return NewRemote(ctx, cfg.URL, common.HexToAddress(cfg.Address))
+	default:
+		return nil, fmt.Errorf("signer: unknown type %q", cfg.Type)
+	}
+}
+
+// TransactOpts returns transact options which sign transaction with s
+func TransactOpts(ctx context.Context, s Signer, chainID *big.Int) *bind.TransactOpts {
+	from := s.Address()
+
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+	}
+}
@@ -0,0 +1,230 @@
+package signer_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/signer"
+	"killswitch/bridge/testutil"
+)
+
+var chainID = big.NewInt(1337)
+
+func writeKeystore(t *testing.T, wallet *testutil.Wallet, passphrase string) string {
+	t.Helper()
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    wallet.Address,
+		PrivateKey: wallet.Key,
+	}, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, ioutil.WriteFile(path, keyJSON, 0600))
+
+	return path
+}
+
+func TestKeystore(t *testing.T) {
+	ctx := testutil.Setup(t)
+	wallet := ctx.Wallets[0]
+
+	path := writeKeystore(t, wallet, "secret")
+
+	t.Run("Passphrase file", func(t *testing.T) {
+		passFile := filepath.Join(t.TempDir(), "pass")
+		require.NoError(t, ioutil.WriteFile(passFile, []byte("secret\n"), 0600))
+
+		s, err := signer.NewKeystore(path, signer.PassphraseFile(passFile))
+		require.NoError(t, err)
+		require.Equal(t, wallet.Address, s.Address())
+
+		_, _, token, err := abi.DeployWrappedToken(signer.TransactOpts(ctx, s, chainID), ctx.Backend, "kTest", "kTest", 18)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		owner, err := token.Owner(nil)
+		require.NoError(t, err)
+		require.Equal(t, wallet.Address, owner)
+	})
+
+	t.Run("Passphrase env", func(t *testing.T) {
+		os.Setenv("TEST_SIGNER_PASSPHRASE", "secret")
+		defer os.Unsetenv("TEST_SIGNER_PASSPHRASE")
+
+		s, err := signer.New(ctx, signer.Config{
+			Type:          "keystore",
+			Keystore:      path,
+			PassphraseEnv: "TEST_SIGNER_PASSPHRASE",
+		})
+		require.NoError(t, err)
+		require.Equal(t, wallet.Address, s.Address())
+	})
+
+	t.Run("Wrong passphrase", func(t *testing.T) {
+		os.Setenv("TEST_SIGNER_PASSPHRASE", "wrong")
+		defer os.Unsetenv("TEST_SIGNER_PASSPHRASE")
+
+		_, err := signer.NewKeystore(path, signer.PassphraseEnv("TEST_SIGNER_PASSPHRASE"))
+		require.Error(t, err)
+	})
+
+	t.Run("Missing passphrase", func(t *testing.T) {
+		_, err := signer.NewKeystore(path, signer.PassphraseEnv("TEST_SIGNER_PASSPHRASE_NOT_SET"))
+		require.Error(t, err)
+	})
+
+	t.Run("Not authorized", func(t *testing.T) {
+		s := signer.NewKey(wallet.Key)
+		opts := signer.TransactOpts(ctx, s, chainID)
+
+		_, err := opts.Signer(ctx.Wallets[1].Address, types.NewTransaction(0, common.Address{}, nil, 21000, nil, nil))
+		require.Error(t, err)
+	})
+}
+
+func TestRemote(t *testing.T) {
+	ctx := testutil.Setup(t)
+	wallet := ctx.Wallets[0]
+
+	newRemote := func(t *testing.T, key func() *testutil.Wallet, modify bool) signer.Signer {
+		srv := testutil.NewRPCServer(t)
+		srv.Handle("account_signTransaction", func(params []json.RawMessage) (interface{}, error) {
+			var args struct {
+				To       *common.Address `json:"to"`
+				Gas      hexutil.Uint64  `json:"gas"`
+				GasPrice *hexutil.Big    `json:"gasPrice"`
+				Value    *hexutil.Big    `json:"value"`
+				Nonce    hexutil.Uint64  `json:"nonce"`
+				Data     hexutil.Bytes   `json:"data"`
+				ChainID  *hexutil.Big    `json:"chainId"`
+			}
+			require.NoError(t, json.Unmarshal(params[0], &args))
+
+			if modify {
+				args.Value = (*hexutil.Big)(decimal.EtherToWei("1"))
+			}
+
+			var tx *types.Transaction
+			if args.To == nil {
+				tx = types.NewContractCreation(uint64(args.Nonce), args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), args.Data)
+			} else {
+				tx = types.NewTransaction(uint64(args.Nonce), *args.To, args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), args.Data)
+			}
+			signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), key().Key)
+			if err != nil {
+				return nil, err
+			}
+
+			raw, err := signed.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"raw": hexutil.Bytes(raw)}, nil
+		})
+		srv.Handle("account_signData", func(params []json.RawMessage) (interface{}, error) {
+			var data hexutil.Bytes
+			require.NoError(t, json.Unmarshal(params[2], &data))
+			if modify {
+				data = append(data, '!')
+			}
+			sig, err := crypto.Sign(accounts.TextHash(data), key().Key)
+			if err != nil {
+				return nil, err
+			}
+			sig[crypto.RecoveryIDOffset] += 27
+			return hexutil.Bytes(sig), nil
+		})
+
+		s, err := signer.New(ctx, signer.Config{
+			Type:    "remote",
+			URL:     srv.URL,
+			Address: wallet.Address.Hex(),
+		})
+		require.NoError(t, err)
+
+		return s
+	}
+
+	t.Run("Sign", func(t *testing.T) {
+		s := newRemote(t, func() *testutil.Wallet { return wallet }, false)
+		require.Equal(t, wallet.Address, s.Address())
+
+		_, _, token, err := abi.DeployWrappedToken(signer.TransactOpts(ctx, s, chainID), ctx.Backend, "kTest", "kTest", 18)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		owner, err := token.Owner(nil)
+		require.NoError(t, err)
+		require.Equal(t, wallet.Address, owner)
+	})
+
+	t.Run("Sign text", func(t *testing.T) {
+		s := newRemote(t, func() *testutil.Wallet { return wallet }, false)
+
+		sig, err := s.SignText(ctx, []byte("report"))
+		require.NoError(t, err)
+		from, err := signer.RecoverText([]byte("report"), sig)
+		require.NoError(t, err)
+		require.Equal(t, wallet.Address, from)
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		s := newRemote(t, func() *testutil.Wallet { return ctx.Wallets[1] }, false)
+
+		_, err := s.SignTx(ctx, types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), chainID)
+		require.Error(t, err)
+		_, err = s.SignText(ctx, []byte("report"))
+		require.Error(t, err)
+	})
+
+	t.Run("Modified tx", func(t *testing.T) {
+		s := newRemote(t, func() *testutil.Wallet { return wallet }, true)
+
+		_, err := s.SignTx(ctx, types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), chainID)
+		require.Error(t, err)
+		_, err = s.SignText(ctx, []byte("report"))
+		require.Error(t, err)
+	})
+}
+
+func TestKeySigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	s := signer.NewKey(key)
+	signed, err := s.SignTx(context.Background(), types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), chainID)
+	require.NoError(t, err)
+
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(t, err)
+	require.Equal(t, s.Address(), from)
+
+	sig, err := s.SignText(context.Background(), []byte("report"))
+	require.NoError(t, err)
+	require.Contains(t, []byte{27, 28}, sig[64])
+	from, err = signer.RecoverText([]byte("report"), sig)
+	require.NoError(t, err)
+	require.Equal(t, s.Address(), from)
+
+	from, err = signer.RecoverText([]byte("report!"), sig)
+	require.NoError(t, err)
+	require.NotEqual(t, s.Address(), from)
+}
@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signText signs EIP-191 personal message hash of data, v of signature is 27 or 28
+func signText(data []byte, sign func(hash []byte) ([]byte, error)) ([]byte, error) {
+	sig, err := sign(accounts.TextHash(data))
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// RecoverText returns the account which signed data with SignText
+func RecoverText(data, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(data), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature; %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func (s *keySigner) SignText(_ context.Context, data []byte) ([]byte, error) {
+	return signText(data, func(hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, s.key)
+	})
+}
+
+func (s *keystoreSigner) SignText(_ context.Context, data []byte) ([]byte, error) {
+	key, err := s.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(key)
+
+	return signText(data, func(hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key.PrivateKey)
+	})
+}
+
+func (s *remoteSigner) SignText(ctx context.Context, data []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.client.CallContext(ctx, &sig, "account_signData", accounts.MimetypeTextPlain, s.address, hexutil.Bytes(data)); err != nil {
+		return nil, fmt.Errorf("can not sign with remote signer; %w", err)
+	}
+
+	from, err := RecoverText(data, sig)
+	if err != nil {
+		return nil, errors.New("invalid remote signature")
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("remote signer signed with %s, expected %s", from.Hex(), s.address.Hex())
+	}
+	return sig, nil
+}
@@ -0,0 +1,448 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/admin"
+	"killswitch/bridge/api"
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/emergency"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/unlocker"
+)
+
+// Button actions
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+	ActionPause   = "pause"
+	ActionUnpause = "unpause"
+)
+
+const usage = "usage: `transfer <lock tx hash>` | `bridges` | `approvals` | `approve <lock tx hash>` | " +
+	"`reject <lock tx hash> <reason>` | `pause <bridge>` | `unpause <bridge>`, " +
+	"<bridge> is address or `<pair name>:<source|destination>`"
+
+// Commands serves Slack slash commands and button actions of on-call operators,
+// every request must be signed with the app signing secret
+type Commands struct {
+	// SigningSecret is the app signing secret, every request is refused when empty
+	SigningSecret string
+	Config        *config.Config
+	Clients       map[string]multiclient.Backend
+
+	// DB is indexed transfer history, nil disables transfer
+	DB    *indexer.DB
+	Heads api.Heads
+	// Bridges returns paused and limiter state of bridges, nil disables bridges
+	Bridges func() []unlocker.BridgeState
+	// NearCap is ratio of daily limit flagged as near cap, 0.9 by default
+	NearCap float64
+	// Approvals is approval queue of large unlocks, nil disables approvals
+	Approvals *approval.Store
+	// Opts signs pause and unpause transactions, nil disables them
+	Opts emergency.TransactOpts
+	// Operators are Slack user ids allowed to pause and unpause,
+	// approve and reject are allowed to configured approvers
+	Operators []string
+
+	// Client posts action results to response url, nil is http.DefaultClient
+	Client *http.Client
+	Log    func(format string, v ...interface{})
+
+	now func() time.Time
+}
+
+// Handler returns http handler of Slack requests:
+//
+//	POST /commands  slash command
+//	POST /actions   interactivity request of buttons
+func (s *Commands) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/commands", s.command)
+	mux.HandleFunc("/actions", s.action)
+	return mux
+}
+
+func (s *Commands) verified(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if s.SigningSecret == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	if err := Verify(s.SigningSecret, r.Header, body, now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return form, true
+}
+
+func writeMessage(w http.ResponseWriter, m Message) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+func text(s string) Message {
+	return Message{Text: s, Blocks: []Block{Section(s)}}
+}
+
+// past returns past tense of action
+func past(action string) string {
+	if strings.HasSuffix(action, "e") {
+		return action + "d"
+	}
+	return action + "ed"
+}
+
+func (s *Commands) operator(user string) bool {
+	for _, id := range s.Operators {
+		if id == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Commands) command(w http.ResponseWriter, r *http.Request) {
+	form, ok := s.verified(w, r)
+	if !ok {
+		return
+	}
+
+	// Slack escapes &, < and > of command text
+	args := strings.TrimSpace(html.UnescapeString(form.Get("text")))
+	name, arg := args, ""
+	if i := strings.IndexByte(args, ' '); i >= 0 {
+		name, arg = args[:i], strings.TrimSpace(args[i+1:])
+	}
+	user := form.Get("user_id")
+
+	ctx := r.Context()
+	var m Message
+	switch name {
+	case "transfer":
+		m = s.transfer(ctx, arg)
+	case "bridges":
+		m = s.bridges(ctx)
+	case "approvals":
+		m = s.approvals(ctx)
+	case ActionApprove, ActionReject:
+		hash, reason := arg, ""
+		if i := strings.IndexByte(arg, ' '); i >= 0 {
+			hash, reason = arg[:i], strings.TrimSpace(arg[i+1:])
+		}
+		m = s.decide(ctx, name, hash, user, reason)
+	case ActionPause, ActionUnpause:
+		if !s.operator(user) {
+			m = text("only operators can " + name)
+			break
+		}
+		m = s.confirmPause(name, arg)
+	default:
+		m = text(usage)
+	}
+	writeMessage(w, m)
+}
+
+type interaction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// action acknowledges button click and posts its result to response url,
+// pause and unpause wait until transaction is mined
+func (s *Commands) action(w http.ResponseWriter, r *http.Request) {
+	form, ok := s.verified(w, r)
+	if !ok {
+		return
+	}
+
+	var in interaction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &in); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if in.Type != "block_actions" || len(in.Actions) != 1 {
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		a := in.Actions[0]
+		var m Message
+		switch {
+		case a.ActionID == ActionApprove || a.ActionID == ActionReject:
+			m = s.decide(ctx, a.ActionID, a.Value, in.User.ID, "rejected in Slack by "+in.User.Username)
+		case !s.operator(in.User.ID):
+			m = text("only operators can " + a.ActionID)
+		case a.ActionID == ActionPause || a.ActionID == ActionUnpause:
+			m = s.pause(ctx, a.ActionID, a.Value, in.User.ID)
+		default:
+			m = text("unknown action " + a.ActionID)
+		}
+		m.ReplaceOriginal = true
+		if err := Post(ctx, s.Client, in.ResponseURL, m); err != nil && s.Log != nil {
+			s.Log("can not respond to slack action %s; %v", a.ActionID, err)
+		}
+	}()
+}
+
+// amount returns v in token unit of bridge asset, smallest unit when decimals are unknown
+func (s *Commands) amount(ctx context.Context, b config.Bridge, v *big.Int) string {
+	decimals, err := admin.NewBridge(b, s.Clients[b.Chain]).Decimals(ctx)
+	if err != nil {
+		return v.String()
+	}
+	return decimal.FromUnit(v, decimals)
+}
+
+func (s *Commands) transfer(ctx context.Context, arg string) Message {
+	if s.DB == nil {
+		return text("transfer history is not configured")
+	}
+	if len(arg) != 66 || !strings.HasPrefix(arg, "0x") {
+		return text("invalid lock tx hash `" + arg + "`")
+	}
+
+	t, err := s.DB.Transfer(ctx, common.HexToHash(arg))
+	if errors.Is(err, indexer.ErrNotFound) {
+		return text("transfer `" + arg + "` not found")
+	}
+	if err != nil {
+		return text("can not get transfer; " + err.Error())
+	}
+	head, err := s.Heads(ctx, t.Lock.Chain)
+	if err != nil {
+		return text("can not get head of " + t.Lock.Chain + "; " + err.Error())
+	}
+	resp := api.NewTransfer(t, s.Config.Chains, head)
+
+	pair, _ := s.Config.Pair(t.Lock.Pair)
+	m := Message{Text: fmt.Sprintf("%s %s", resp.Status, resp.Hash)}
+	m.Blocks = append(m.Blocks,
+		Section(fmt.Sprintf("*%s* `%s`", resp.Status, resp.Hash)),
+		Fields(
+			"*Pair*\n"+resp.Pair,
+			"*Account*\n`"+resp.Account+"`",
+			"*Amount*\n"+s.amount(ctx, pair.Source, t.Lock.Amount),
+			fmt.Sprintf("*Confirmations*\n%d of %d", resp.Confirmations, resp.Required),
+		),
+		Context(fmt.Sprintf("lock <%s|%s>", resp.Source.TxURL, resp.Source.Chain)),
+	)
+	if d := resp.Destination; d != nil {
+		m.Blocks = append(m.Blocks, Context(fmt.Sprintf("unlock <%s|%s> after %.0fs", d.TxURL, d.Chain, resp.Latency)))
+	}
+	return m
+}
+
+func (s *Commands) bridges(ctx context.Context) Message {
+	if s.Bridges == nil {
+		return text("bridge state is not configured")
+	}
+
+	ratio := s.NearCap
+	if ratio == 0 {
+		ratio = 0.9
+	}
+	m := Message{Text: "bridges"}
+	for _, st := range s.Bridges() {
+		state := "active"
+		if st.Paused {
+			state = ":double_vertical_bar: *paused*"
+		}
+		limit := "no limiter"
+		if st.Limit != nil {
+			max := "unlimited"
+			if st.Limit.Sign() > 0 {
+				max = s.amount(ctx, st.Bridge, st.Limit)
+			}
+			limit = fmt.Sprintf("daily usage %s of %s", s.amount(ctx, st.Bridge, st.Usage), max)
+			if st.NearCap(ratio) {
+				limit += " :warning:"
+			}
+		}
+		m.Blocks = append(m.Blocks, Section(fmt.Sprintf("*%s* %s on %s `%s`\n%s, %s at block %d",
+			st.Bridge.Pair, st.Bridge.Side, st.Bridge.Chain, st.Bridge.Address.Hex(), state, limit, st.Block)))
+	}
+	if len(m.Blocks) == 0 {
+		return text("no bridge state yet")
+	}
+	return m
+}
+
+func (s *Commands) approvals(ctx context.Context) Message {
+	if s.Approvals == nil {
+		return text("approval queue is not configured")
+	}
+
+	pending, err := s.Approvals.Pending(ctx)
+	if err != nil {
+		return text("can not get approvals; " + err.Error())
+	}
+	if len(pending) == 0 {
+		return text("no unlock waits for approval")
+	}
+
+	m := Message{Text: fmt.Sprintf("%d unlocks wait for approval", len(pending))}
+	for _, r := range pending {
+		m.Blocks = append(m.Blocks,
+			Section(requestText(r)),
+			Actions(
+				Button("Approve", ActionApprove, r.Hash.Hex(), "primary"),
+				Button("Reject", ActionReject, r.Hash.Hex(), "danger"),
+			),
+		)
+	}
+	return m
+}
+
+func requestText(r approval.Request) string {
+	return fmt.Sprintf("*%s* %s to `%s`\nlock `%s`, %s, %d of %d approvals",
+		r.Pair, decimal.FromUnit(r.Amount, r.Decimals), r.Account.Hex(), r.Hash.Hex(), r.Status, len(r.Approvers), r.Required)
+}
+
+// decide approves or rejects queued unlock as configured approver of Slack user
+func (s *Commands) decide(ctx context.Context, action, hash, user, reason string) Message {
+	if s.Approvals == nil {
+		return text("approval queue is not configured")
+	}
+	approver, ok := s.Config.ApproverBySlack(user)
+	if !ok {
+		return text("only approvers can " + action)
+	}
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return text("invalid lock tx hash `" + hash + "`")
+	}
+	if action == ActionReject && reason == "" {
+		return text("reject needs a reason")
+	}
+
+	var (
+		r   approval.Request
+		err error
+	)
+	if action == ActionApprove {
+		r, err = s.Approvals.Approve(ctx, common.HexToHash(hash), approver.Name, approval.ViaSlack)
+	} else {
+		r, err = s.Approvals.Reject(ctx, common.HexToHash(hash), approver.Name, approval.ViaSlack, reason)
+	}
+	if err != nil {
+		return text(fmt.Sprintf("can not %s `%s`; %v", action, hash, err))
+	}
+	return Message{
+		ResponseType: "in_channel",
+		Text:         fmt.Sprintf("<@%s> %s %s", user, past(action), hash),
+		Blocks:       []Block{Section(requestText(r)), Context(fmt.Sprintf("%s by <@%s>", past(action), user))},
+	}
+}
+
+// confirmPause asks for second confirmation with a button showing a confirmation dialog
+func (s *Commands) confirmPause(action, arg string) Message {
+	if s.Opts == nil {
+		return text(action + " is not configured")
+	}
+	b, err := s.Config.FindBridge(arg)
+	if err != nil {
+		return text(err.Error())
+	}
+
+	button := Button(strings.Title(action)+" "+b.Pair+" "+b.Side, action, b.Address.Hex(), "danger")
+	button.Confirm = &Confirm{
+		Title:   plain("Confirm " + action),
+		Text:    plain(fmt.Sprintf("%s %s?", action, b)),
+		Confirm: plain(strings.Title(action)),
+		Deny:    plain("Cancel"),
+		Style:   "danger",
+	}
+	return Message{
+		Text:   fmt.Sprintf("%s %s?", action, b),
+		Blocks: []Block{Section(fmt.Sprintf("%s *%s* %s on %s `%s`?", action, b.Pair, b.Side, b.Chain, b.Address.Hex())), Actions(button)},
+	}
+}
+
+// pause pauses or unpauses bridge and waits until transaction is mined
+func (s *Commands) pause(ctx context.Context, action, addr, user string) Message {
+	if s.Opts == nil {
+		return text(action + " is not configured")
+	}
+	cfg, err := s.Config.FindBridge(addr)
+	if err != nil {
+		return text(err.Error())
+	}
+
+	b := admin.NewBridge(cfg, s.Clients[cfg.Chain])
+	var change admin.Change
+	if action == ActionPause {
+		change, err = b.Pause(ctx)
+	} else {
+		change, err = b.Unpause(ctx)
+	}
+	if errors.Is(err, admin.ErrNoChange) {
+		return text(fmt.Sprintf("%s is already %s", cfg, past(action)))
+	}
+	if err != nil {
+		return text(err.Error())
+	}
+
+	opts, err := s.Opts(ctx, cfg.Chain)
+	if err != nil {
+		return text(err.Error())
+	}
+	tx, _, err := change.Execute(ctx, opts, b.Backend)
+	if err != nil {
+		return text(fmt.Sprintf("can not %s %s; %v", action, cfg, err))
+	}
+
+	link := s.Config.Chains[cfg.Chain].TxURL(tx.Hash())
+	return Message{
+		ResponseType: "in_channel",
+		Text:         fmt.Sprintf("<@%s> %s %s", user, past(action), cfg),
+		Blocks:       []Block{Section(fmt.Sprintf("<@%s> %s *%s* %s on %s, tx <%s|%s>", user, past(action), cfg.Pair, cfg.Side, cfg.Chain, link, tx.Hash().Hex()))},
+	}
+}
@@ -0,0 +1,210 @@
+package slack_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/approval"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/slack"
+	"killswitch/bridge/testutil"
+	"killswitch/bridge/unlocker"
+)
+
+const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func TestVerify(t *testing.T) {
+	body := []byte("token=x&command=%2Fbridge&text=bridges")
+	now := time.Unix(1531420618, 0)
+	h := http.Header{}
+	h.Set(slack.HeaderTimestamp, "1531420618")
+	h.Set(slack.HeaderSignature, slack.Sign(secret, now.Unix(), body))
+
+	require.NoError(t, slack.Verify(secret, h, body, now.Add(time.Minute)))
+	require.ErrorIs(t, slack.Verify(secret, h, body, now.Add(10*time.Minute)), slack.ErrExpired)
+	require.ErrorIs(t, slack.Verify("other", h, body, now), slack.ErrSignature)
+	require.ErrorIs(t, slack.Verify(secret, h, append(body, '1'), now), slack.ErrSignature)
+}
+
+func TestCommands(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	_, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	pair := config.Pair{
+		Name:        "BNB <=> kBNB",
+		Source:      config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Pair: "BNB <=> kBNB", Side: config.SideSource},
+		Destination: config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: common.Address{1}, Pair: "BNB <=> kBNB", Side: config.SideDestination},
+	}
+	cfg := &config.Config{
+		Chains: map[string]config.Chain{"bsc": {Name: "bsc", Explorer: "https://bscscan.com"}, "bkc": {Name: "bkc"}},
+		Pairs:  []config.Pair{pair},
+		// U1 approves, U2 is not an approver
+		Approvers: []config.Approver{{Name: "alice", Slack: "U1"}},
+	}
+
+	store, err := approval.OpenStore(filepath.Join(t.TempDir(), "approvals.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	hash := common.Hash{0xab}
+	_, _, err = store.Request(ctx, approval.Request{Hash: hash, Pair: pair.Name, Account: user.Address, Amount: decimal.EtherToWei("1500"), Decimals: 18, Required: 1})
+	require.NoError(t, err)
+
+	s := &slack.Commands{
+		SigningSecret: secret,
+		Config:        cfg,
+		Clients:       map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend},
+		Bridges: func() []unlocker.BridgeState {
+			return []unlocker.BridgeState{{Bridge: pair.Source, Paused: true, Usage: decimal.EtherToWei("95"), Limit: decimal.EtherToWei("100"), Block: 7}}
+		},
+		Approvals: store,
+		Opts: func(ctx context.Context, chain string) (*bind.TransactOpts, error) {
+			return owner.TxOpts, nil
+		},
+		Operators: []string{"U1"},
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	responses := make(chan slack.Message, 1)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m slack.Message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&m))
+		responses <- m
+	}))
+	defer responder.Close()
+
+	post := func(path string, form url.Values, sign bool) *http.Response {
+		t.Helper()
+		body := form.Encode()
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ts := time.Now().Unix()
+		req.Header.Set(slack.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		if sign {
+			req.Header.Set(slack.HeaderSignature, slack.Sign(secret, ts, []byte(body)))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	command := func(user, text string) slack.Message {
+		t.Helper()
+		resp := post("/commands", url.Values{"command": {"/bridge"}, "text": {text}, "user_id": {user}}, true)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var m slack.Message
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+		return m
+	}
+	click := func(user, action, value string) slack.Message {
+		t.Helper()
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":         "block_actions",
+			"user":         map[string]string{"id": user, "username": "alice"},
+			"response_url": responder.URL,
+			"actions":      []map[string]string{{"action_id": action, "value": value}},
+		})
+		resp := post("/actions", url.Values{"payload": {string(payload)}}, true)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		select {
+		case m := <-responses:
+			return m
+		case <-time.After(10 * time.Second):
+			t.Fatal("no action response")
+			return slack.Message{}
+		}
+	}
+	blocks := func(m slack.Message) string {
+		b, _ := json.Marshal(m.Blocks)
+		return string(b)
+	}
+
+	t.Run("Unsigned", func(t *testing.T) {
+		resp := post("/commands", url.Values{"text": {"bridges"}}, false)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("NoSecret", func(t *testing.T) {
+		// signed with empty key of an unconfigured secret
+		open := httptest.NewServer((&slack.Commands{Config: cfg}).Handler())
+		defer open.Close()
+
+		body := url.Values{"text": {"bridges"}, "user_id": {"U1"}}.Encode()
+		req, err := http.NewRequest(http.MethodPost, open.URL+"/commands", strings.NewReader(body))
+		require.NoError(t, err)
+		ts := time.Now().Unix()
+		req.Header.Set(slack.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(slack.HeaderSignature, slack.Sign("", ts, []byte(body)))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Bridges", func(t *testing.T) {
+		m := command("U2", "bridges")
+		require.Contains(t, blocks(m), "*paused*")
+		require.Contains(t, blocks(m), "daily usage 95 of 100 :warning:")
+	})
+
+	t.Run("Approvals", func(t *testing.T) {
+		m := command("U2", "approvals")
+		require.Contains(t, blocks(m), "1500 to `"+user.Address.Hex()+"`")
+		require.Contains(t, blocks(m), `"action_id":"approve"`)
+		require.Contains(t, blocks(m), `"value":"`+hash.Hex()+`"`)
+
+		m = command("U2", "approve "+hash.Hex())
+		require.Equal(t, "only approvers can approve", m.Text)
+
+		m = click("U1", slack.ActionApprove, hash.Hex())
+		require.True(t, m.ReplaceOriginal)
+		require.Equal(t, "<@U1> approved "+hash.Hex(), m.Text)
+
+		r, err := store.Get(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, approval.StatusApproved, r.Status)
+		log, err := store.Log(ctx, &hash, 10)
+		require.NoError(t, err)
+		require.Equal(t, approval.ViaSlack, log[1].Via)
+		require.Equal(t, "alice", log[1].Actor)
+	})
+
+	t.Run("Pause", func(t *testing.T) {
+		testutil.AutoCommit(t, ctx)
+
+		// bridge name is escaped by Slack
+		m := command("U1", "pause BNB &lt;=&gt; kBNB:source")
+		require.Contains(t, blocks(m), `"action_id":"pause"`)
+		require.Contains(t, blocks(m), `"value":"`+etherAddr.Hex()+`"`)
+		require.Contains(t, blocks(m), `"title":{"text":"Confirm pause"`)
+
+		m = click("U2", slack.ActionPause, etherAddr.Hex())
+		require.Equal(t, "only operators can pause", m.Text)
+
+		m = click("U1", slack.ActionPause, etherAddr.Hex())
+		require.Contains(t, m.Text, "<@U1> paused")
+		require.Contains(t, blocks(m), "https://bscscan.com/tx/")
+
+		m = click("U1", slack.ActionPause, etherAddr.Hex())
+		require.Contains(t, m.Text, "is already paused")
+	})
+}
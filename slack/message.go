@@ -0,0 +1,97 @@
+package slack
+
+// Message is a Slack message or response of a command or action
+type Message struct {
+	// ResponseType is "ephemeral" (default) or "in_channel"
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+// Block is a Slack layout block
+type Block struct {
+	Type   string `json:"type"`
+	Text   *Text  `json:"text,omitempty"`
+	Fields []Text `json:"fields,omitempty"`
+	// Elements are buttons of actions block or texts of context block
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+// Text is a text object, Type is "mrkdwn" or "plain_text"
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Element is an interactive element of actions block
+type Element struct {
+	Type     string   `json:"type"`
+	Text     *Text    `json:"text,omitempty"`
+	ActionID string   `json:"action_id,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Style    string   `json:"style,omitempty"`
+	Confirm  *Confirm `json:"confirm,omitempty"`
+}
+
+// Confirm is a confirmation dialog shown before a button action is sent
+type Confirm struct {
+	Title   Text   `json:"title"`
+	Text    Text   `json:"text"`
+	Confirm Text   `json:"confirm"`
+	Deny    Text   `json:"deny"`
+	Style   string `json:"style,omitempty"`
+}
+
+func plain(s string) Text {
+	return Text{Type: "plain_text", Text: s}
+}
+
+func mrkdwn(s string) *Text {
+	return &Text{Type: "mrkdwn", Text: s}
+}
+
+// Header returns header block
+func Header(s string) Block {
+	t := plain(s)
+	return Block{Type: "header", Text: &t}
+}
+
+// Section returns markdown section block
+func Section(s string) Block {
+	return Block{Type: "section", Text: mrkdwn(s)}
+}
+
+// Fields returns section block of markdown fields in two columns
+func Fields(fields ...string) Block {
+	b := Block{Type: "section"}
+	for _, f := range fields {
+		b.Fields = append(b.Fields, *mrkdwn(f))
+	}
+	return b
+}
+
+// Context returns context block of small markdown text
+func Context(s string) Block {
+	return Block{Type: "context", Elements: []interface{}{mrkdwn(s)}}
+}
+
+// Divider returns divider block
+func Divider() Block {
+	return Block{Type: "divider"}
+}
+
+// Actions returns actions block of buttons
+func Actions(buttons ...Element) Block {
+	b := Block{Type: "actions"}
+	for _, e := range buttons {
+		b.Elements = append(b.Elements, e)
+	}
+	return b
+}
+
+// Button returns button with action id and value, style is "", "primary" or "danger"
+func Button(text, actionID, value, style string) Element {
+	t := plain(text)
+	return Element{Type: "button", Text: &t, ActionID: actionID, Value: value, Style: style}
+}
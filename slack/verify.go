@@ -0,0 +1,51 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxSkew is max age of a signed request, older requests are rejected as replays
+const MaxSkew = 5 * time.Minute
+
+// Slack request signature headers
+const (
+	HeaderSignature = "X-Slack-Signature"
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+)
+
+var (
+	// ErrSignature is returned when request signature does not match
+	ErrSignature = errors.New("slack: invalid signature")
+	// ErrExpired is returned when request timestamp is too old or in the future
+	ErrExpired = errors.New("slack: request expired")
+)
+
+// Sign returns Slack v0 signature of body sent at ts with signing secret
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(ts, 10) + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks Slack signature headers of request body at time now
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > MaxSkew || skew < -MaxSkew {
+		return ErrExpired
+	}
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, ts, body))) {
+		return ErrSignature
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Archive is simulated backend which calls contracts at past blocks like an archive node,
+// simulated backend calls at the latest block only
+type Archive struct {
+	*backends.SimulatedBackend
+}
+
+// CallContract executes call at state of block, nil is the latest block
+func (a Archive) CallContract(ctx context.Context, call ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	chain := a.Blockchain()
+	if block == nil || block.Cmp(chain.CurrentBlock().Number()) == 0 {
+		return a.SimulatedBackend.CallContract(ctx, call, nil)
+	}
+
+	header := chain.GetHeaderByNumber(block.Uint64())
+	if header == nil {
+		return nil, fmt.Errorf("block %s does not exist", block)
+	}
+	state, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Gas == 0 {
+		call.Gas = 50000000
+	}
+	if call.Value == nil {
+		call.Value = new(big.Int)
+	}
+	msg := types.NewMessage(call.From, call.To, 0, call.Value, call.Gas, new(big.Int), call.Data, nil, false)
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), core.NewEVMTxContext(msg), state, chain.Config(), vm.Config{})
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Revert()) > 0 {
+		if reason, err := abi.UnpackRevert(res.Revert()); err == nil {
+			return nil, fmt.Errorf("execution reverted: %s", reason)
+		}
+		return nil, errors.New("execution reverted")
+	}
+	return res.Return(), res.Err
+}
+
+// Archive returns backend of ctx which calls contracts at past blocks
+func (c Context) Archive() Archive {
+	return Archive{SimulatedBackend: c.Backend}
+}
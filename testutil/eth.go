@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
@@ -84,3 +85,30 @@ func BalanceETH(ctx Context, address common.Address) *big.Int {
 
 	return balance
 }
+
+// AutoCommit commits simulated backend periodically until test finished,
+// so code which waits for transaction to be mined can proceed
+func AutoCommit(t *testing.T, ctx Context) {
+	t.Helper()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx.Backend.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+}
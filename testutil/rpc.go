@@ -0,0 +1,132 @@
+package testutil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// RPCHandler handles a json-rpc method call with raw params
+type RPCHandler func(params []json.RawMessage) (interface{}, error)
+
+// RPCError is a json-rpc error with code returned by a handler, other errors have code -32000
+type RPCError struct {
+	Code    int
+	Message string
+}
+
+func (e RPCError) Error() string {
+	return e.Message
+}
+
+// RPCServer is a local json-rpc endpoint stand-in,
+// each method is answered by a registered handler
+type RPCServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]RPCHandler
+	calls    map[string]int
+	down     bool
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// NewRPCServer starts a json-rpc stand-in, it is closed when test finished
+func NewRPCServer(t *testing.T) *RPCServer {
+	t.Helper()
+
+	s := &RPCServer{
+		handlers: map[string]RPCHandler{},
+		calls:    map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Handle registers handler for method
+func (s *RPCServer) Handle(method string, h RPCHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[method] = h
+}
+
+// HandleResult registers static result for method
+func (s *RPCServer) HandleResult(method string, result interface{}) {
+	s.Handle(method, func([]json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// SetDown makes server respond http 503 to every request
+func (s *RPCServer) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.down = down
+}
+
+// Calls returns number of calls to method
+func (s *RPCServer) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+func (s *RPCServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	down := s.down
+	h := s.handlers[req.Method]
+	s.calls[req.Method]++
+	s.mu.Unlock()
+
+	if down {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := rpcResponse{Version: "2.0", ID: req.ID}
+	if h == nil {
+		resp.Error = &rpcError{Code: -32601, Message: "the method " + req.Method + " does not exist/is not available"}
+	} else if result, err := h(req.Params); err != nil {
+		resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+		var rpcErr RPCError
+		if errors.As(err, &rpcErr) {
+			resp.Error.Code = rpcErr.Code
+		}
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Error = &rpcError{Code: -32603, Message: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"killswitch/bridge/api"
+	"killswitch/bridge/config"
+	"killswitch/bridge/health"
+	"killswitch/bridge/indexer"
+	"killswitch/bridge/metrics"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/signer"
+	"killswitch/bridge/unlocker"
+	"killswitch/bridge/webhook"
+)
+
+// transfer-indexer ingests Locked and Unlocked events of all configured pairs
+// into a sqlite transfer history and optionally serves the transfer status api
+func main() {
+	var (
+		configPath  = flag.String("config", "config.yaml", "config file")
+		dbPath      = flag.String("db", "transfers.db", "sqlite database")
+		backfill    = flag.Bool("backfill", false, "re-index full history before sync")
+		once        = flag.Bool("once", false, "sync once and exit")
+		interval    = flag.Duration("interval", 15*time.Second, "poll interval")
+		listen      = flag.String("listen", "", "serve transfer status api on address, e.g. :8080")
+		hooksPath   = flag.String("webhooks", "", "sqlite database of webhooks, enables webhook delivery")
+		adminAddr   = flag.String("admin", "", "serve webhook management api on address, e.g. 127.0.0.1:8081")
+		metricsAddr = flag.String("metrics", "", "serve prometheus metrics on address, e.g. :9100")
+		healthAddr  = flag.String("health", "", "serve /healthz and /readyz on address, e.g. :8082")
+		maxLag      = flag.Uint64("max-lag", 100, "blocks indexing may lag head before not ready")
+		checkSigner = flag.Bool("check-signer", false, "not ready when configured signer can not pay an unlock")
+		nearCap     = flag.Float64("near-cap", 0.9, "ratio of daily limit reported as near cap by /bridges")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := indexer.Open(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	clients, err := cfg.Clients(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	backends := map[string]multiclient.Backend{}
+	for name, c := range clients {
+		defer c.Close()
+		backends[name] = c
+	}
+
+	ix := &indexer.Indexer{
+		DB:      db,
+		Pairs:   cfg.Pairs,
+		Chains:  cfg.Chains,
+		Clients: backends,
+		Log:     log.Printf,
+	}
+
+	heads := func(ctx context.Context, chain string) (uint64, error) {
+		c, ok := clients[chain]
+		if !ok {
+			return 0, fmt.Errorf("unknown chain %s", chain)
+		}
+		return c.BlockNumber(ctx)
+	}
+
+	// paused state served by /bridges, pauses are notified by bridge-monitor
+	var bridges *unlocker.PauseWatcher
+	if *listen != "" {
+		bridges = &unlocker.PauseWatcher{Clients: backends}
+		for _, p := range cfg.Pairs {
+			bridges.Bridges = append(bridges.Bridges, p.Source, p.Destination)
+		}
+		server := &api.Server{DB: db, Chains: cfg.Chains, Heads: heads, Bridges: bridges.States, NearCap: *nearCap, Log: log.Printf}
+		go func() {
+			log.Fatal(http.ListenAndServe(*listen, server.Handler()))
+		}()
+	}
+
+	var collector *metrics.Collector
+	if *metricsAddr != "" {
+		collector = &metrics.Collector{Pairs: cfg.Pairs, Clients: backends, DB: db}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", collector.Handler())
+		go func() {
+			log.Fatal(http.ListenAndServe(*metricsAddr, mux))
+		}()
+	}
+
+	if *healthAddr != "" {
+		checker := health.New(cfg.Chains, backends, cfg.Pairs)
+		checker.Processed = ix.Processed
+		checker.MaxLag = *maxLag
+		if *checkSigner {
+			s, err := signer.New(ctx, cfg.Signer)
+			if err != nil {
+				log.Fatal(err)
+			}
+			address := s.Address()
+			checker.Signer = &address
+		}
+		go func() {
+			log.Fatal(http.ListenAndServe(*healthAddr, checker.Handler()))
+		}()
+	}
+
+	var dispatcher *webhook.Dispatcher
+	if *hooksPath != "" {
+		store, err := webhook.OpenStore(*hooksPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		dispatcher = &webhook.Dispatcher{Store: store, DB: db, Chains: cfg.Chains, Heads: heads}
+		if *adminAddr != "" {
+			token := os.Getenv("WEBHOOK_ADMIN_TOKEN")
+			if token == "" {
+				log.Fatal("-admin requires WEBHOOK_ADMIN_TOKEN")
+			}
+			handler := &webhook.Handler{Store: store, Token: token}
+			go func() {
+				log.Fatal(http.ListenAndServe(*adminAddr, handler))
+			}()
+		}
+	}
+
+	if *backfill {
+		if err := ix.Backfill(ctx); err != nil {
+			log.Fatalf("can not backfill; %v", err)
+		}
+	}
+
+	for {
+		if err := ix.Sync(ctx); err != nil {
+			log.Printf("can not sync; %v", err)
+		}
+		if bridges != nil {
+			if err := bridges.Sync(ctx); err != nil {
+				log.Printf("can not sync paused state; %v", err)
+			}
+		}
+		if dispatcher != nil {
+			if _, err := dispatcher.Scan(ctx); err != nil {
+				log.Printf("can not scan webhook events; %v", err)
+			}
+			if _, err := dispatcher.Deliver(ctx); err != nil {
+				log.Printf("can not deliver webhooks; %v", err)
+			}
+		}
+		if collector != nil {
+			if err := collector.Update(ctx); err != nil {
+				log.Printf("can not update metrics; %v", err)
+			}
+		}
+		if *once {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
@@ -0,0 +1,157 @@
+package unlocker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+)
+
+// ErrNotUnlockTx is returned when transaction is not a direct call to unlock
+var ErrNotUnlockTx = errors.New("unlocker: not an unlock transaction")
+
+var bridgeABI, _ = ethabi.JSON(strings.NewReader(abi.IBridgeABI))
+
+// Topics of bridge events
+var (
+	LockedTopic   = bridgeABI.Events["Locked"].ID
+	UnlockedTopic = bridgeABI.Events["Unlocked"].ID
+)
+
+// Hash returns the canonical unlock hash of a source Locked log,
+// it is the hash of the lock transaction
+func Hash(lock types.Log) common.Hash {
+	return lock.TxHash
+}
+
+// Job is an unlock to send to destination bridge for a source Locked event
+type Job struct {
+	Hash    common.Hash
+	Account common.Address
+	Amount  *big.Int
+	Lock    types.Log
+}
+
+// NewJob creates job from source Locked log
+func NewJob(lock types.Log) (Job, error) {
+	filterer, _ := abi.NewIBridgeFilterer(lock.Address, nil)
+	ev, err := filterer.ParseLocked(lock)
+	if err != nil {
+		return Job{}, fmt.Errorf("can not parse locked log; %w", err)
+	}
+
+	return Job{
+		Hash:    Hash(lock),
+		Account: ev.Sender,
+		Amount:  ev.Amount,
+		Lock:    lock,
+	}, nil
+}
+
+// Calldata returns destination unlock call data of job
+func (j Job) Calldata() []byte {
+	data, _ := bridgeABI.Pack("unlock", j.Account, j.Amount, j.Hash)
+	return data
+}
+
+// FilterJobs returns jobs of all Locked events of bridge in block range
+func FilterJobs(ctx context.Context, backend bind.ContractFilterer, bridge common.Address, from, to uint64) ([]Job, error) {
+	filterer, _ := abi.NewIBridgeFilterer(bridge, backend)
+	it, err := filterer.FilterLocked(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not filter locked; %w", err)
+	}
+	defer it.Close()
+
+	var r []Job
+	for it.Next() {
+		job, err := NewJob(it.Event.Raw)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, job)
+	}
+	return r, it.Error()
+}
+
+// Unlock is an unlock sent to destination bridge
+type Unlock struct {
+	Hash    common.Hash
+	Account common.Address
+	Amount  *big.Int
+	Log     types.Log
+}
+
+// UnlockFromTx decodes unlock call from transaction
+func UnlockFromTx(tx *types.Transaction) (Unlock, error) {
+	data := tx.Data()
+	if len(data) < 4 {
+		return Unlock{}, ErrNotUnlockTx
+	}
+	method, err := bridgeABI.MethodById(data[:4])
+	if err != nil || method.Name != "unlock" {
+		return Unlock{}, ErrNotUnlockTx
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return Unlock{}, fmt.Errorf("can not unpack unlock args; %w", err)
+	}
+
+	return Unlock{
+		Account: args[0].(common.Address),
+		Amount:  args[1].(*big.Int),
+		Hash:    args[2].([32]byte),
+	}, nil
+}
+
+// UnlockReader is chain access needed to recover unlocks
+type UnlockReader interface {
+	bind.ContractFilterer
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// FilterUnlocks returns all unlocks of bridge in block range,
+// the unlock hash is recovered from the unlock transaction
+func FilterUnlocks(ctx context.Context, backend UnlockReader, bridge common.Address, from, to uint64) ([]Unlock, error) {
+	filterer, _ := abi.NewIBridgeFilterer(bridge, backend)
+	it, err := filterer.FilterUnlocked(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not filter unlocked; %w", err)
+	}
+	defer it.Close()
+
+	var r []Unlock
+	for it.Next() {
+		u, err := NewUnlock(ctx, backend, it.Event.Raw)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, u)
+	}
+	return r, it.Error()
+}
+
+// NewUnlock creates unlock from destination Unlocked log,
+// the unlock hash is recovered from the unlock transaction
+func NewUnlock(ctx context.Context, backend UnlockReader, unlocked types.Log) (Unlock, error) {
+	tx, _, err := backend.TransactionByHash(ctx, unlocked.TxHash)
+	if err != nil {
+		return Unlock{}, fmt.Errorf("can not get unlock tx %s; %w", unlocked.TxHash.Hex(), err)
+	}
+
+	u, err := UnlockFromTx(tx)
+	if err != nil {
+		return Unlock{}, fmt.Errorf("can not decode unlock tx %s; %w", unlocked.TxHash.Hex(), err)
+	}
+	u.Log = unlocked
+	return u, nil
+}
@@ -0,0 +1,144 @@
+package unlocker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+)
+
+// Liquidity returns custody of bridge available to unlock,
+// nil for burner which mints on unlock
+func Liquidity(ctx context.Context, backend multiclient.Backend, b config.Bridge) (*big.Int, error) {
+	switch b.Type {
+	case config.TypeBurner:
+		return nil, nil
+	case config.TypeEther:
+		v, err := backend.BalanceAt(ctx, b.Address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("can not get balance of %s; %w", b, err)
+		}
+		return v, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	locker, _ := abi.NewBridgeLocker(b.Address, backend)
+	tokenAddr, err := locker.Token(opts)
+	if err != nil {
+		return nil, fmt.Errorf("can not get token of %s; %w", b, err)
+	}
+	token, _ := abi.NewIERC20(tokenAddr, backend)
+	v, err := token.BalanceOf(opts, b.Address)
+	if err != nil {
+		return nil, fmt.Errorf("can not get custody of %s; %w", b, err)
+	}
+	return v, nil
+}
+
+// Held is a job waiting for liquidity
+type Held struct {
+	Job Job
+	// Available is custody when job was last checked
+	Available *big.Int
+	Since     time.Time
+}
+
+// LiquidityGate holds unlocks of a locker or ether bridge while its custody
+// can not pay them, instead of sending transactions which revert,
+// held jobs are released once liquidity returns,
+// it is meant for the relayer and is not wired into any command of this repository
+type LiquidityGate struct {
+	Bridge  config.Bridge
+	Backend multiclient.Backend
+
+	// OnWaiting is called once when job starts waiting for liquidity
+	OnWaiting func(h Held)
+	// OnResumed is called when held job has liquidity again
+	OnResumed func(h Held)
+
+	mu   sync.Mutex
+	held map[common.Hash]*Held
+	now  func() time.Time
+}
+
+// Ready returns jobs which custody can pay, in order, jobs which do not fit
+// are held and later smaller jobs may still pass,
+// jobs must be passed again until they are returned
+func (g *LiquidityGate) Ready(ctx context.Context, jobs []Job) ([]Job, error) {
+	available, err := Liquidity(ctx, g.Backend, g.Bridge)
+	if err != nil || available == nil {
+		return jobs, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.held == nil {
+		g.held = map[common.Hash]*Held{}
+	}
+	if g.now == nil {
+		g.now = time.Now
+	}
+
+	left := new(big.Int).Set(available)
+	var r []Job
+	for _, job := range jobs {
+		h, held := g.held[job.Hash]
+		if job.Amount.Cmp(left) <= 0 {
+			left.Sub(left, job.Amount)
+			r = append(r, job)
+			if held {
+				delete(g.held, job.Hash)
+				if g.OnResumed != nil {
+					g.OnResumed(*h)
+				}
+			}
+			continue
+		}
+
+		if held {
+			h.Available = available
+			continue
+		}
+		h = &Held{Job: job, Available: available, Since: g.now()}
+		g.held[job.Hash] = h
+		if g.OnWaiting != nil {
+			g.OnWaiting(*h)
+		}
+	}
+	return r, nil
+}
+
+// Held returns jobs waiting for liquidity, longest waiting first
+func (g *LiquidityGate) Held() []Held {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var r []Held
+	for _, h := range g.held {
+		r = append(r, *h)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if !r[i].Since.Equal(r[j].Since) {
+			return r[i].Since.Before(r[j].Since)
+		}
+		return r[i].Job.Hash.Hex() < r[j].Job.Hash.Hex()
+	})
+	return r
+}
+
+// Forget stops holding job, e.g. after it is unlocked by another relayer
+func (g *LiquidityGate) Forget(hash common.Hash) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.held, hash)
+}
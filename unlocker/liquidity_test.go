@@ -0,0 +1,85 @@
+package unlocker_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/testutil"
+	"killswitch/bridge/unlocker"
+)
+
+func TestLiquidityGate(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	_, err := token.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	fund := func(amount string) {
+		t.Helper()
+		_, err := token.Mint(owner.TxOpts, lockerAddr, decimal.EtherToWei(amount))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+	job := func(hash byte, amount string) unlocker.Job {
+		return unlocker.Job{Hash: common.Hash{hash}, Account: user.Address, Amount: decimal.EtherToWei(amount)}
+	}
+
+	var waiting, resumed []unlocker.Held
+	g := &unlocker.LiquidityGate{
+		Bridge:  config.Bridge{Chain: "bsc", Type: config.TypeLocker, Address: lockerAddr},
+		Backend: ctx.Backend,
+		OnWaiting: func(h unlocker.Held) {
+			waiting = append(waiting, h)
+		},
+		OnResumed: func(h unlocker.Held) {
+			resumed = append(resumed, h)
+		},
+	}
+
+	fund("5")
+	jobs := []unlocker.Job{job(1, "3"), job(2, "4"), job(3, "2")}
+
+	ready, err := g.Ready(ctx, jobs)
+	require.NoError(t, err)
+	require.Equal(t, []unlocker.Job{jobs[0], jobs[2]}, ready)
+	require.Len(t, waiting, 1)
+	require.Equal(t, jobs[1], waiting[0].Job)
+	require.Equal(t, decimal.EtherToWei("5"), waiting[0].Available)
+
+	// unlock of ready jobs spends custody
+	for _, j := range ready {
+		_, err := locker.Unlock(owner.TxOpts, j.Account, j.Amount, j.Hash)
+		require.NoError(t, err)
+	}
+	ctx.Backend.Commit()
+
+	ready, err = g.Ready(ctx, jobs[1:2])
+	require.NoError(t, err)
+	require.Empty(t, ready)
+	require.Len(t, waiting, 1, "alert once")
+	require.Len(t, g.Held(), 1)
+	require.Zero(t, g.Held()[0].Available.Sign())
+
+	fund("4")
+	ready, err = g.Ready(ctx, jobs[1:2])
+	require.NoError(t, err)
+	require.Equal(t, jobs[1:2], ready)
+	require.Len(t, resumed, 1)
+	require.Empty(t, g.Held())
+
+	t.Run("Burner", func(t *testing.T) {
+		g := &unlocker.LiquidityGate{Bridge: config.Bridge{Type: config.TypeBurner}, Backend: ctx.Backend}
+		ready, err := g.Ready(ctx, jobs)
+		require.NoError(t, err)
+		require.Equal(t, jobs, ready)
+	})
+}
@@ -0,0 +1,262 @@
+package unlocker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/multiclient"
+)
+
+// BridgeState is paused and limiter state of a bridge
+type BridgeState struct {
+	Bridge config.Bridge
+	Paused bool
+	// Usage and Limit of daily limiter, Limit is nil without limiter and zero when unlimited
+	Usage *big.Int
+	Limit *big.Int
+	// Block is the head block state was read at
+	Block uint64
+}
+
+// NearCap reports whether daily limiter usage reached ratio of limit
+func (s BridgeState) NearCap(ratio float64) bool {
+	if s.Limit == nil || s.Limit.Sign() == 0 {
+		return false
+	}
+	used, _ := new(big.Float).Quo(new(big.Float).SetInt(s.Usage), new(big.Float).SetInt(s.Limit)).Float64()
+	return used >= ratio
+}
+
+// PauseWatcher tracks paused state of bridges from Paused and Unpaused events,
+// state is initialized and reconciled with paused()
+type PauseWatcher struct {
+	Bridges []config.Bridge
+	Clients map[string]multiclient.Backend
+
+	// OnChange is called for every Paused or Unpaused event in order
+	OnChange func(b config.Bridge, paused bool, log types.Log)
+
+	mu     sync.Mutex
+	states map[common.Address]*BridgeState
+}
+
+// Sync reads events of all bridges since last sync up to head
+func (w *PauseWatcher) Sync(ctx context.Context) error {
+	heads := map[string]uint64{}
+	for _, b := range w.Bridges {
+		head, ok := heads[b.Chain]
+		if !ok {
+			h, err := w.Clients[b.Chain].HeaderByNumber(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("can not get head of %s; %w", b.Chain, err)
+			}
+			head = h.Number.Uint64()
+			heads[b.Chain] = head
+		}
+		if err := w.sync(ctx, b, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *PauseWatcher) sync(ctx context.Context, b config.Bridge, head uint64) error {
+	client := w.Clients[b.Chain]
+	bridge, _ := abi.NewBridgeBase(b.Address, client)
+
+	w.mu.Lock()
+	last, ok := w.states[b.Address]
+	w.mu.Unlock()
+
+	if ok && head > last.Block {
+		logs, err := pauseLogs(ctx, bridge, last.Block+1, head)
+		if err != nil {
+			return fmt.Errorf("can not filter pause events of %s; %w", b, err)
+		}
+		if w.OnChange != nil {
+			for _, l := range logs {
+				w.OnChange(b, l.paused, l.log)
+			}
+		}
+	}
+
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(head)}
+	paused, err := bridge.Paused(opts)
+	if err != nil {
+		return fmt.Errorf("can not get paused of %s; %w", b, err)
+	}
+	state := &BridgeState{Bridge: b, Paused: paused, Block: head}
+
+	limiterAddr, err := bridge.GetLimiter(opts)
+	if err != nil {
+		return fmt.Errorf("can not get limiter of %s; %w", b, err)
+	}
+	if limiterAddr != (common.Address{}) {
+		limiter, _ := abi.NewLimiterDaily(limiterAddr, client)
+		if state.Limit, err = limiter.GetLimit(opts, b.Address); err != nil {
+			return fmt.Errorf("can not get limit of %s; %w", b, err)
+		}
+		if state.Usage, err = limiter.GetUsage(opts, b.Address); err != nil {
+			return fmt.Errorf("can not get limiter usage of %s; %w", b, err)
+		}
+	}
+
+	w.mu.Lock()
+	if w.states == nil {
+		w.states = map[common.Address]*BridgeState{}
+	}
+	w.states[b.Address] = state
+	w.mu.Unlock()
+	return nil
+}
+
+type pauseLog struct {
+	paused bool
+	log    types.Log
+}
+
+// pauseLogs returns Paused and Unpaused events of bridge in block range in chain order
+func pauseLogs(ctx context.Context, bridge *abi.BridgeBase, from, to uint64) ([]pauseLog, error) {
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+
+	var r []pauseLog
+	paused, err := bridge.FilterPaused(opts)
+	if err != nil {
+		return nil, err
+	}
+	for paused.Next() {
+		r = append(r, pauseLog{true, paused.Event.Raw})
+	}
+	paused.Close()
+	if err := paused.Error(); err != nil {
+		return nil, err
+	}
+
+	unpaused, err := bridge.FilterUnpaused(opts)
+	if err != nil {
+		return nil, err
+	}
+	for unpaused.Next() {
+		r = append(r, pauseLog{false, unpaused.Event.Raw})
+	}
+	unpaused.Close()
+	if err := unpaused.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].log.BlockNumber != r[j].log.BlockNumber {
+			return r[i].log.BlockNumber < r[j].log.BlockNumber
+		}
+		return r[i].log.Index < r[j].log.Index
+	})
+	return r, nil
+}
+
+// State returns last synced state of bridge
+func (w *PauseWatcher) State(bridge common.Address) (BridgeState, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s, ok := w.states[bridge]
+	if !ok {
+		return BridgeState{}, false
+	}
+	return *s, true
+}
+
+// States returns last synced state of all bridges in configured order
+func (w *PauseWatcher) States() []BridgeState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var r []BridgeState
+	for _, b := range w.Bridges {
+		if s, ok := w.states[b.Address]; ok {
+			r = append(r, *s)
+		}
+	}
+	return r
+}
+
+// Paused reports whether bridge was paused at last sync, unknown bridges are paused
+func (w *PauseWatcher) Paused(bridge common.Address) bool {
+	s, ok := w.State(bridge)
+	return !ok || s.Paused
+}
+
+// PauseGate holds unlocks while destination bridge is paused,
+// held jobs are released in lock order once it is unpaused,
+// the relayer passes its jobs through it before sending
+type PauseGate struct {
+	Watcher     *PauseWatcher
+	Destination common.Address
+
+	// OnHeld is called once when job is held
+	OnHeld func(job Job)
+	// OnResumed is called with held jobs released after unpause, in lock order
+	OnResumed func(jobs []Job)
+
+	mu   sync.Mutex
+	held map[common.Hash]bool
+}
+
+// Ready returns jobs to unlock ordered by lock block and log index,
+// no job is returned while destination is paused
+func (g *PauseGate) Ready(jobs []Job) []Job {
+	sorted := append([]Job(nil), jobs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Lock, sorted[j].Lock
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		return a.Index < b.Index
+	})
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.held == nil {
+		g.held = map[common.Hash]bool{}
+	}
+
+	if g.Watcher.Paused(g.Destination) {
+		for _, job := range sorted {
+			if !g.held[job.Hash] {
+				g.held[job.Hash] = true
+				if g.OnHeld != nil {
+					g.OnHeld(job)
+				}
+			}
+		}
+		return nil
+	}
+
+	var resumed []Job
+	for _, job := range sorted {
+		if g.held[job.Hash] {
+			delete(g.held, job.Hash)
+			resumed = append(resumed, job)
+		}
+	}
+	if len(resumed) > 0 && g.OnResumed != nil {
+		g.OnResumed(resumed)
+	}
+	return sorted
+}
+
+// Held returns number of held jobs
+func (g *PauseGate) Held() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.held)
+}
@@ -0,0 +1,133 @@
+package unlocker_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/config"
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/multiclient"
+	"killswitch/bridge/testutil"
+	"killswitch/bridge/unlocker"
+)
+
+func TestPauseGate(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	send := func(tx interface{}, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+	}
+
+	_, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kBNB", "kBNB", 18)
+	ether, etherAddr := testutil.DeployBridgeEther(ctx, owner, "BNB", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kBNB Burner", decimal.EtherToWei("0"))
+	limiterAddr, _, limiter, err := abi.DeployLimiterDaily(owner.TxOpts, ctx.Backend)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	send(ether.SetLimiter(owner.TxOpts, limiterAddr))
+	send(limiter.SetLimit(owner.TxOpts, etherAddr, decimal.EtherToWei("10")))
+
+	opts := *user.TxOpts
+	opts.Value = decimal.EtherToWei("9.5")
+	send(ether.Lock(&opts, decimal.EtherToWei("9.5")))
+
+	source := config.Bridge{Chain: "bsc", Type: config.TypeEther, Address: etherAddr, Side: config.SideSource}
+	destination := config.Bridge{Chain: "bkc", Type: config.TypeBurner, Address: burnerAddr, Side: config.SideDestination}
+
+	type change struct {
+		bridge common.Address
+		paused bool
+	}
+	var changes []change
+	w := &unlocker.PauseWatcher{
+		Bridges: []config.Bridge{source, destination},
+		Clients: map[string]multiclient.Backend{"bsc": ctx.Backend, "bkc": ctx.Backend},
+		OnChange: func(b config.Bridge, paused bool, log types.Log) {
+			changes = append(changes, change{b.Address, paused})
+		},
+	}
+
+	var held []unlocker.Job
+	var resumed [][]unlocker.Job
+	g := &unlocker.PauseGate{
+		Watcher:     w,
+		Destination: burnerAddr,
+		OnHeld: func(job unlocker.Job) {
+			held = append(held, job)
+		},
+		OnResumed: func(jobs []unlocker.Job) {
+			resumed = append(resumed, jobs)
+		},
+	}
+
+	job := func(hash byte, block uint64, index uint) unlocker.Job {
+		return unlocker.Job{
+			Hash:    common.Hash{hash},
+			Account: user.Address,
+			Amount:  decimal.EtherToWei("1"),
+			Lock:    types.Log{BlockNumber: block, Index: index},
+		}
+	}
+	jobs := []unlocker.Job{job(1, 20, 0), job(2, 10, 3), job(3, 10, 1)}
+	ordered := []unlocker.Job{jobs[2], jobs[1], jobs[0]}
+
+	t.Run("Unknown is paused", func(t *testing.T) {
+		require.Empty(t, g.Ready(jobs))
+		require.Equal(t, ordered, held)
+		require.Equal(t, 3, g.Held())
+	})
+
+	require.NoError(t, w.Sync(ctx))
+	require.Empty(t, changes, "initial state is read from paused()")
+
+	t.Run("Limiter", func(t *testing.T) {
+		s, ok := w.State(etherAddr)
+		require.True(t, ok)
+		require.False(t, s.Paused)
+		require.Equal(t, decimal.EtherToWei("9.5"), s.Usage)
+		require.True(t, s.NearCap(0.9))
+		require.False(t, s.NearCap(0.99))
+
+		s, _ = w.State(burnerAddr)
+		require.Nil(t, s.Limit)
+		require.False(t, s.NearCap(0.9))
+	})
+
+	t.Run("Resume in order", func(t *testing.T) {
+		require.Equal(t, ordered, g.Ready(jobs))
+		require.Equal(t, [][]unlocker.Job{ordered}, resumed)
+		require.Zero(t, g.Held())
+	})
+
+	t.Run("Paused", func(t *testing.T) {
+		held, resumed = nil, nil
+		send(burner.Pause(owner.TxOpts))
+		send(ether.Pause(owner.TxOpts))
+		require.NoError(t, w.Sync(ctx))
+		require.Equal(t, []change{{etherAddr, true}, {burnerAddr, true}}, changes)
+		require.True(t, w.Paused(etherAddr))
+
+		require.Empty(t, g.Ready(jobs[:1]))
+		require.Empty(t, g.Ready(jobs))
+		require.Equal(t, []unlocker.Job{jobs[0], jobs[2], jobs[1]}, held, "held once")
+
+		// source paused does not hold unlocks
+		send(burner.Unpause(owner.TxOpts))
+		require.NoError(t, w.Sync(ctx))
+		require.Equal(t, change{burnerAddr, false}, changes[2])
+		require.Equal(t, ordered, g.Ready(jobs))
+		require.Equal(t, [][]unlocker.Job{ordered}, resumed)
+	})
+
+	require.Len(t, w.States(), 2)
+	require.True(t, w.States()[0].Paused)
+	require.False(t, w.States()[1].Paused)
+}
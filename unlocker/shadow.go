@@ -0,0 +1,268 @@
+package unlocker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/abi"
+	"killswitch/bridge/multiclient"
+)
+
+// Outcome is the comparison result of a shadow unlock with production
+type Outcome string
+
+const (
+	// OutcomeMatch production sent the same unlock
+	OutcomeMatch Outcome = "match"
+	// OutcomeMismatch production sent unlock with different account or amount
+	OutcomeMismatch Outcome = "mismatch"
+	// OutcomeMissing production did not send unlock within grace period
+	OutcomeMissing Outcome = "missing"
+	// OutcomeUnexpected production sent unlock which shadow would not send
+	OutcomeUnexpected Outcome = "unexpected"
+)
+
+// Simulation is the result of unlock simulated with eth_call and estimate gas
+type Simulation struct {
+	// Skipped is true when production already completed the unlock before simulation
+	Skipped bool
+	// Gas is estimated at head only, it is zero when job is first seen after production unlock
+	Gas uint64
+	Err error
+}
+
+// Result is a shadow unlock compared with production
+type Result struct {
+	Hash       common.Hash
+	Outcome    Outcome
+	Job        *Job
+	Simulation Simulation
+	Production *Unlock
+}
+
+// Shadow processes the same Locked events as production unlocker,
+// but only simulates unlock and compares with Unlocked events
+// emitted by production
+type Shadow struct {
+	// Source bridge emits Locked events
+	Source       common.Address
+	SourceClient multiclient.Backend
+
+	// Destination bridge emits Unlocked events
+	Destination       common.Address
+	DestinationClient multiclient.Backend
+
+	// From is production unlocker account (bridge owner)
+	From common.Address
+
+	// Grace is how long to wait for the counterpart before reporting
+	// missing or unexpected unlock
+	Grace time.Duration
+
+	mu         sync.Mutex
+	jobs       map[common.Hash]*pending
+	production map[common.Hash]*pending
+	now        func() time.Time
+}
+
+type pending struct {
+	result Result
+	seenAt time.Time
+}
+
+func (s *Shadow) init() {
+	if s.jobs == nil {
+		s.jobs = map[common.Hash]*pending{}
+		s.production = map[common.Hash]*pending{}
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+}
+
+// Simulate runs unlock of job on destination bridge at head without sending transaction
+func (s *Shadow) Simulate(ctx context.Context, job Job) Simulation {
+	return s.SimulateAt(ctx, job, nil)
+}
+
+// SimulateAt runs unlock of job on destination bridge at state of block, nil is head,
+// gas is estimated only at head, past blocks require an archive node
+func (s *Shadow) SimulateAt(ctx context.Context, job Job, block *big.Int) Simulation {
+	bridge, _ := abi.NewBridgeBase(s.Destination, s.DestinationClient)
+	completed, err := bridge.IsUnlockCompleted(&bind.CallOpts{Context: ctx, BlockNumber: block}, job.Hash)
+	if err != nil {
+		return Simulation{Err: fmt.Errorf("can not check unlock completed; %w", err)}
+	}
+	if completed {
+		return Simulation{Skipped: true}
+	}
+
+	msg := ethereum.CallMsg{
+		From: s.From,
+		To:   &s.Destination,
+		Data: job.Calldata(),
+	}
+	if _, err := s.DestinationClient.CallContract(ctx, msg, block); err != nil {
+		return Simulation{Err: err}
+	}
+	if block != nil {
+		return Simulation{}
+	}
+	gas, err := s.DestinationClient.EstimateGas(ctx, msg)
+	if err != nil {
+		return Simulation{Err: err}
+	}
+	return Simulation{Gas: gas}
+}
+
+// before simulates job at the block before production unlock u, the state production unlocked in,
+// gas of a successful simulation is kept from the head simulation sim
+func (s *Shadow) before(ctx context.Context, job Job, u *Unlock, sim Simulation) Simulation {
+	r := s.SimulateAt(ctx, job, new(big.Int).SetUint64(u.Log.BlockNumber-1))
+	if r.Err == nil && !r.Skipped {
+		r.Gas = sim.Gas
+	}
+	return r
+}
+
+// Locks ingests source Locked events in block range,
+// returns results which production already unlocked
+func (s *Shadow) Locks(ctx context.Context, from, to uint64) ([]Result, error) {
+	jobs, err := FilterJobs(ctx, s.SourceClient, s.Source, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []Result
+	for i := range jobs {
+		job := jobs[i]
+
+		s.mu.Lock()
+		s.init()
+		p, unlocked := s.production[job.Hash]
+		delete(s.production, job.Hash)
+		_, seen := s.jobs[job.Hash]
+		s.mu.Unlock()
+
+		switch {
+		case unlocked:
+			r = append(r, compare(&job, s.before(ctx, job, p.result.Production, Simulation{}), p.result.Production))
+		case !seen:
+			sim := s.Simulate(ctx, job)
+			s.mu.Lock()
+			s.jobs[job.Hash] = &pending{
+				result: Result{Hash: job.Hash, Job: &job, Simulation: sim},
+				seenAt: s.now(),
+			}
+			s.mu.Unlock()
+		}
+	}
+	return r, nil
+}
+
+// Unlocks ingests destination Unlocked events in block range,
+// returns results of matched jobs
+func (s *Shadow) Unlocks(ctx context.Context, from, to uint64) ([]Result, error) {
+	unlocks, err := FilterUnlocks(ctx, s.DestinationClient, s.Destination, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*pending
+	s.mu.Lock()
+	s.init()
+	for i := range unlocks {
+		u := unlocks[i]
+		if p, ok := s.jobs[u.Hash]; ok {
+			delete(s.jobs, u.Hash)
+			p.result.Production = &u
+			matched = append(matched, p)
+		} else if _, ok := s.production[u.Hash]; !ok {
+			s.production[u.Hash] = &pending{
+				result: Result{Hash: u.Hash, Production: &u},
+				seenAt: s.now(),
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	// head simulation may predate changes of destination, simulate again where production unlocked
+	var r []Result
+	for _, p := range matched {
+		job, u := p.result.Job, p.result.Production
+		r = append(r, compare(job, s.before(ctx, *job, u, p.result.Simulation), u))
+	}
+	return r, nil
+}
+
+// Expired returns results which counterpart is not seen within grace period
+func (s *Shadow) Expired() []Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+
+	now := s.now()
+
+	var r []Result
+	for h, p := range s.jobs {
+		if now.Sub(p.seenAt) >= s.Grace {
+			delete(s.jobs, h)
+			p.result.Outcome = OutcomeMissing
+			r = append(r, p.result)
+		}
+	}
+	for h, p := range s.production {
+		if now.Sub(p.seenAt) >= s.Grace {
+			delete(s.production, h)
+			p.result.Outcome = OutcomeUnexpected
+			r = append(r, p.result)
+		}
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Hash.Hex() < r[j].Hash.Hex()
+	})
+	return r
+}
+
+func compare(job *Job, sim Simulation, u *Unlock) Result {
+	r := Result{
+		Hash:       job.Hash,
+		Outcome:    OutcomeMatch,
+		Job:        job,
+		Simulation: sim,
+		Production: u,
+	}
+	if job.Account != u.Account || job.Amount.Cmp(u.Amount) != 0 {
+		r.Outcome = OutcomeMismatch
+	}
+	return r
+}
+
+// Write writes human readable result
+func (r Result) Write(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", r.Outcome, r.Hash.Hex())
+	if r.Job != nil {
+		fmt.Fprintf(w, "  would send: unlock(%s, %s) lock block %d\n", r.Job.Account.Hex(), r.Job.Amount, r.Job.Lock.BlockNumber)
+		switch {
+		case r.Simulation.Skipped:
+			fmt.Fprintf(w, "  simulation: skipped, already unlocked\n")
+		case r.Simulation.Err != nil:
+			fmt.Fprintf(w, "  simulation: failed; %v\n", r.Simulation.Err)
+		default:
+			fmt.Fprintf(w, "  simulation: ok, gas %d\n", r.Simulation.Gas)
+		}
+	}
+	if r.Production != nil {
+		fmt.Fprintf(w, "  production: unlock(%s, %s) tx %s\n", r.Production.Account.Hex(), r.Production.Amount, r.Production.Log.TxHash.Hex())
+	}
+}
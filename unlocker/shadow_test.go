@@ -0,0 +1,162 @@
+package unlocker_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/decimal"
+	"killswitch/bridge/testutil"
+	"killswitch/bridge/unlocker"
+)
+
+func TestShadow(t *testing.T) {
+	ctx := testutil.Setup(t)
+
+	// addr0 => bridges owner, production unlocker
+	// addr1 => user
+	owner := ctx.Wallets[0]
+	user := ctx.Wallets[1]
+
+	token, tokenAddr := testutil.DeployTokenWith(ctx, owner, "DAI", "DAI", 18)
+	_, err := token.AddMinter(owner.TxOpts, owner.Address)
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = token.Mint(owner.TxOpts, user.Address, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+
+	locker, lockerAddr := testutil.DeployBridgeLocker(ctx, owner, tokenAddr, "DAI Locker", decimal.EtherToWei("0"))
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+
+	_, err = wrapped.AddMinter(owner.TxOpts, burnerAddr)
+	require.NoError(t, err)
+	_, err = token.Approve(user.TxOpts, lockerAddr, decimal.EtherToWei("10"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	shadow := &unlocker.Shadow{
+		Source:            lockerAddr,
+		SourceClient:      ctx.Backend,
+		Destination:       burnerAddr,
+		DestinationClient: ctx.Archive(),
+		From:              owner.Address,
+	}
+
+	start := ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+	var hashes []common.Hash
+	for i := 0; i < 3; i++ {
+		tx, err := locker.Lock(user.TxOpts, decimal.EtherToWei("1"))
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+		hashes = append(hashes, tx.Hash())
+	}
+	head := ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+
+	r, err := shadow.Locks(ctx, start, head)
+	require.NoError(t, err)
+	require.Empty(t, r)
+
+	// production unlocks, one correct, one with wrong amount and one without lock
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), hashes[0])
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("2"), hashes[1])
+	require.NoError(t, err)
+	_, err = burner.Unlock(owner.TxOpts, ctx.Wallets[5].Address, decimal.EtherToWei("5"), common.Hash{1})
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+
+	r, err = shadow.Unlocks(ctx, head+1, ctx.Backend.Blockchain().CurrentBlock().NumberU64())
+	require.NoError(t, err)
+	require.Len(t, r, 2)
+
+	require.Equal(t, unlocker.OutcomeMatch, r[0].Outcome)
+	require.Equal(t, hashes[0], r[0].Hash)
+	require.NoError(t, r[0].Simulation.Err)
+	require.NotZero(t, r[0].Simulation.Gas)
+
+	require.Equal(t, unlocker.OutcomeMismatch, r[1].Outcome)
+	require.Equal(t, hashes[1], r[1].Hash)
+	require.Equal(t, decimal.EtherToWei("1").String(), r[1].Job.Amount.String())
+	require.Equal(t, decimal.EtherToWei("2").String(), r[1].Production.Amount.String())
+
+	r = shadow.Expired()
+	require.Len(t, r, 2)
+	for _, res := range r {
+		switch res.Hash {
+		case hashes[2]:
+			require.Equal(t, unlocker.OutcomeMissing, res.Outcome)
+			require.NoError(t, res.Simulation.Err)
+		case common.Hash{1}:
+			require.Equal(t, unlocker.OutcomeUnexpected, res.Outcome)
+			require.Equal(t, ctx.Wallets[5].Address, res.Production.Account)
+		default:
+			t.Fatalf("unexpected result %s", res.Hash.Hex())
+		}
+	}
+
+	// production unlock seen before its lock is simulated at the block before the unlock
+	start = ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+	tx, err := locker.Lock(user.TxOpts, decimal.EtherToWei("1"))
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	_, err = burner.Unlock(owner.TxOpts, user.Address, decimal.EtherToWei("1"), tx.Hash())
+	require.NoError(t, err)
+	ctx.Backend.Commit()
+	head = ctx.Backend.Blockchain().CurrentBlock().NumberU64()
+
+	r, err = shadow.Unlocks(ctx, start+1, head)
+	require.NoError(t, err)
+	require.Empty(t, r)
+	r, err = shadow.Locks(ctx, start+1, head)
+	require.NoError(t, err)
+	require.Len(t, r, 1)
+	require.Equal(t, unlocker.OutcomeMatch, r[0].Outcome)
+	require.NoError(t, r[0].Simulation.Err)
+	require.False(t, r[0].Simulation.Skipped)
+	require.Zero(t, r[0].Simulation.Gas)
+}
+
+func TestShadowSimulate(t *testing.T) {
+	ctx := testutil.Setup(t)
+	owner := ctx.Wallets[0]
+
+	wrapped, wrappedAddr := testutil.DeployTokenWith(ctx, owner, "kDAI", "kDAI", 18)
+	burner, burnerAddr := testutil.DeployBridgeBurner(ctx, owner, wrappedAddr, "kDAI Burner", decimal.EtherToWei("0"))
+
+	job := unlocker.Job{
+		Hash:    common.Hash{1},
+		Account: ctx.Wallets[1].Address,
+		Amount:  decimal.EtherToWei("1"),
+	}
+
+	t.Run("Not minter", func(t *testing.T) {
+		shadow := &unlocker.Shadow{Destination: burnerAddr, DestinationClient: ctx.Backend, From: owner.Address}
+		sim := shadow.Simulate(ctx, job)
+		require.Error(t, sim.Err)
+	})
+
+	t.Run("Not owner", func(t *testing.T) {
+		_, err := wrapped.AddMinter(owner.TxOpts, burnerAddr)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		shadow := &unlocker.Shadow{Destination: burnerAddr, DestinationClient: ctx.Backend, From: ctx.Wallets[1].Address}
+		sim := shadow.Simulate(ctx, job)
+		require.Error(t, sim.Err)
+	})
+
+	t.Run("Already unlocked", func(t *testing.T) {
+		_, err := burner.Unlock(owner.TxOpts, job.Account, job.Amount, job.Hash)
+		require.NoError(t, err)
+		ctx.Backend.Commit()
+
+		shadow := &unlocker.Shadow{Destination: burnerAddr, DestinationClient: ctx.Backend, From: owner.Address}
+		sim := shadow.Simulate(ctx, job)
+		require.NoError(t, sim.Err)
+		require.True(t, sim.Skipped)
+	})
+}
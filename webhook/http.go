@@ -0,0 +1,243 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Handler is the webhook management api:
+//
+//	POST   /webhooks                                 register {url, secret, pair, account}
+//	GET    /webhooks                                 list hooks
+//	DELETE /webhooks/{id}                            delete hook
+//	GET    /webhooks/{id}/deliveries                 latest deliveries
+//	POST   /webhooks/{id}/deliveries/{id}/replay     send delivery again
+type Handler struct {
+	Store *Store
+	// Token is required as bearer token, every request is refused when empty
+	Token string
+
+	now func() time.Time
+}
+
+type hookRequest struct {
+	URL     string `json:"url"`
+	Secret  string `json:"secret"`
+	Pair    string `json:"pair"`
+	Account string `json:"account"`
+}
+
+type hookResponse struct {
+	ID      int64     `json:"id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret,omitempty"`
+	Pair    string    `json:"pair,omitempty"`
+	Account string    `json:"account,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type deliveryResponse struct {
+	ID          int64           `json:"id"`
+	Event       Event           `json:"event"`
+	Hash        string          `json:"hash"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt *time.Time      `json:"nextAttempt,omitempty"`
+	Delivered   *time.Time      `json:"delivered,omitempty"`
+	LastError   string          `json:"lastError,omitempty"`
+}
+
+func newHookResponse(h Hook) hookResponse {
+	r := hookResponse{ID: h.ID, URL: h.URL, Pair: h.Pair, Created: h.Created}
+	if h.Account != nil {
+		r.Account = h.Account.Hex()
+	}
+	return r
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.now == nil {
+		h.now = time.Now
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "webhooks" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		h.register(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.list(w, r)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		h.delete(w, r, parts[1])
+	case len(parts) == 3 && parts[2] == "deliveries" && r.Method == http.MethodGet:
+		h.deliveries(w, r, parts[1])
+	case len(parts) == 5 && parts[2] == "deliveries" && parts[4] == "replay" && r.Method == http.MethodPost:
+		h.replay(w, r, parts[1], parts[3])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
+	var req hookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		writeError(w, http.StatusBadRequest, "invalid url")
+		return
+	}
+
+	hook := Hook{URL: req.URL, Secret: req.Secret, Pair: req.Pair, Created: h.now().UTC()}
+	if req.Account != "" {
+		if !common.IsHexAddress(req.Account) {
+			writeError(w, http.StatusBadRequest, "invalid account")
+			return
+		}
+		account := common.HexToAddress(req.Account)
+		hook.Account = &account
+	}
+	if hook.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		hook.Secret = hex.EncodeToString(b)
+	}
+
+	hook, err := h.Store.Register(r.Context(), hook)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// secret is only returned on registration
+	resp := newHookResponse(hook)
+	resp.Secret = hook.Secret
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.Store.Hooks(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := []hookResponse{}
+	for _, hook := range hooks {
+		resp = append(resp, newHookResponse(hook))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func parseID(w http.ResponseWriter, s string) (int64, bool) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, hookID string) {
+	id, ok := parseID(w, hookID)
+	if !ok {
+		return
+	}
+	err := h.Store.Delete(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, "hook not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) deliveries(w http.ResponseWriter, r *http.Request, hookID string) {
+	id, ok := parseID(w, hookID)
+	if !ok {
+		return
+	}
+	deliveries, err := h.Store.Deliveries(r.Context(), id, 100)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := []deliveryResponse{}
+	for _, d := range deliveries {
+		v := deliveryResponse{
+			ID:        d.ID,
+			Event:     d.Event,
+			Hash:      d.Hash.Hex(),
+			Payload:   d.Payload,
+			Attempts:  d.Attempts,
+			LastError: d.LastError,
+		}
+		if !d.Delivered.IsZero() {
+			delivered := d.Delivered
+			v.Delivered = &delivered
+		} else if d.NextAttempt.Year() < 9999 {
+			next := d.NextAttempt
+			v.NextAttempt = &next
+		}
+		resp = append(resp, v)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) replay(w http.ResponseWriter, r *http.Request, hookID, deliveryID string) {
+	hook, ok := parseID(w, hookID)
+	if !ok {
+		return
+	}
+	id, ok := parseID(w, deliveryID)
+	if !ok {
+		return
+	}
+
+	err := h.Store.Replay(r.Context(), hook, id, h.now())
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, "delivery not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
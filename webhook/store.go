@@ -0,0 +1,310 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	// sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNotFound is returned when hook or delivery does not exist
+var ErrNotFound = errors.New("webhook: not found")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS hooks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	pair TEXT NOT NULL,
+	account TEXT NOT NULL,
+	created INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hook_id INTEGER NOT NULL REFERENCES hooks (id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt INTEGER NOT NULL,
+	delivered INTEGER,
+	last_error TEXT NOT NULL DEFAULT '',
+	UNIQUE (hook_id, event, hash)
+);
+CREATE INDEX IF NOT EXISTS deliveries_due ON deliveries (delivered, next_attempt);
+
+CREATE TABLE IF NOT EXISTS cursor (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	seq INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS progress (
+	hash TEXT PRIMARY KEY,
+	event TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS progress_event ON progress (event, hash);
+`
+
+// Hook is a registered webhook, empty pair or nil account matches every transfer
+type Hook struct {
+	ID      int64
+	URL     string
+	Secret  string
+	Pair    string
+	Account *common.Address
+	Created time.Time
+}
+
+// Match reports whether hook wants events of transfer
+func (h Hook) Match(pair string, account common.Address) bool {
+	return (h.Pair == "" || h.Pair == pair) && (h.Account == nil || *h.Account == account)
+}
+
+// Delivery is a callback of an event to a hook
+type Delivery struct {
+	ID          int64
+	HookID      int64
+	Event       Event
+	Hash        common.Hash
+	Payload     []byte
+	Attempts    int
+	NextAttempt time.Time
+	// Delivered is zero until receiver accepts the callback
+	Delivered time.Time
+	LastError string
+}
+
+// Store persists hooks and deliveries in sqlite
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens or creates sqlite database at path
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("can not open %s; %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can not create schema; %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Register saves hook and returns it with id
+func (s *Store) Register(ctx context.Context, h Hook) (Hook, error) {
+	account := ""
+	if h.Account != nil {
+		account = h.Account.Hex()
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO hooks (url, secret, pair, account, created) VALUES (?, ?, ?, ?, ?)`,
+		h.URL, h.Secret, h.Pair, account, h.Created.Unix())
+	if err != nil {
+		return Hook{}, fmt.Errorf("can not register hook; %w", err)
+	}
+	h.ID, err = res.LastInsertId()
+	return h, err
+}
+
+// Delete deletes hook and its deliveries
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM hooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("can not delete hook %d; %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Hooks returns all hooks
+func (s *Store) Hooks(ctx context.Context) ([]Hook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url, secret, pair, account, created FROM hooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("can not query hooks; %w", err)
+	}
+	defer rows.Close()
+
+	var r []Hook
+	for rows.Next() {
+		var (
+			h       Hook
+			account string
+			created int64
+		)
+		if err := rows.Scan(&h.ID, &h.URL, &h.Secret, &h.Pair, &account, &created); err != nil {
+			return nil, err
+		}
+		if account != "" {
+			a := common.HexToAddress(account)
+			h.Account = &a
+		}
+		h.Created = time.Unix(created, 0).UTC()
+		r = append(r, h)
+	}
+	return r, rows.Err()
+}
+
+// Enqueue adds delivery, returns false when event of transfer is already enqueued for hook
+func (s *Store) Enqueue(ctx context.Context, d Delivery) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO deliveries (hook_id, event, hash, payload, next_attempt) VALUES (?, ?, ?, ?, ?)`,
+		d.HookID, string(d.Event), d.Hash.Hex(), d.Payload, d.NextAttempt.Unix())
+	if err != nil {
+		return false, fmt.Errorf("can not enqueue delivery; %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Cursor returns indexing seq of the last scanned transfer
+func (s *Store) Cursor(ctx context.Context) (int64, error) {
+	var seq int64
+	err := s.db.QueryRowContext(ctx, `SELECT seq FROM cursor WHERE id = 1`).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can not get cursor; %w", err)
+	}
+	return seq, nil
+}
+
+// SetCursor saves cursor, see Cursor
+func (s *Store) SetCursor(ctx context.Context, seq int64) error {
+	if _, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO cursor (id, seq) VALUES (1, ?)`, seq); err != nil {
+		return fmt.Errorf("can not save cursor; %w", err)
+	}
+	return nil
+}
+
+// Progress returns the last event enqueued of transfer, empty when none is enqueued
+func (s *Store) Progress(ctx context.Context, hash common.Hash) (Event, error) {
+	var event string
+	err := s.db.QueryRowContext(ctx, `SELECT event FROM progress WHERE hash = ?`, hash.Hex()).Scan(&event)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("can not get progress of %s; %w", hash.Hex(), err)
+	}
+	return Event(event), nil
+}
+
+// SetProgress saves the last event enqueued of transfer
+func (s *Store) SetProgress(ctx context.Context, hash common.Hash, event Event) error {
+	if _, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO progress (hash, event) VALUES (?, ?)`, hash.Hex(), string(event)); err != nil {
+		return fmt.Errorf("can not save progress of %s; %w", hash.Hex(), err)
+	}
+	return nil
+}
+
+// Pending returns up to limit transfers after hash, ordered by hash,
+// whose last enqueued event is not final so they may still reach an event
+func (s *Store) Pending(ctx context.Context, after common.Hash, limit int) ([]common.Hash, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT hash FROM progress WHERE event NOT IN (?, ?) AND hash > ? ORDER BY hash LIMIT ?`,
+		string(EventUnlocked), string(EventFailed), after.Hex(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("can not query pending transfers; %w", err)
+	}
+	defer rows.Close()
+
+	var r []common.Hash
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		r = append(r, common.HexToHash(hash))
+	}
+	return r, rows.Err()
+}
+
+const selectDelivery = `SELECT id, hook_id, event, hash, payload, attempts, next_attempt, delivered, last_error FROM deliveries`
+
+func scanDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	defer rows.Close()
+
+	var r []Delivery
+	for rows.Next() {
+		var (
+			d           Delivery
+			event, hash string
+			next        int64
+			delivered   sql.NullInt64
+		)
+		if err := rows.Scan(&d.ID, &d.HookID, &event, &hash, &d.Payload, &d.Attempts, &next, &delivered, &d.LastError); err != nil {
+			return nil, err
+		}
+		d.Event = Event(event)
+		d.Hash = common.HexToHash(hash)
+		d.NextAttempt = time.Unix(next, 0).UTC()
+		if delivered.Valid {
+			d.Delivered = time.Unix(delivered.Int64, 0).UTC()
+		}
+		r = append(r, d)
+	}
+	return r, rows.Err()
+}
+
+// Due returns undelivered deliveries which next attempt is due, oldest first
+func (s *Store) Due(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, selectDelivery+` WHERE delivered IS NULL AND next_attempt <= ? ORDER BY id LIMIT ?`, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("can not query due deliveries; %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// Deliveries returns latest deliveries of hook
+func (s *Store) Deliveries(ctx context.Context, hookID int64, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, selectDelivery+` WHERE hook_id = ? ORDER BY id DESC LIMIT ?`, hookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can not query deliveries; %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// Delivered marks delivery accepted by receiver
+func (s *Store) Delivered(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE deliveries SET attempts = attempts + 1, delivered = ?, last_error = '' WHERE id = ?`, at.Unix(), id)
+	return err
+}
+
+// Failed records failed attempt, next is zero when delivery is given up
+func (s *Store) Failed(ctx context.Context, id int64, next time.Time, reason string) error {
+	nextAttempt := next.Unix()
+	if next.IsZero() {
+		// never due again until replayed
+		nextAttempt = 1<<62 - 1
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE deliveries SET attempts = attempts + 1, next_attempt = ?, last_error = ? WHERE id = ?`, nextAttempt, reason, id)
+	return err
+}
+
+// Replay makes delivery due again with fresh attempts, also when it was delivered
+func (s *Store) Replay(ctx context.Context, hookID, id int64, now time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE deliveries SET attempts = 0, delivered = NULL, next_attempt = ? WHERE id = ? AND hook_id = ?`, now.Unix(), id, hookID)
+	if err != nil {
+		return fmt.Errorf("can not replay delivery %d; %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"killswitch/bridge/api"
+	"killswitch/bridge/config"
+	"killswitch/bridge/indexer"
+)
+
+// Event is a transfer event sent to hooks
+type Event string
+
+const (
+	// EventLockObserved lock is indexed on source chain
+	EventLockObserved Event = "lock.observed"
+	// EventLockConfirmed lock reached required confirmations
+	EventLockConfirmed Event = "lock.confirmed"
+	// EventUnlocked transfer is unlocked on destination chain
+	EventUnlocked Event = "transfer.unlocked"
+	// EventFailed transfer is unlocked with different account or amount
+	EventFailed Event = "transfer.failed"
+)
+
+// Headers of callback request
+const (
+	HeaderEvent     = "X-Bridge-Event"
+	HeaderDelivery  = "X-Bridge-Delivery"
+	HeaderTimestamp = "X-Bridge-Timestamp"
+	HeaderSignature = "X-Bridge-Signature"
+)
+
+// Payload is the callback body
+type Payload struct {
+	Event    Event        `json:"event"`
+	Transfer api.Transfer `json:"transfer"`
+}
+
+// events returns events reached by transfer in order
+func events(t api.Transfer) []Event {
+	r := []Event{EventLockObserved}
+	if t.Status != api.StatusPendingConfirmations {
+		r = append(r, EventLockConfirmed)
+	}
+	switch t.Status {
+	case api.StatusUnlocked:
+		r = append(r, EventUnlocked)
+	case api.StatusFailed:
+		r = append(r, EventFailed)
+	}
+	return r
+}
+
+// Sign returns hex hmac-sha256 of "<timestamp>.<body>" with secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature of callback request, receivers should also reject old timestamps
+func Verify(secret string, timestamp, signature string, body []byte) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// Dispatcher enqueues transfer events for matching hooks and delivers them with retries
+type Dispatcher struct {
+	Store  *Store
+	DB     *indexer.DB
+	Chains map[string]config.Chain
+	Heads  api.Heads
+
+	Client *http.Client
+	// MaxAttempts before delivery is given up, default 10
+	MaxAttempts int
+	// Backoff is delay after first failed attempt, doubled on each attempt, default 30s
+	Backoff time.Duration
+	// Page is number of transfers read at once, default 500
+	Page int
+
+	now func() time.Time
+}
+
+func (d *Dispatcher) init() {
+	if d.Client == nil {
+		d.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if d.MaxAttempts == 0 {
+		d.MaxAttempts = 10
+	}
+	if d.Backoff == 0 {
+		d.Backoff = 30 * time.Second
+	}
+	if d.Page == 0 {
+		d.Page = 500
+	}
+	if d.now == nil {
+		d.now = time.Now
+	}
+}
+
+// Scan enqueues events of transfers for matching hooks, hooks only receive transfers
+// locked after they were registered. New transfers are scanned in indexing order after
+// the cursor, transfers which may still reach an event are revisited by their progress
+func (d *Dispatcher) Scan(ctx context.Context) (int, error) {
+	d.init()
+
+	hooks, err := d.Store.Hooks(ctx)
+	if err != nil || len(hooks) == 0 {
+		return 0, err
+	}
+	cursor, err := d.Store.Cursor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	heads := &api.HeadCache{Heads: d.Heads}
+	n := 0
+	for {
+		transfers, err := d.DB.Transfers(ctx, indexer.Query{After: &cursor, Limit: d.Page})
+		if err != nil {
+			return n, err
+		}
+		for _, t := range transfers {
+			enqueued, err := d.enqueue(ctx, hooks, heads, t)
+			n += enqueued
+			if err != nil {
+				return n, err
+			}
+			cursor = t.Seq
+		}
+		if len(transfers) > 0 {
+			if err := d.Store.SetCursor(ctx, cursor); err != nil {
+				return n, err
+			}
+		}
+		if len(transfers) < d.Page {
+			break
+		}
+	}
+
+	var after common.Hash
+	for {
+		pending, err := d.Store.Pending(ctx, after, d.Page)
+		if err != nil {
+			return n, err
+		}
+		for _, hash := range pending {
+			after = hash
+			t, err := d.DB.Transfer(ctx, hash)
+			if errors.Is(err, indexer.ErrNotFound) {
+				// lock was reorged out, it is scanned again when indexed
+				continue
+			}
+			if err != nil {
+				return n, err
+			}
+			enqueued, err := d.enqueue(ctx, hooks, heads, t)
+			n += enqueued
+			if err != nil {
+				return n, err
+			}
+		}
+		if len(pending) < d.Page {
+			break
+		}
+	}
+	return n, nil
+}
+
+// enqueue enqueues events of transfer after its last enqueued event for matching hooks
+func (d *Dispatcher) enqueue(ctx context.Context, hooks []Hook, heads *api.HeadCache, t indexer.Transfer) (int, error) {
+	var matched []Hook
+	for _, h := range hooks {
+		if h.Match(t.Lock.Pair, t.Lock.Account) && !t.Lock.Time.Before(h.Created) {
+			matched = append(matched, h)
+		}
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	head, err := heads.Get(ctx, t.Lock.Chain)
+	if err != nil {
+		return 0, err
+	}
+	transfer := api.NewTransfer(t, d.Chains, head)
+	last, err := d.Store.Progress(ctx, t.Lock.Hash)
+	if err != nil {
+		return 0, err
+	}
+
+	reached := events(transfer)
+	next := 0
+	for i, event := range reached {
+		if event == last {
+			next = i + 1
+		}
+	}
+	if next == len(reached) {
+		return 0, nil
+	}
+
+	n := 0
+	for _, event := range reached[next:] {
+		payload, err := json.Marshal(Payload{Event: event, Transfer: transfer})
+		if err != nil {
+			return n, err
+		}
+		for _, h := range matched {
+			ok, err := d.Store.Enqueue(ctx, Delivery{HookID: h.ID, Event: event, Hash: t.Lock.Hash, Payload: payload, NextAttempt: d.now()})
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+	}
+	return n, d.Store.SetProgress(ctx, t.Lock.Hash, reached[len(reached)-1])
+}
+
+// Deliver sends due deliveries, returns number of delivered callbacks
+func (d *Dispatcher) Deliver(ctx context.Context) (int, error) {
+	d.init()
+
+	due, err := d.Store.Due(ctx, d.now(), 100)
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+	hooks, err := d.Store.Hooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	byID := map[int64]Hook{}
+	for _, h := range hooks {
+		byID[h.ID] = h
+	}
+
+	n := 0
+	for _, delivery := range due {
+		hook, ok := byID[delivery.HookID]
+		if !ok {
+			continue
+		}
+
+		if err := d.send(ctx, hook, delivery); err != nil {
+			next := time.Time{}
+			if attempt := delivery.Attempts + 1; attempt < d.MaxAttempts {
+				next = d.now().Add(d.Backoff << (attempt - 1))
+			}
+			if err := d.Store.Failed(ctx, delivery.ID, next, err.Error()); err != nil {
+				return n, err
+			}
+			continue
+		}
+		if err := d.Store.Delivered(ctx, delivery.ID, d.now()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook Hook, delivery Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return nil
+}
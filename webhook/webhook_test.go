@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"killswitch/bridge/config"
+	"killswitch/bridge/indexer"
+)
+
+const pair = "DAI <=> kDAI"
+
+type receiver struct {
+	sync.Mutex
+	secret string
+	fail   int
+	events []Event
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	body, _ := ioutil.ReadAll(req.Body)
+	if !Verify(r.secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.fail > 0 {
+		r.fail--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil || string(p.Event) != req.Header.Get(HeaderEvent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, p.Event)
+}
+
+func do(t *testing.T, method, url, token string, body string, code int, v interface{}) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, code, resp.StatusCode)
+	if v != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := indexer.Open(filepath.Join(t.TempDir(), "transfers.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "webhooks.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	account := common.HexToAddress("0xa4e3a7DE03D4138620EEc38766C06d175dF64963")
+	other := common.HexToAddress("0x2b2D2cE8dA0D3E0c0a0B3e4C5bA8fEc3F1b4C2d1")
+
+	// lock 1 before hook is registered, lock 2 is unlocked, lock 3 is not confirmed, lock 4 of other account
+	var locks []indexer.Lock
+	for i, a := range []common.Address{account, account, account, other} {
+		locks = append(locks, indexer.Lock{
+			Hash:    common.Hash{byte(i + 1)},
+			Pair:    pair,
+			Chain:   "bsc",
+			Account: a,
+			Amount:  big.NewInt(100),
+			Fee:     big.NewInt(1),
+			Block:   uint64(10 + i),
+			Time:    now.Add(time.Duration(i-1) * time.Minute),
+		})
+	}
+	require.NoError(t, db.Save(ctx, pair, config.SideSource, 0, 20, locks, nil))
+	require.NoError(t, db.Save(ctx, pair, config.SideDestination, 0, 20, nil, []indexer.Unlock{
+		{Hash: locks[1].Hash, Pair: pair, Chain: "bkc", Account: account, Amount: big.NewInt(100), Tx: common.Hash{0xa1}, Block: 5, Time: now.Add(time.Minute)},
+	}))
+
+	rcv := &receiver{fail: 1}
+	callback := httptest.NewServer(rcv)
+	defer callback.Close()
+
+	open := httptest.NewServer(&Handler{Store: store, now: clock})
+	defer open.Close()
+	do(t, http.MethodGet, open.URL+"/webhooks", "", "", http.StatusUnauthorized, nil)
+
+	admin := httptest.NewServer(&Handler{Store: store, Token: "token", now: clock})
+	defer admin.Close()
+
+	do(t, http.MethodGet, admin.URL+"/webhooks", "wrong", "", http.StatusUnauthorized, nil)
+	do(t, http.MethodPost, admin.URL+"/webhooks", "token", `{"url":"ftp://example"}`, http.StatusBadRequest, nil)
+
+	var hook hookResponse
+	do(t, http.MethodPost, admin.URL+"/webhooks", "token",
+		fmt.Sprintf(`{"url":%q,"pair":%q,"account":%q}`, callback.URL, pair, account.Hex()), http.StatusCreated, &hook)
+	require.Len(t, hook.Secret, 64)
+	rcv.secret = hook.Secret
+
+	var hooks []hookResponse
+	do(t, http.MethodGet, admin.URL+"/webhooks", "token", "", http.StatusOK, &hooks)
+	require.Len(t, hooks, 1)
+	require.Empty(t, hooks[0].Secret)
+	require.Equal(t, account.Hex(), hooks[0].Account)
+
+	d := &Dispatcher{
+		Store: store,
+		DB:    db,
+		Chains: map[string]config.Chain{
+			"bsc": {Name: "bsc", Confirmations: 3},
+			"bkc": {Name: "bkc", Confirmations: 3},
+		},
+		Heads: func(ctx context.Context, chain string) (uint64, error) {
+			return 12, nil
+		},
+		// transfers are read two at a time
+		Page: 2,
+		now:  clock,
+	}
+
+	// lock 2: observed, confirmed, unlocked; lock 3: observed
+	n, err := d.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	n, err = d.Scan(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	// every transfer is scanned, lock 3 waits for confirmations
+	cursor, err := store.Cursor(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), cursor)
+	pending, err := store.Pending(ctx, common.Hash{}, 10)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{locks[2].Hash}, pending)
+	last, err := store.Progress(ctx, locks[1].Hash)
+	require.NoError(t, err)
+	require.Equal(t, EventUnlocked, last)
+
+	// first callback fails and is retried after backoff
+	n, err = d.Deliver(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	n, err = d.Deliver(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	now = now.Add(d.Backoff)
+	n, err = d.Deliver(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.ElementsMatch(t, []Event{EventLockObserved, EventLockConfirmed, EventUnlocked, EventLockObserved}, rcv.events)
+
+	var deliveries []deliveryResponse
+	do(t, http.MethodGet, fmt.Sprintf("%s/webhooks/%d/deliveries", admin.URL, hook.ID), "token", "", http.StatusOK, &deliveries)
+	require.Len(t, deliveries, 4)
+	first := deliveries[3]
+	require.Equal(t, 2, first.Attempts)
+	require.NotNil(t, first.Delivered)
+
+	// lock 3 is confirmed with new head
+	d.Heads = func(ctx context.Context, chain string) (uint64, error) {
+		return 20, nil
+	}
+	n, err = d.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	do(t, http.MethodPost, fmt.Sprintf("%s/webhooks/%d/deliveries/%d/replay", admin.URL, hook.ID, first.ID), "token", "", http.StatusAccepted, nil)
+	do(t, http.MethodPost, fmt.Sprintf("%s/webhooks/%d/deliveries/999/replay", admin.URL, hook.ID), "token", "", http.StatusNotFound, nil)
+
+	n, err = d.Deliver(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Len(t, rcv.events, 6)
+
+	// lock 3 is unlocked, every transfer reached its last event
+	require.NoError(t, db.Save(ctx, pair, config.SideDestination, 21, 30, nil, []indexer.Unlock{
+		{Hash: locks[2].Hash, Pair: pair, Chain: "bkc", Account: account, Amount: big.NewInt(100), Tx: common.Hash{0xa2}, Block: 25, Time: now.Add(2 * time.Minute)},
+	}))
+	n, err = d.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	pending, err = store.Pending(ctx, common.Hash{}, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	do(t, http.MethodDelete, fmt.Sprintf("%s/webhooks/%d", admin.URL, hook.ID), "token", "", http.StatusNoContent, nil)
+	do(t, http.MethodDelete, fmt.Sprintf("%s/webhooks/%d", admin.URL, hook.ID), "token", "", http.StatusNotFound, nil)
+}
+
+func TestGiveUp(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := OpenStore(filepath.Join(t.TempDir(), "webhooks.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer callback.Close()
+
+	now := time.Unix(1000, 0)
+	hook, err := store.Register(ctx, Hook{URL: callback.URL, Secret: "secret", Created: now})
+	require.NoError(t, err)
+	_, err = store.Enqueue(ctx, Delivery{HookID: hook.ID, Event: EventLockObserved, Payload: []byte(`{}`), NextAttempt: now})
+	require.NoError(t, err)
+
+	d := &Dispatcher{Store: store, MaxAttempts: 3, Backoff: time.Second, now: func() time.Time { return now }}
+	for i := 0; i < 5; i++ {
+		_, err := d.Deliver(ctx)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+	}
+
+	deliveries, err := store.Deliveries(ctx, hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, 3, deliveries[0].Attempts)
+	require.Contains(t, deliveries[0].LastError, "500")
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"lock.observed"}`)
+	signature := Sign("secret", 1622505600, body)
+
+	require.True(t, Verify("secret", "1622505600", signature, body))
+	require.False(t, Verify("secret", "1622505601", signature, body))
+	require.False(t, Verify("other", "1622505600", signature, body))
+	require.False(t, Verify("secret", "1622505600", signature, append(body, ' ')))
+	require.False(t, Verify("secret", "now", signature, body))
+}